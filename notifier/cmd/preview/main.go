@@ -0,0 +1,63 @@
+// Command preview renders a notification template against sample data.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/templates"
+	"github.com/CP-Payne/taskflow/pkg/events"
+)
+
+func main() {
+	var eventType, lang, dir, part string
+	flag.StringVar(&eventType, "event", events.TypeTaskAssigned, "Event type to render")
+	flag.StringVar(&lang, "lang", templates.DefaultLanguage, "Template language")
+	flag.StringVar(&dir, "dir", "", "Optional directory with template overrides")
+	flag.StringVar(&part, "part", "all", "Part to print: all, subject, text or html")
+	flag.Parse()
+
+	samples := templates.Samples()
+	data, ok := samples[eventType]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "no sample data for event type %q, available: %v\n", eventType, sampleTypes(samples))
+		os.Exit(1)
+	}
+
+	renderer, err := templates.New(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load templates: %v\n", err)
+		os.Exit(1)
+	}
+
+	msg, err := renderer.Render(eventType, lang, data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to render template: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch part {
+	case "subject":
+		fmt.Println(msg.Subject)
+	case "text":
+		fmt.Print(msg.Text)
+	case "html":
+		fmt.Print(msg.HTML)
+	case "all":
+		fmt.Printf("Subject: %s\n\n--- text/plain ---\n%s\n--- text/html ---\n%s", msg.Subject, msg.Text, msg.HTML)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown part %q\n", part)
+		os.Exit(1)
+	}
+}
+
+func sampleTypes(samples map[string]any) []string {
+	types := make([]string, 0, len(samples))
+	for t := range samples {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
@@ -3,21 +3,27 @@ package main
 import (
 	"context"
 	"errors"
-	"flag"
 	"fmt"
 	"os"
-	"os/signal"
-	"sync"
-	"syscall"
 	"time"
+	_ "time/tzdata" // quiet hours resolve user time zones
 
+	"github.com/CP-Payne/taskflow/notifier/internal/config"
 	"github.com/CP-Payne/taskflow/notifier/internal/gateway/user"
+	grpchandler "github.com/CP-Payne/taskflow/notifier/internal/handler/grpc"
+	"github.com/CP-Payne/taskflow/notifier/internal/inbox"
 	"github.com/CP-Payne/taskflow/notifier/internal/notification"
+	redisrepo "github.com/CP-Payne/taskflow/notifier/internal/repository/redis"
 	"github.com/CP-Payne/taskflow/notifier/internal/service"
 	"github.com/CP-Payne/taskflow/notifier/internal/subscriber"
-	"github.com/CP-Payne/taskflow/pkg/discovery"
-	"github.com/CP-Payne/taskflow/pkg/discovery/consul"
-	"github.com/joho/godotenv"
+	"github.com/CP-Payne/taskflow/notifier/internal/templates"
+	"github.com/CP-Payne/taskflow/notifier/internal/webhook"
+	pkgconfig "github.com/CP-Payne/taskflow/pkg/config"
+	"github.com/CP-Payne/taskflow/pkg/discovery/backend"
+	grpcApi "github.com/CP-Payne/taskflow/pkg/gen/notifier/v1"
+	"github.com/CP-Payne/taskflow/pkg/grpcauth"
+	"github.com/CP-Payne/taskflow/pkg/secrets"
+	"github.com/CP-Payne/taskflow/pkg/server"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
@@ -27,44 +33,43 @@ const (
 	shutdownTimeout     = 15 * time.Second
 	healthCheckInterval = 5 * time.Second
 	healthCheckTimeout  = 2 * time.Second
+	schedulerInterval   = 30 * time.Second
 )
 
 func main() {
 	logger := zap.Must(zap.NewProduction()).Sugar()
 	defer logger.Sync()
 
-	var port int
-	flag.IntVar(&port, "port", 9003, "Service identification port (not listening)")
-	flag.Parse()
-	logger.Infof("Starting the notifier service on port %d", port)
-
-	ctx, cancel := context.WithCancel(context.Background())
-
-	if err := godotenv.Load("./config/.env"); err != nil {
-		logger.Fatalw("Failed to load global config", "error", err)
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, pkgconfig.ErrPrinted) {
+		return
+	} else if err != nil {
+		logger.Fatalw("Invalid configuration", "error", err)
 	}
+	logger.Infof("Starting the notifier service on port %d", cfg.Port)
 
-	if err := godotenv.Load("./notifier/internal/config/.env"); err != nil {
-		logger.Fatalw("Failed to load notifer service config", "error", err)
-	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	// TODO: Add registry addr to global config
-	registry, err := consul.NewRegistry("localhost:8500")
+	registry, err := backend.New(cfg.Discovery)
 	if err != nil {
 		logger.Fatalw("Failed to create service discovery registry", "error", err)
 	}
+	logger.Infow("Using service discovery backend", "backend", cfg.Discovery.Backend)
 
-	instanceID := discovery.GenerateInstanceID(serviceName)
-	serviceAddr := fmt.Sprintf("localhost:%d", port)
-
-	// TODO: Add to .env and load from config
-	redisAddr := os.Getenv("REDIS_NOTIFIER_ADDR")
-	if redisAddr == "" {
-		logger.Fatalw("Redis address not configured (REDIS_NOTIFIER_ADDR)")
+	// Secret references in the configuration are read from the secrets
+	// providers
+	secretsProvider, err := secrets.New(ctx, cfg.Secrets, logger)
+	if err != nil {
+		logger.Fatalw("Failed to create secrets provider", "error", err)
+	}
+	if err := pkgconfig.ResolveSecrets(&cfg, secrets.Resolver(ctx, secretsProvider)); err != nil {
+		logger.Fatalw("Failed to resolve secrets", "error", err)
 	}
 
 	rdb := redis.NewClient(&redis.Options{
-		Addr: redisAddr,
+		Addr:     cfg.RedisAddr,
+		Password: cfg.RedisPassword,
 	})
 	pingCtx, pingCancel := context.WithTimeout(ctx, 5*time.Second)
 
@@ -72,98 +77,95 @@ func main() {
 	// Release context resources
 	pingCancel()
 	if err != nil {
-		logger.Fatalw("Failed to connect to Redis", "error", err, "address", redisAddr)
+		logger.Fatalw("Failed to connect to Redis", "error", err, "address", cfg.RedisAddr)
 	}
 	defer rdb.Close()
-	logger.Infow("Connected to Redis", "address", redisAddr)
+	logger.Infow("Connected to Redis", "address", cfg.RedisAddr)
 
-	// Gmail sender dependencies
-	gmailSource := os.Getenv("GMAIL_SOURCE")
-	gmailAppPass := os.Getenv("GMAIL_APP_PASSWORD")
-	if gmailSource == "" || gmailAppPass == "" {
-		logger.Warnw("Gmail credentials not fully configured")
+	notificationSender, err := newSender(cfg.Sender, logger)
+	if err != nil {
+		logger.Fatalw("Failed to create notification sender", "error", err)
 	}
 
-	userGtw := user.NewGateway(registry, logger)
-	notificationSender := notification.NewEmailSender(gmailSource, gmailAppPass, logger)
-	notificationSrv := service.NewNotificationService(userGtw, notificationSender)
-	redisSubscriber := subscriber.NewRedisSubscriber(rdb, notificationSrv, logger)
-
-	// Register to consul
-	registerCtx, registerCancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer registerCancel()
-	if err := registry.Register(registerCtx, instanceID, serviceName, serviceAddr); err != nil {
-		logger.Fatalw("failed registering service to consul", "error", err)
+	renderer, err := templates.New(cfg.TemplateDir)
+	if err != nil {
+		logger.Fatalw("Failed to load notification templates", "error", err)
 	}
-	logger.Infow("Service registered successfully", "id", instanceID, "name", serviceName, "address", serviceAddr)
-
-	go func(ctx context.Context) {
-		ticker := time.NewTicker(healthCheckInterval)
-		defer ticker.Stop()
-		logger.Info("Starting health reporter...")
-		for {
-			select {
-			case <-ticker.C:
-				reportCtx, reportCancel := context.WithTimeout(context.Background(), healthCheckTimeout)
-				if err := registry.ReportHealthState(reportCtx, instanceID, serviceName); err != nil {
-					logger.Warnw("failed to report healthy state", "error", err, "instanceID", instanceID)
-				}
-				reportCancel()
-			case <-ctx.Done():
-				logger.Info("Stopping health reporter due to context cancellation.")
-				return
-			}
-		}
-	}(ctx)
 
-	var wg sync.WaitGroup
+	userGtw, err := user.NewGateway(registry, user.DefaultCacheTTL, cfg.AuthCacheTTL, user.ClientConfig(), logger)
+	if err != nil {
+		logger.Fatalw("Failed to create user service client", "error", err)
+	}
+	defer userGtw.Close()
+	preferencesRepo := redisrepo.NewPreferencesRepository(rdb)
+	heldRepo := redisrepo.NewHeldNotificationRepository(rdb)
+	digestRepo := redisrepo.NewDigestRepository(rdb)
+	notificationSrv := service.NewNotificationService(userGtw, notificationSender, renderer, preferencesRepo, heldRepo, digestRepo, logger)
+	preferencesSrv := service.NewPreferencesService(preferencesRepo)
+
+	webhookRepo := redisrepo.NewWebhookRepository(rdb)
+	webhookDispatcher := webhook.NewDispatcher(webhookRepo, logger)
+	webhookSrv := service.NewWebhookService(webhookRepo, webhookDispatcher, logger)
+
+	inboxSrv := service.NewInboxService(redisrepo.NewInboxRepository(rdb), inbox.NewBroker(inbox.DefaultBufferSize), renderer, logger)
+
+	redisSubscriber := subscriber.NewRedisSubscriber(rdb, notificationSrv, webhookSrv, inboxSrv, userGtw, logger)
+
+	// Calls act for the user whose session JWT they carry, checked with the
+	// user service. Access tokens are refused, since their scopes only cover
+	// tasks.
+	sessionOnly := grpcauth.Rule{SessionOnly: true}
+	authInterceptor := grpcauth.New(userGtw, map[string]grpcauth.Rule{
+		grpcApi.Webhooks_RegisterEndpoint_FullMethodName:     sessionOnly,
+		grpcApi.Webhooks_ListEndpoints_FullMethodName:        sessionOnly,
+		grpcApi.Webhooks_DeleteEndpoint_FullMethodName:       sessionOnly,
+		grpcApi.Webhooks_ListDeliveries_FullMethodName:       sessionOnly,
+		grpcApi.Preferences_GetPreferences_FullMethodName:    sessionOnly,
+		grpcApi.Preferences_UpdatePreferences_FullMethodName: sessionOnly,
+		grpcApi.Inbox_ListNotifications_FullMethodName:       sessionOnly,
+		grpcApi.Inbox_MarkRead_FullMethodName:                sessionOnly,
+		grpcApi.Inbox_MarkAllRead_FullMethodName:             sessionOnly,
+		grpcApi.Inbox_UnreadCount_FullMethodName:             sessionOnly,
+		grpcApi.Inbox_WatchNotifications_FullMethodName:      sessionOnly,
+	}, logger)
+
+	app := server.New(server.Config{
+		Name:            serviceName,
+		Port:            cfg.Port,
+		Registry:        registry,
+		RegisterOptions: cfg.Discovery.RegisterOptions(),
+		HealthInterval:  healthCheckInterval,
+		CheckTimeout:    healthCheckTimeout,
+		ShutdownTimeout: shutdownTimeout,
+	}, logger, authInterceptor.ServerOptions()...)
+	grpcApi.RegisterWebhooksServer(app.GRPC(), grpchandler.NewWebhookHandler(webhookSrv, logger))
+	grpcApi.RegisterPreferencesServer(app.GRPC(), grpchandler.NewPreferencesHandler(preferencesSrv, logger))
+	grpcApi.RegisterInboxServer(app.GRPC(), grpchandler.NewInboxHandler(inboxSrv, logger))
+
+	app.AddCheck("redis", func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
+	})
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		logger.Info("Starting Redis subscriber...")
-		if err = redisSubscriber.SubscribeAndProcess(ctx); err != nil && !errors.Is(err, context.Canceled) {
-			// Log unexpected errors. context.Canceled is expected on graceful shutdown.
+	// Background tasks stop once the gRPC server no longer takes calls.
+	app.Go("redis subscriber", func(ctx context.Context) {
+		if err := redisSubscriber.SubscribeAndProcess(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			// context.Canceled is expected on graceful shutdown.
 			logger.Errorw("Redis subscriber stopped unexpectedly", "error", err)
-		} else {
-			logger.Info("Redis subscriber processing stopped.")
 		}
-	}()
-
-	// --- Signal Handling ---
-	shutdownChan := make(chan os.Signal, 1)
-	signal.Notify(shutdownChan, syscall.SIGINT, syscall.SIGTERM)
-
-	// --- Wait for shutdown signal ---
-	sig := <-shutdownChan
-	logger.Infow("Received shutdown signal", "signal", sig)
-
-	// --- Initiate Graceful Shutdown ---
-	logger.Info("Initiating graceful shutdown...")
-
-	// 1. Create a context with timeout for shutdown cleanup
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
-	defer shutdownCancel()
-
-	// 2. Deregister from Consul *first*
-	logger.Info("Deregistering service from Consul...")
-	if err := registry.Deregister(shutdownCtx, instanceID, serviceName); err != nil {
-		logger.Errorw("failed to deregister service during shutdown", "error", err, "instanceID", instanceID)
-		// Log error but continue shutdown
-	} else {
-		logger.Info("Service deregistered successfully.")
-	}
-
-	// 3. Signal background tasks (subscriber, health check) to stop
-	logger.Info("Signaling background tasks to stop...")
-	cancel() // Cancel the main context
+	})
+	app.Go("webhook dispatcher", func(ctx context.Context) {
+		webhookDispatcher.Run(ctx)
+	})
+	app.Go("notification scheduler", func(ctx context.Context) {
+		notificationSrv.RunScheduler(ctx, schedulerInterval)
+	})
+	app.Go("vault token renewal", secretsProvider.RenewTokens)
 
-	// 4. Wait for the subscriber goroutine (and potentially others) to finish
-	logger.Info("Waiting for background tasks to finish...")
-	wg.Wait() // Block until wg.Done() is called for all Add(1) calls
-	logger.Info("Background tasks finished.")
+	if err := app.Run(ctx); err != nil {
+		logger.Errorw("Notifier service stopped with error", "error", err)
+	}
 
-	// 5. Close Redis connection explicitly (defer also works, but this is cleaner timing)
+	// Close Redis connection explicitly (defer also works, but this is cleaner timing)
 	logger.Info("Closing Redis connection...")
 	if err := rdb.Close(); err != nil {
 		logger.Errorw("Error closing Redis connection", "error", err)
@@ -171,3 +173,42 @@ func main() {
 
 	logger.Info("Shutdown complete.")
 }
+
+// newSender selects the notification sender: smtp, mbox to append to a local
+// file, or capture to keep messages in memory.
+func newSender(cfg config.Sender, logger *zap.SugaredLogger) (notification.Sender, error) {
+	switch cfg.Kind {
+	case config.SenderSMTP:
+		if !cfg.EmailConfigured() {
+			logger.Warnw("Neither SMTP_HOST nor Gmail credentials are configured, emails will fail to send")
+			return notification.UnconfiguredSender{}, nil
+		}
+		return notification.NewEmailSender(smtpConfig(cfg), logger)
+	case config.SenderMbox:
+		logger.Infow("Writing notifications to mbox", "path", cfg.MboxPath)
+		return notification.NewFileSender(cfg.MboxPath, cfg.SMTP.From, logger), nil
+	case config.SenderCapture:
+		logger.Warnw("Notifications are captured in memory and will not be delivered")
+		return notification.NewCaptureSender(), nil
+	default:
+		return nil, fmt.Errorf("unknown NOTIFIER_SENDER %q", cfg.Kind)
+	}
+}
+
+func smtpConfig(cfg config.Sender) notification.SMTPConfig {
+	// Fall back to the Gmail settings used before SMTP was configurable.
+	if cfg.SMTP.Host == "" {
+		return notification.GmailConfig(cfg.Gmail.Source, cfg.Gmail.AppPassword)
+	}
+
+	return notification.SMTPConfig{
+		Host:               cfg.SMTP.Host,
+		Port:               cfg.SMTP.Port,
+		Username:           cfg.SMTP.Username,
+		Password:           cfg.SMTP.Password,
+		From:               cfg.SMTP.From,
+		TLSMode:            notification.TLSMode(cfg.SMTP.TLS),
+		Auth:               notification.AuthMethod(cfg.SMTP.Auth),
+		InsecureSkipVerify: cfg.SMTP.InsecureSkipVerify,
+	}
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/gateway/user"
+	pkgconfig "github.com/CP-Payne/taskflow/pkg/config"
+	"github.com/CP-Payne/taskflow/pkg/discovery/backend"
+	"github.com/CP-Payne/taskflow/pkg/secrets"
+)
+
+// envFiles are read for variables missing from the environment.
+var envFiles = []string{"config/.env", "notifier/internal/config/.env"}
+
+// Senders selectable with NOTIFIER_SENDER.
+const (
+	SenderSMTP    = "smtp"
+	SenderMbox    = "mbox"
+	SenderCapture = "capture"
+)
+
+type Config struct {
+	Port          int            `yaml:"port" flag:"port" usage:"API handler port"`
+	Discovery     backend.Config `yaml:"discovery"`
+	RedisAddr     string         `yaml:"redis_addr" env:"REDIS_NOTIFIER_ADDR" required:"true"`
+	RedisPassword string         `yaml:"redis_password" env:"REDIS_PASSWORD" secret:"true"`
+	// AuthCacheTTL is how long bearer tokens verified by the user service
+	// are trusted, and so how long a revoked token keeps working.
+	AuthCacheTTL time.Duration `yaml:"auth_cache_ttl" env:"AUTH_CACHE_TTL"`
+	// Secrets resolves secret references such as
+	// "secret:notifier/redis#password".
+	Secrets secrets.Config `yaml:"secrets"`
+	// TemplateDir optionally holds templates overriding the embedded ones.
+	TemplateDir string `yaml:"template_dir" env:"NOTIFIER_TEMPLATE_DIR"`
+	Sender      Sender `yaml:"sender"`
+}
+
+type Sender struct {
+	// Kind is smtp, mbox to append to a local file, or capture to keep
+	// messages in memory.
+	Kind     string `yaml:"kind" env:"NOTIFIER_SENDER"`
+	MboxPath string `yaml:"mbox_path" env:"NOTIFIER_MBOX_PATH"`
+	SMTP     SMTP   `yaml:"smtp"`
+	// Gmail is used when no SMTP host is configured.
+	Gmail Gmail `yaml:"gmail"`
+}
+
+type SMTP struct {
+	Host     string `yaml:"host" env:"SMTP_HOST"`
+	Port     int    `yaml:"port" env:"SMTP_PORT"`
+	Username string `yaml:"username" env:"SMTP_USERNAME"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD" secret:"true"`
+	// From defaults to Username when empty.
+	From               string `yaml:"from" env:"SMTP_FROM"`
+	TLS                string `yaml:"tls" env:"SMTP_TLS" usage:"starttls or tls"`
+	Auth               string `yaml:"auth" env:"SMTP_AUTH" usage:"plain, login, cram-md5 or none"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify" env:"SMTP_INSECURE_SKIP_VERIFY"`
+}
+
+type Gmail struct {
+	Source      string `yaml:"source" env:"GMAIL_SOURCE"`
+	AppPassword string `yaml:"app_password" env:"GMAIL_APP_PASSWORD" secret:"true"`
+}
+
+// EmailConfigured reports whether SMTP_HOST or the Gmail credentials are
+// set, so the smtp sender can deliver email.
+func (s Sender) EmailConfigured() bool {
+	return s.SMTP.Host != "" || (s.Gmail.Source != "" && s.Gmail.AppPassword != "")
+}
+
+func (s Sender) Validate() error {
+	switch s.Kind {
+	case SenderSMTP, SenderMbox, SenderCapture:
+		return nil
+	default:
+		return fmt.Errorf("unknown NOTIFIER_SENDER %q", s.Kind)
+	}
+}
+
+func Default() Config {
+	return Config{
+		Port:         9003,
+		Discovery:    backend.DefaultConfig(),
+		AuthCacheTTL: user.DefaultAuthCacheTTL,
+		Sender: Sender{
+			Kind:     SenderSMTP,
+			MboxPath: "notifier.mbox",
+			SMTP:     SMTP{Port: 587},
+		},
+	}
+}
+
+// Load returns the configuration of the notifier service. It returns
+// pkgconfig.ErrPrinted when run with --print-config.
+func Load(args []string) (Config, error) {
+	cfg := Default()
+	err := pkgconfig.New("notifier", envFiles...).Load(&cfg, args)
+	return cfg, err
+}
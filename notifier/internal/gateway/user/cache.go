@@ -0,0 +1,94 @@
+package user
+
+import (
+	"sync"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/google/uuid"
+)
+
+// maxCacheEntries bounds the memory held by each cache, however many users
+// or tokens pass through it.
+const maxCacheEntries = 10000
+
+type cacheEntry struct {
+	user      model.User
+	expiresAt time.Time
+}
+
+// cache keeps user details for a fixed TTL, or until invalidated.
+type cache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[uuid.UUID]cacheEntry
+	now     func() time.Time
+	// epoch counts invalidations, so details fetched before one are not
+	// cached after it
+	epoch uint64
+}
+
+func newCache(ttl time.Duration) *cache {
+	return &cache{
+		ttl:     ttl,
+		entries: make(map[uuid.UUID]cacheEntry),
+		now:     time.Now,
+	}
+}
+
+func (c *cache) get(userID uuid.UUID) (*model.User, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[userID]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(e.expiresAt) {
+		delete(c.entries, userID)
+		return nil, false
+	}
+	user := e.user
+	return &user, true
+}
+
+// current returns the epoch to pass to set for details fetched from now on.
+func (c *cache) current() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.epoch
+}
+
+// set caches user unless the cache was invalidated since epoch, in which
+// case user may predate the change.
+func (c *cache) set(user *model.User, epoch uint64) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.epoch != epoch {
+		return
+	}
+	// Drop expired entries now and then, since users who are not notified
+	// again are not looked up again
+	now := c.now()
+	if _, ok := c.entries[user.UserID]; !ok && len(c.entries) >= maxCacheEntries {
+		for id, e := range c.entries {
+			if !now.Before(e.expiresAt) {
+				delete(c.entries, id)
+			}
+		}
+		if len(c.entries) >= maxCacheEntries {
+			return
+		}
+	}
+	c.entries[user.UserID] = cacheEntry{user: *user, expiresAt: now.Add(c.ttl)}
+}
+
+func (c *cache) invalidate(userID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.epoch++
+	delete(c.entries, userID)
+}
@@ -2,62 +2,125 @@ package user
 
 import (
 	"context"
-	"math/rand"
+	"fmt"
+	"time"
 
 	"github.com/CP-Payne/taskflow/notifier/internal/model"
 	"github.com/CP-Payne/taskflow/pkg/discovery"
+	"github.com/CP-Payne/taskflow/pkg/discovery/grpcresolver"
 	gen "github.com/CP-Payne/taskflow/pkg/gen/user/v1"
+	"github.com/CP-Payne/taskflow/pkg/grpcauth"
+	"github.com/CP-Payne/taskflow/pkg/grpcclient"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
-type Gateway struct {
-	logger   *zap.SugaredLogger
-	registry discovery.Registry
-}
+// DefaultCacheTTL is how long user details are cached when no user.updated
+// event invalidates them first.
+const DefaultCacheTTL = 5 * time.Minute
 
-func NewGateway(registry discovery.Registry, logger *zap.SugaredLogger) *Gateway {
-	return &Gateway{
-		logger:   logger,
-		registry: registry,
+// DefaultAuthCacheTTL is how long a verified token is trusted without asking
+// the user service again, and so how long a revoked token keeps working.
+const DefaultAuthCacheTTL = 30 * time.Second
+
+// ClientConfig bounds calls to the user service so that a slow instance
+// cannot stall the event loop or an authenticated call.
+func ClientConfig() grpcclient.Config {
+	cfg := grpcclient.DefaultConfig()
+	for _, method := range []string{gen.User_GetByID_FullMethodName, gen.User_IntrospectToken_FullMethodName} {
+		cfg.Methods[method] = grpcclient.MethodPolicy{
+			Timeout:    2 * time.Second,
+			Idempotent: true,
+		}
 	}
+	return cfg
 }
 
-func (g *Gateway) GetUserDetails(ctx context.Context, userID uuid.UUID) (*model.User, error) {
-	// Get service address from registry
-	addrs, err := g.registry.ServiceAddresses(ctx, "user")
+// Gateway calls the user service over a single long-lived connection that
+// resolves instances through the registry and balances across them. It
+// implements grpcauth.Verifier with the tokens the user service issued.
+type Gateway struct {
+	logger *zap.SugaredLogger
+	conn   *grpc.ClientConn
+	client gen.UserClient
+	cache  *cache
+	tokens *tokenCache
+}
+
+func NewGateway(registry discovery.Registry, cacheTTL, authCacheTTL time.Duration, clientConfig grpcclient.Config, logger *zap.SugaredLogger) (*Gateway, error) {
+	opts := append(grpcresolver.DialOptions(registry), grpcclient.DialOptions(clientConfig)...)
+	opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient(grpcresolver.Target("user"), opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	// g.logger.Infof("ADDRESSES RETRIEVED: %v", addrs)
-
-	target := addrs[rand.Intn(len(addrs))]
-
-	g.logger.Infow("Calling user service", "address", target)
+	return &Gateway{
+		logger: logger,
+		conn:   conn,
+		client: gen.NewUserClient(conn),
+		cache:  newCache(cacheTTL),
+		tokens: newTokenCache(authCacheTTL),
+	}, nil
+}
 
-	// conn, err := grpc.NewClient("localhost:9001", grpc.WithTransportCredentials(insecure.NewCredentials()))
-	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		g.logger.Errorw("Failed to open connection to user service", "address", target)
-		return nil, err
+func (g *Gateway) GetUserDetails(ctx context.Context, userID uuid.UUID) (*model.User, error) {
+	if user, ok := g.cache.get(userID); ok {
+		return user, nil
 	}
+	epoch := g.cache.current()
 
-	defer conn.Close()
-
-	client := gen.NewUserClient(conn)
-	res, err := client.GetByID(ctx, &gen.GetByIDRequest{
+	res, err := g.client.GetByID(ctx, &gen.GetByIDRequest{
 		UserId: userID.String(),
 	})
 	if err != nil {
+		g.logger.Warnw("Failed to fetch user details", "userID", userID, "error", err)
 		return nil, err
 	}
 
-	return &model.User{
+	user := &model.User{
 		UserID:   userID,
 		Username: res.GetUsername(),
 		Email:    res.GetEmail(),
-	}, nil
+	}
+	g.cache.set(user, epoch)
+	return user, nil
+}
+
+func (g *Gateway) Verify(ctx context.Context, token string) (*grpcauth.Principal, error) {
+	if principal, ok := g.tokens.get(token); ok {
+		return principal, nil
+	}
+
+	res, err := g.client.IntrospectToken(ctx, &gen.IntrospectTokenRequest{Token: token})
+	if err != nil {
+		g.logger.Warnw("Failed to introspect token", "error", err)
+		return nil, err
+	}
+	if !res.GetActive() {
+		return nil, grpcauth.ErrInvalidToken
+	}
+	userID, err := uuid.Parse(res.GetUserId())
+	if err != nil {
+		return nil, fmt.Errorf("user service returned an invalid user ID: %w", err)
+	}
+
+	principal := &grpcauth.Principal{
+		UserID:  userID,
+		TokenID: res.GetTokenId(),
+		Scopes:  res.GetScopes(),
+	}
+	g.tokens.set(token, principal)
+	return principal, nil
+}
+
+// Invalidate drops the cached details of the user.
+func (g *Gateway) Invalidate(userID uuid.UUID) {
+	g.cache.invalidate(userID)
+}
+
+func (g *Gateway) Close() error {
+	return g.conn.Close()
 }
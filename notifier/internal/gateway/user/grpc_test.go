@@ -0,0 +1,131 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/gateway/user"
+	"github.com/CP-Payne/taskflow/pkg/discovery/static"
+	gen "github.com/CP-Payne/taskflow/pkg/gen/user/v1"
+	"github.com/CP-Payne/taskflow/pkg/grpcauth"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+type userServer struct {
+	gen.UnimplementedUserServer
+	calls atomic.Int32
+}
+
+func (s *userServer) GetByID(ctx context.Context, req *gen.GetByIDRequest) (*gen.GetByIDResponse, error) {
+	s.calls.Add(1)
+	return &gen.GetByIDResponse{UserId: req.GetUserId(), Username: "jane", Email: "jane@example.com"}, nil
+}
+
+func (s *userServer) IntrospectToken(ctx context.Context, req *gen.IntrospectTokenRequest) (*gen.IntrospectTokenResponse, error) {
+	s.calls.Add(1)
+	if req.GetToken() != "session" {
+		return &gen.IntrospectTokenResponse{Active: false}, nil
+	}
+	return &gen.IntrospectTokenResponse{Active: true, UserId: sessionUserID.String()}, nil
+}
+
+var sessionUserID = uuid.New()
+
+func startUserServer(t *testing.T) (*userServer, string) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	srv := &userServer{}
+	s := grpc.NewServer()
+	gen.RegisterUserServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return srv, lis.Addr().String()
+}
+
+func TestGateway_GetUserDetails(t *testing.T) {
+	first, firstAddr := startUserServer(t)
+	second, secondAddr := startUserServer(t)
+
+	gtw, err := user.NewGateway(static.NewRegistry(map[string][]string{"user": {firstAddr, secondAddr}}), time.Minute, time.Minute, user.ClientConfig(), zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatalf("NewGateway() failed: %v", err)
+	}
+	defer gtw.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Distinct users are not cached, so calls are balanced across instances.
+	for i := 0; i < 10; i++ {
+		if _, err := gtw.GetUserDetails(ctx, uuid.New()); err != nil {
+			t.Fatalf("GetUserDetails() failed: %v", err)
+		}
+	}
+	if first.calls.Load() == 0 || second.calls.Load() == 0 {
+		t.Errorf("expected calls on both instances, got %d and %d", first.calls.Load(), second.calls.Load())
+	}
+
+	// Repeated lookups are served from the cache until invalidated.
+	userID := uuid.New()
+	before := first.calls.Load() + second.calls.Load()
+	for i := 0; i < 3; i++ {
+		u, err := gtw.GetUserDetails(ctx, userID)
+		if err != nil {
+			t.Fatalf("GetUserDetails() failed: %v", err)
+		}
+		if u.UserID != userID || u.Email != "jane@example.com" {
+			t.Errorf("unexpected user %+v", u)
+		}
+	}
+	if got := first.calls.Load() + second.calls.Load() - before; got != 1 {
+		t.Errorf("expected 1 call for cached user, got %d", got)
+	}
+
+	gtw.Invalidate(userID)
+	if _, err := gtw.GetUserDetails(ctx, userID); err != nil {
+		t.Fatalf("GetUserDetails() failed: %v", err)
+	}
+	if got := first.calls.Load() + second.calls.Load() - before; got != 2 {
+		t.Errorf("expected a new call after invalidation, got %d calls", got)
+	}
+}
+
+func TestGateway_Verify(t *testing.T) {
+	srv, addr := startUserServer(t)
+
+	gtw, err := user.NewGateway(static.NewRegistry(map[string][]string{"user": {addr}}), time.Minute, time.Minute, user.ClientConfig(), zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatalf("NewGateway() failed: %v", err)
+	}
+	defer gtw.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Verified tokens are served from the cache.
+	for i := 0; i < 3; i++ {
+		p, err := gtw.Verify(ctx, "session")
+		if err != nil {
+			t.Fatalf("Verify() failed: %v", err)
+		}
+		if p.UserID != sessionUserID || !p.Session() {
+			t.Errorf("unexpected principal %+v", p)
+		}
+	}
+	if got := srv.calls.Load(); got != 1 {
+		t.Errorf("expected 1 call for a cached token, got %d", got)
+	}
+
+	if _, err := gtw.Verify(ctx, "expired"); !errors.Is(err, grpcauth.ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+}
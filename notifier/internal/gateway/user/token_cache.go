@@ -0,0 +1,72 @@
+package user
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/CP-Payne/taskflow/pkg/grpcauth"
+)
+
+type tokenCacheEntry struct {
+	principal grpcauth.Principal
+	expiresAt time.Time
+}
+
+// tokenCache keeps verified tokens for a fixed TTL. Tokens are keyed by their
+// SHA-256 so the bearer tokens themselves are not kept in memory.
+type tokenCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[[sha256.Size]byte]tokenCacheEntry
+	now     func() time.Time
+}
+
+func newTokenCache(ttl time.Duration) *tokenCache {
+	return &tokenCache{
+		ttl:     ttl,
+		entries: make(map[[sha256.Size]byte]tokenCacheEntry),
+		now:     time.Now,
+	}
+}
+
+func (c *tokenCache) get(token string) (*grpcauth.Principal, bool) {
+	key := sha256.Sum256([]byte(token))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(e.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	principal := e.principal
+	return &principal, true
+}
+
+func (c *tokenCache) set(token string, principal *grpcauth.Principal) {
+	if c.ttl <= 0 {
+		return
+	}
+	key := sha256.Sum256([]byte(token))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop expired entries now and then, since tokens are not looked up
+	// again once their clients stop
+	now := c.now()
+	if len(c.entries) >= maxCacheEntries {
+		for k, e := range c.entries {
+			if !now.Before(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	if len(c.entries) >= maxCacheEntries {
+		return
+	}
+	c.entries[key] = tokenCacheEntry{principal: *principal, expiresAt: now.Add(c.ttl)}
+}
@@ -0,0 +1,38 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/CP-Payne/taskflow/pkg/grpcauth"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// callerID returns the authenticated user. A user ID in the request must be
+// the caller's own, since tokens only act for the user they belong to; an
+// empty one defaults to the caller.
+func callerID(ctx context.Context, logger *zap.SugaredLogger, method, requested string) (uuid.UUID, error) {
+	caller, ok := grpcauth.FromContext(ctx)
+	if !ok {
+		return uuid.Nil, status.Errorf(codes.Unauthenticated, "authentication required")
+	}
+	if requested == "" {
+		return caller.UserID, nil
+	}
+	userID, err := uuid.Parse(requested)
+	if err != nil {
+		logger.Warnw(method+" invalid userID", "userID", requested, "error", err)
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "invalid userID")
+	}
+	if userID != caller.UserID {
+		logger.Warnw(method+" userID does not match the caller",
+			"userID", userID,
+			"callerID", caller.UserID,
+			"tokenID", caller.TokenID,
+		)
+		return uuid.Nil, status.Errorf(codes.PermissionDenied, "user_id must be the authenticated user")
+	}
+	return userID, nil
+}
@@ -0,0 +1,155 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/service"
+	api "github.com/CP-Payne/taskflow/pkg/gen/notifier/v1"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type InboxHandler struct {
+	api.UnimplementedInboxServer
+	inboxService *service.InboxService
+	logger       *zap.SugaredLogger
+}
+
+func NewInboxHandler(inboxService *service.InboxService, logger *zap.SugaredLogger) *InboxHandler {
+	return &InboxHandler{
+		inboxService: inboxService,
+		logger:       logger,
+	}
+}
+
+func (h *InboxHandler) ListNotifications(ctx context.Context, req *api.ListNotificationsRequest) (*api.ListNotificationsResponse, error) {
+	userID, err := callerID(ctx, h.logger, "ListNotifications", req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	notifications, next, err := h.inboxService.List(ctx, userID, int(req.GetPageSize()), req.GetPageToken(), req.GetUnreadOnly())
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidArgument) {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		h.logger.Errorw("ListNotifications internal error", "userID", userID, "error", err)
+		return nil, status.Errorf(codes.Internal, "internal server error")
+	}
+
+	res := &api.ListNotificationsResponse{
+		Notifications: make([]*api.Notification, len(notifications)),
+		NextPageToken: next,
+	}
+	for i := range notifications {
+		res.Notifications[i] = notificationToProto(&notifications[i])
+	}
+	return res, nil
+}
+
+func (h *InboxHandler) MarkRead(ctx context.Context, req *api.MarkReadRequest) (*api.MarkReadResponse, error) {
+	userID, err := callerID(ctx, h.logger, "MarkRead", req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(req.GetNotificationIds()))
+	for _, raw := range req.GetNotificationIds() {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			h.logger.Warnw("MarkRead invalid notificationID", "notificationID", raw, "error", err)
+			return nil, status.Errorf(codes.InvalidArgument, "invalid notificationID %q", raw)
+		}
+		ids = append(ids, id)
+	}
+
+	updated, err := h.inboxService.MarkRead(ctx, userID, ids)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidArgument) {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		h.logger.Errorw("MarkRead internal error", "userID", userID, "error", err)
+		return nil, status.Errorf(codes.Internal, "internal server error")
+	}
+	return &api.MarkReadResponse{Updated: int32(updated)}, nil
+}
+
+func (h *InboxHandler) MarkAllRead(ctx context.Context, req *api.MarkAllReadRequest) (*api.MarkAllReadResponse, error) {
+	userID, err := callerID(ctx, h.logger, "MarkAllRead", req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := h.inboxService.MarkAllRead(ctx, userID)
+	if err != nil {
+		h.logger.Errorw("MarkAllRead internal error", "userID", userID, "error", err)
+		return nil, status.Errorf(codes.Internal, "internal server error")
+	}
+	return &api.MarkAllReadResponse{Updated: int32(updated)}, nil
+}
+
+func (h *InboxHandler) UnreadCount(ctx context.Context, req *api.UnreadCountRequest) (*api.UnreadCountResponse, error) {
+	userID, err := callerID(ctx, h.logger, "UnreadCount", req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := h.inboxService.UnreadCount(ctx, userID)
+	if err != nil {
+		h.logger.Errorw("UnreadCount internal error", "userID", userID, "error", err)
+		return nil, status.Errorf(codes.Internal, "internal server error")
+	}
+	return &api.UnreadCountResponse{Count: int32(count)}, nil
+}
+
+func (h *InboxHandler) WatchNotifications(req *api.WatchNotificationsRequest, stream api.Inbox_WatchNotificationsServer) error {
+	userID, err := callerID(stream.Context(), h.logger, "WatchNotifications", req.GetUserId())
+	if err != nil {
+		return err
+	}
+
+	notifications, stop := h.inboxService.Watch(userID)
+	defer stop()
+	h.logger.Infow("Inbox watcher connected", "userID", userID)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			h.logger.Infow("Inbox watcher disconnected", "userID", userID)
+			return nil
+		case n, ok := <-notifications:
+			if !ok {
+				return status.Errorf(codes.Unavailable, "notification stream closed")
+			}
+			if err := stream.Send(notificationToProto(&n)); err != nil {
+				h.logger.Warnw("Failed to send notification to watcher", "userID", userID, "error", err)
+				return err
+			}
+		}
+	}
+}
+
+func notificationToProto(n *model.Notification) *api.Notification {
+	p := &api.Notification{
+		Id:        n.ID.String(),
+		UserId:    n.UserID.String(),
+		EventType: n.EventType,
+		EventId:   n.EventID,
+		Title:     n.Title,
+		Body:      n.Body,
+		CreatedAt: timestamppb.New(n.CreatedAt),
+		Read:      n.Read(),
+	}
+	if n.TaskID != uuid.Nil {
+		p.TaskId = n.TaskID.String()
+	}
+	if n.ReadAt != nil {
+		p.ReadAt = timestamppb.New(*n.ReadAt)
+	}
+	return p
+}
@@ -0,0 +1,162 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/service"
+	api "github.com/CP-Payne/taskflow/pkg/gen/notifier/v1"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type PreferencesHandler struct {
+	api.UnimplementedPreferencesServer
+	preferencesService *service.PreferencesService
+	logger             *zap.SugaredLogger
+}
+
+func NewPreferencesHandler(preferencesService *service.PreferencesService, logger *zap.SugaredLogger) *PreferencesHandler {
+	return &PreferencesHandler{
+		preferencesService: preferencesService,
+		logger:             logger,
+	}
+}
+
+func (h *PreferencesHandler) GetPreferences(ctx context.Context, req *api.GetPreferencesRequest) (*api.GetPreferencesResponse, error) {
+	userID, err := callerID(ctx, h.logger, "GetPreferences", req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	prefs, err := h.preferencesService.Get(ctx, userID)
+	if err != nil {
+		h.logger.Errorw("GetPreferences internal error", "userID", userID, "error", err)
+		return nil, status.Errorf(codes.Internal, "internal server error")
+	}
+
+	return &api.GetPreferencesResponse{Preferences: preferencesToProto(prefs)}, nil
+}
+
+func (h *PreferencesHandler) UpdatePreferences(ctx context.Context, req *api.UpdatePreferencesRequest) (*api.UpdatePreferencesResponse, error) {
+	if req == nil || req.GetPreferences() == nil {
+		h.logger.Warnw("UpdatePreferences validation failed: nil preferences")
+		return nil, status.Errorf(codes.InvalidArgument, "nil request or invalid arguments")
+	}
+
+	userID, err := callerID(ctx, h.logger, "UpdatePreferences", req.GetPreferences().GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	prefs, err := preferencesFromProto(userID, req.GetPreferences())
+	if err != nil {
+		h.logger.Warnw("UpdatePreferences validation failed", "userID", userID, "error", err)
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	prefs, err = h.preferencesService.Update(ctx, prefs)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidArgument) {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		h.logger.Errorw("UpdatePreferences internal error", "userID", prefs.UserID, "error", err)
+		return nil, status.Errorf(codes.Internal, "internal server error")
+	}
+
+	h.logger.Infow("Notification preferences updated", "userID", prefs.UserID)
+	return &api.UpdatePreferencesResponse{Preferences: preferencesToProto(prefs)}, nil
+}
+
+var channelNames = map[api.Channel]model.Channel{
+	api.Channel_EMAIL:   model.ChannelEmail,
+	api.Channel_WEBHOOK: model.ChannelWebhook,
+	api.Channel_IN_APP:  model.ChannelInApp,
+}
+
+var digestModes = map[api.DigestMode]model.DigestMode{
+	api.DigestMode_IMMEDIATE: model.DigestImmediate,
+	api.DigestMode_HOURLY:    model.DigestHourly,
+	api.DigestMode_DAILY:     model.DigestDaily,
+}
+
+func preferencesFromProto(userID uuid.UUID, p *api.NotificationPreferences) (*model.Preferences, error) {
+	prefs := &model.Preferences{
+		UserID:     userID,
+		EventTypes: p.GetEventTypes(),
+		Language:   p.GetLanguage(),
+	}
+	digest, ok := digestModes[p.GetDigest()]
+	if !ok {
+		return nil, fmt.Errorf("unknown digest mode %v", p.GetDigest())
+	}
+	prefs.Digest = digest
+
+	for _, c := range p.GetChannels() {
+		channel, ok := channelNames[c]
+		if !ok {
+			return nil, fmt.Errorf("unknown channel %v", c)
+		}
+		prefs.Channels = append(prefs.Channels, channel)
+	}
+
+	if q := p.GetQuietHours(); q != nil {
+		start, err := parseClock(q.GetStart())
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(q.GetEnd())
+		if err != nil {
+			return nil, err
+		}
+		prefs.QuietHours = &model.QuietHours{Start: start, End: end, TimeZone: q.GetTimeZone()}
+	}
+	return prefs, nil
+}
+
+func preferencesToProto(prefs *model.Preferences) *api.NotificationPreferences {
+	p := &api.NotificationPreferences{
+		UserId:     prefs.UserID.String(),
+		EventTypes: prefs.EventTypes,
+		Language:   prefs.Language,
+	}
+	for _, c := range prefs.Channels {
+		for k, v := range channelNames {
+			if v == c {
+				p.Channels = append(p.Channels, k)
+			}
+		}
+	}
+	for k, v := range digestModes {
+		if v == prefs.Digest {
+			p.Digest = k
+		}
+	}
+	if q := prefs.QuietHours; q != nil {
+		p.QuietHours = &api.QuietHours{
+			Start:    formatClock(q.Start),
+			End:      formatClock(q.End),
+			TimeZone: q.TimeZone,
+		}
+	}
+	if !prefs.UpdatedAt.IsZero() {
+		p.UpdatedAt = timestamppb.New(prefs.UpdatedAt)
+	}
+	return p
+}
+
+func parseClock(s string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	return h*60 + m, nil
+}
+
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
@@ -0,0 +1,180 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/service"
+	api "github.com/CP-Payne/taskflow/pkg/gen/notifier/v1"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type WebhookHandler struct {
+	api.UnimplementedWebhooksServer
+	webhookService *service.WebhookService
+	logger         *zap.SugaredLogger
+}
+
+func NewWebhookHandler(webhookService *service.WebhookService, logger *zap.SugaredLogger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		logger:         logger,
+	}
+}
+
+func (h *WebhookHandler) RegisterEndpoint(ctx context.Context, req *api.RegisterEndpointRequest) (*api.RegisterEndpointResponse, error) {
+	owner, err := h.owner(ctx, "RegisterEndpoint", req.GetOwner())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetUrl() == "" {
+		h.logger.Warnw("RegisterEndpoint validation failed: invalid arguments",
+			"owner", req.GetOwner(),
+			"url", req.GetUrl(),
+		)
+		return nil, status.Errorf(codes.InvalidArgument, "nil request or invalid arguments")
+	}
+
+	endpoint, err := h.webhookService.RegisterEndpoint(ctx, owner, req.GetUrl(), req.GetEventTypes())
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidArgument) {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		h.logger.Errorw("Internal error during webhook registration", "owner", owner, zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "internal server error")
+	}
+
+	h.logger.Infow("Webhook endpoint registered", "endpointID", endpoint.ID, "owner", owner)
+	return &api.RegisterEndpointResponse{
+		Endpoint: endpointToProto(endpoint),
+		Secret:   endpoint.Secret,
+	}, nil
+}
+
+func (h *WebhookHandler) ListEndpoints(ctx context.Context, req *api.ListEndpointsRequest) (*api.ListEndpointsResponse, error) {
+	owner, err := h.owner(ctx, "ListEndpoints", req.GetOwner())
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, err := h.webhookService.ListEndpoints(ctx, owner)
+	if err != nil {
+		h.logger.Errorw("Internal error during webhook listing", "owner", owner, zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "internal server error")
+	}
+
+	res := &api.ListEndpointsResponse{Endpoints: make([]*api.WebhookEndpoint, len(endpoints))}
+	for i := range endpoints {
+		res.Endpoints[i] = endpointToProto(&endpoints[i])
+	}
+	return res, nil
+}
+
+func (h *WebhookHandler) DeleteEndpoint(ctx context.Context, req *api.DeleteEndpointRequest) (*api.DeleteEndpointResponse, error) {
+	owner, err := h.owner(ctx, "DeleteEndpoint", req.GetOwner())
+	if err != nil {
+		return nil, err
+	}
+	endpointID, err := uuid.Parse(req.GetEndpointId())
+	if err != nil {
+		h.logger.Warnw("DeleteEndpoint validation failed: invalid arguments",
+			"owner", req.GetOwner(),
+			"endpointID", req.GetEndpointId(),
+		)
+		return nil, status.Errorf(codes.InvalidArgument, "nil request or invalid arguments")
+	}
+
+	if err := h.webhookService.DeleteEndpoint(ctx, owner, endpointID); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "endpoint not found")
+		}
+		h.logger.Errorw("Internal error during webhook deletion", "endpointID", endpointID, zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "internal server error")
+	}
+
+	h.logger.Infow("Webhook endpoint deleted", "endpointID", endpointID, "owner", owner)
+	return &api.DeleteEndpointResponse{}, nil
+}
+
+func (h *WebhookHandler) ListDeliveries(ctx context.Context, req *api.ListDeliveriesRequest) (*api.ListDeliveriesResponse, error) {
+	owner, err := h.owner(ctx, "ListDeliveries", req.GetOwner())
+	if err != nil {
+		return nil, err
+	}
+	endpointID, err := uuid.Parse(req.GetEndpointId())
+	if err != nil || req.GetLimit() < 0 {
+		h.logger.Warnw("ListDeliveries validation failed: invalid arguments",
+			"owner", req.GetOwner(),
+			"endpointID", req.GetEndpointId(),
+		)
+		return nil, status.Errorf(codes.InvalidArgument, "nil request or invalid arguments")
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(ctx, owner, endpointID, int(req.GetLimit()))
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "endpoint not found")
+		}
+		h.logger.Errorw("Internal error during delivery listing", "endpointID", endpointID, zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "internal server error")
+	}
+
+	res := &api.ListDeliveriesResponse{Deliveries: make([]*api.WebhookDelivery, len(deliveries))}
+	for i, d := range deliveries {
+		res.Deliveries[i] = &api.WebhookDelivery{
+			DeliveryId:  d.ID.String(),
+			EndpointId:  d.EndpointID.String(),
+			EventId:     d.EventID,
+			EventType:   d.EventType,
+			Attempt:     int32(d.Attempt),
+			StatusCode:  int32(d.StatusCode),
+			Error:       d.Error,
+			Succeeded:   d.Succeeded,
+			DurationMs:  d.Duration.Milliseconds(),
+			AttemptedAt: timestamppb.New(d.AttemptedAt),
+		}
+	}
+	return res, nil
+}
+
+// owner returns the endpoint owner the caller acts for. User endpoints belong
+// to the caller, in the canonical form events carry user IDs in, and a
+// missing owner means the caller. Workspace endpoints are refused, since no
+// service records who may act for a workspace yet.
+func (h *WebhookHandler) owner(ctx context.Context, method string, owner *api.Owner) (model.Owner, error) {
+	switch owner.GetType() {
+	case api.OwnerType_USER:
+		userID, err := callerID(ctx, h.logger, method, owner.GetId())
+		if err != nil {
+			return model.Owner{}, err
+		}
+		return model.Owner{Type: model.OwnerUser, ID: userID.String()}, nil
+	case api.OwnerType_WORKSPACE:
+		if _, err := callerID(ctx, h.logger, method, ""); err != nil {
+			return model.Owner{}, err
+		}
+		h.logger.Warnw(method+" refused for a workspace owner", "workspaceID", owner.GetId())
+		return model.Owner{}, status.Errorf(codes.PermissionDenied, "workspace endpoints cannot be managed yet")
+	default:
+		h.logger.Warnw(method+" invalid owner type", "owner", owner)
+		return model.Owner{}, status.Errorf(codes.InvalidArgument, "invalid owner type")
+	}
+}
+
+func endpointToProto(e *model.WebhookEndpoint) *api.WebhookEndpoint {
+	return &api.WebhookEndpoint{
+		Id: e.ID.String(),
+		Owner: &api.Owner{
+			Type: api.OwnerType(e.Owner.Type),
+			Id:   e.Owner.ID,
+		},
+		Url:        e.URL,
+		EventTypes: e.EventTypes,
+		CreatedAt:  timestamppb.New(e.CreatedAt),
+	}
+}
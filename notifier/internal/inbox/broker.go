@@ -0,0 +1,79 @@
+// Package inbox fans new in-app notifications out to connected clients.
+package inbox
+
+import (
+	"sync"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/google/uuid"
+)
+
+// DefaultBufferSize is the number of notifications a subscriber may fall
+// behind before further notifications are dropped for it.
+const DefaultBufferSize = 16
+
+// Broker delivers published notifications to the subscribers of their user.
+// Delivery is best effort: a subscriber whose buffer is full misses the
+// notification, which it can still fetch from the inbox.
+type Broker struct {
+	mu          sync.Mutex
+	bufferSize  int
+	subscribers map[uuid.UUID]map[*subscription]struct{}
+}
+
+type subscription struct {
+	ch chan model.Notification
+}
+
+func NewBroker(bufferSize int) *Broker {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	return &Broker{
+		bufferSize:  bufferSize,
+		subscribers: make(map[uuid.UUID]map[*subscription]struct{}),
+	}
+}
+
+// Subscribe returns a channel receiving the user's new notifications and a
+// function that ends the subscription and closes the channel.
+func (b *Broker) Subscribe(userID uuid.UUID) (<-chan model.Notification, func()) {
+	sub := &subscription{ch: make(chan model.Notification, b.bufferSize)}
+
+	b.mu.Lock()
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[*subscription]struct{})
+	}
+	b.subscribers[userID][sub] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subscribers[userID], sub)
+			if len(b.subscribers[userID]) == 0 {
+				delete(b.subscribers, userID)
+			}
+			close(sub.ch)
+		})
+	}
+}
+
+// Publish delivers n to the user's subscribers and returns how many of them
+// dropped it because their buffer was full.
+func (b *Broker) Publish(n model.Notification) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	dropped := 0
+	for sub := range b.subscribers[n.UserID] {
+		select {
+		case sub.ch <- n:
+		default:
+			dropped++
+		}
+	}
+	return dropped
+}
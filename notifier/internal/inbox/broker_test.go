@@ -0,0 +1,49 @@
+package inbox_test
+
+import (
+	"testing"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/inbox"
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/google/uuid"
+)
+
+func TestBroker_Publish(t *testing.T) {
+	broker := inbox.NewBroker(2)
+	userID := uuid.New()
+
+	first, stopFirst := broker.Subscribe(userID)
+	defer stopFirst()
+	second, stopSecond := broker.Subscribe(userID)
+	other, stopOther := broker.Subscribe(uuid.New())
+	defer stopOther()
+
+	n := model.Notification{ID: uuid.New(), UserID: userID}
+	if dropped := broker.Publish(n); dropped != 0 {
+		t.Fatalf("expected no drops, got %d", dropped)
+	}
+	for _, ch := range []<-chan model.Notification{first, second} {
+		if got := <-ch; got.ID != n.ID {
+			t.Errorf("got notification %v, want %v", got.ID, n.ID)
+		}
+	}
+	select {
+	case got := <-other:
+		t.Errorf("other user received %v", got.ID)
+	default:
+	}
+
+	// A stopped subscription is closed and no longer receives.
+	stopSecond()
+	stopSecond()
+	if _, ok := <-second; ok {
+		t.Error("expected the stopped subscription to be closed")
+	}
+
+	// The buffer holds two notifications; the third is dropped.
+	for i, wantDropped := range []int{0, 0, 1} {
+		if dropped := broker.Publish(model.Notification{ID: uuid.New(), UserID: userID}); dropped != wantDropped {
+			t.Errorf("publish %d: expected %d drops, got %d", i, wantDropped, dropped)
+		}
+	}
+}
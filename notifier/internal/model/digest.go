@@ -0,0 +1,59 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DigestMode string
+
+const (
+	// DigestImmediate sends each notification as it happens.
+	DigestImmediate DigestMode = ""
+	DigestHourly    DigestMode = "hourly"
+	DigestDaily     DigestMode = "daily"
+)
+
+func (m DigestMode) Validate() error {
+	switch m {
+	case DigestImmediate, DigestHourly, DigestDaily:
+		return nil
+	default:
+		return fmt.Errorf("unknown digest mode %q", m)
+	}
+}
+
+// NextPeriod returns the end of the digest period containing now, in loc.
+// Hourly periods end on the hour, daily ones at midnight.
+func (m DigestMode) NextPeriod(now time.Time, loc *time.Location) time.Time {
+	local := now.In(loc)
+	switch m {
+	case DigestHourly:
+		return time.Date(local.Year(), local.Month(), local.Day(), local.Hour()+1, 0, 0, 0, loc)
+	case DigestDaily:
+		return time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, loc)
+	default:
+		return now
+	}
+}
+
+// DigestEntry is a single event collected for a user's next digest.
+type DigestEntry struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	EventType  string
+	Task       Task
+	OccurredAt time.Time
+}
+
+// Digest is a batch of entries claimed for sending as one summary.
+type Digest struct {
+	// ID identifies the claimed batch and is used to acknowledge it.
+	ID     string
+	UserID uuid.UUID
+	// Period is when the batch became due, the end of the period it covers.
+	Period  time.Time
+	Entries []DigestEntry
+}
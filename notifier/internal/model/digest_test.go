@@ -0,0 +1,56 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+)
+
+func TestDigestMode_NextPeriod(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation() failed: %v", err)
+	}
+	now := time.Date(2025, time.March, 10, 22, 30, 0, 0, time.UTC) // 23:30 in Berlin
+
+	tests := []struct {
+		name string
+		mode model.DigestMode
+		loc  *time.Location
+		want time.Time
+	}{
+		{
+			name: "Immediate",
+			mode: model.DigestImmediate,
+			loc:  time.UTC,
+			want: now,
+		},
+		{
+			name: "Hourly",
+			mode: model.DigestHourly,
+			loc:  time.UTC,
+			want: time.Date(2025, time.March, 10, 23, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Daily in UTC",
+			mode: model.DigestDaily,
+			loc:  time.UTC,
+			want: time.Date(2025, time.March, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Daily in user's time zone",
+			mode: model.DigestDaily,
+			loc:  berlin,
+			want: time.Date(2025, time.March, 10, 23, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.mode.NextPeriod(now, tt.loc); !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification is an item in a user's in-app inbox.
+type Notification struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	EventType string
+	// EventID is the envelope ID of the event the notification was created for.
+	EventID   string
+	Title     string
+	Body      string
+	TaskID    uuid.UUID
+	CreatedAt time.Time
+	// ReadAt is nil while the notification is unread.
+	ReadAt *time.Time
+}
+
+func (n *Notification) Read() bool {
+	return n.ReadAt != nil
+}
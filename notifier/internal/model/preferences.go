@@ -0,0 +1,143 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Channel string
+
+const (
+	ChannelEmail   Channel = "email"
+	ChannelWebhook Channel = "webhook"
+	ChannelInApp   Channel = "in_app"
+)
+
+// Preferences controls which notifications a user receives and how.
+type Preferences struct {
+	UserID uuid.UUID
+	// EventTypes the user wants to hear about. Empty means all of them.
+	EventTypes []string
+	Channels   []Channel
+	// Language selects the notification templates, e.g. "en" or "es".
+	Language   string
+	QuietHours *QuietHours
+	// Digest batches emails into periodic summaries instead of sending them
+	// one by one.
+	Digest    DigestMode
+	UpdatedAt time.Time
+}
+
+// DefaultPreferences applies to users who never saved any preferences.
+func DefaultPreferences(userID uuid.UUID) *Preferences {
+	return &Preferences{
+		UserID:   userID,
+		Channels: []Channel{ChannelEmail, ChannelWebhook, ChannelInApp},
+	}
+}
+
+// Allows reports whether eventType should be delivered over channel.
+func (p *Preferences) Allows(eventType string, channel Channel) bool {
+	return p.wantsEvent(eventType) && p.usesChannel(channel)
+}
+
+// Location is the user's time zone, taken from their quiet hours, or UTC.
+func (p *Preferences) Location() *time.Location {
+	if p.QuietHours != nil {
+		if loc, err := time.LoadLocation(p.QuietHours.TimeZone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+func (p *Preferences) wantsEvent(eventType string) bool {
+	if len(p.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range p.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Preferences) usesChannel(channel Channel) bool {
+	for _, c := range p.Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// QuietHours is a daily window, in the user's time zone, during which
+// notifications are held back. A window whose end is before its start spans
+// midnight, e.g. 22:00-07:00.
+type QuietHours struct {
+	// Start and End are minutes since midnight.
+	Start    int
+	End      int
+	TimeZone string
+}
+
+func (q *QuietHours) Validate() error {
+	if q.Start < 0 || q.Start >= 24*60 || q.End < 0 || q.End >= 24*60 {
+		return errors.New("quiet hours must be within 00:00 and 23:59")
+	}
+	if q.Start == q.End {
+		return errors.New("quiet hours start and end must differ")
+	}
+	if _, err := time.LoadLocation(q.TimeZone); err != nil {
+		return fmt.Errorf("unknown time zone %q", q.TimeZone)
+	}
+	return nil
+}
+
+// ReleaseTime returns when the quiet window containing now ends, and false
+// if now is outside quiet hours.
+func (q *QuietHours) ReleaseTime(now time.Time) (time.Time, bool) {
+	if q == nil {
+		return time.Time{}, false
+	}
+	loc, err := time.LoadLocation(q.TimeZone)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	endOn := func(day int) time.Time {
+		return time.Date(local.Year(), local.Month(), day, q.End/60, q.End%60, 0, 0, loc)
+	}
+
+	if q.Start < q.End {
+		if minute >= q.Start && minute < q.End {
+			return endOn(local.Day()), true
+		}
+		return time.Time{}, false
+	}
+
+	// The window spans midnight.
+	switch {
+	case minute >= q.Start:
+		return endOn(local.Day() + 1), true
+	case minute < q.End:
+		return endOn(local.Day()), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// HeldNotification is a notification postponed until quiet hours end.
+type HeldNotification struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	EventType string
+	Task      Task
+	ReleaseAt time.Time
+}
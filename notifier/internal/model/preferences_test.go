@@ -0,0 +1,78 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+)
+
+func TestQuietHours_ReleaseTime(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, time.March, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		quiet     *model.QuietHours
+		now       time.Time
+		wantQuiet bool
+		wantAt    time.Time
+	}{
+		{
+			name:  "No quiet hours",
+			quiet: nil,
+			now:   at(10, 23, 0),
+		},
+		{
+			name:      "Inside daytime window",
+			quiet:     &model.QuietHours{Start: 12 * 60, End: 14 * 60, TimeZone: "UTC"},
+			now:       at(10, 13, 0),
+			wantQuiet: true,
+			wantAt:    at(10, 14, 0),
+		},
+		{
+			name:  "Outside daytime window",
+			quiet: &model.QuietHours{Start: 12 * 60, End: 14 * 60, TimeZone: "UTC"},
+			now:   at(10, 14, 0),
+		},
+		{
+			name:      "Before midnight in overnight window",
+			quiet:     &model.QuietHours{Start: 22 * 60, End: 7*60 + 30, TimeZone: "UTC"},
+			now:       at(10, 23, 15),
+			wantQuiet: true,
+			wantAt:    at(11, 7, 30),
+		},
+		{
+			name:      "After midnight in overnight window",
+			quiet:     &model.QuietHours{Start: 22 * 60, End: 7*60 + 30, TimeZone: "UTC"},
+			now:       at(11, 2, 0),
+			wantQuiet: true,
+			wantAt:    at(11, 7, 30),
+		},
+		{
+			name:  "Outside overnight window",
+			quiet: &model.QuietHours{Start: 22 * 60, End: 7*60 + 30, TimeZone: "UTC"},
+			now:   at(11, 12, 0),
+		},
+		{
+			name:      "Window in user's time zone",
+			quiet:     &model.QuietHours{Start: 22 * 60, End: 7 * 60, TimeZone: "America/New_York"},
+			now:       at(10, 3, 0), // 23:00 on the 9th in New York
+			wantQuiet: true,
+			wantAt:    at(10, 11, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, quiet := tt.quiet.ReleaseTime(tt.now)
+			if quiet != tt.wantQuiet {
+				t.Fatalf("expected quiet: %v, got %v", tt.wantQuiet, quiet)
+			}
+			if quiet && !got.Equal(tt.wantAt) {
+				t.Errorf("release time: got %v, want %v", got, tt.wantAt)
+			}
+		})
+	}
+}
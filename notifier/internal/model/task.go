@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Task is the task snapshot carried by task events.
+type Task struct {
+	TaskID      uuid.UUID
+	Title       string
+	Description string
+	CreatedBy   uuid.UUID
+	Priority    string
+	DueDate     *time.Time
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OwnerType int
+
+const (
+	OwnerUser OwnerType = iota
+	OwnerWorkspace
+)
+
+// Owner identifies who registered a webhook endpoint.
+type Owner struct {
+	Type OwnerType
+	ID   string
+}
+
+type WebhookEndpoint struct {
+	ID         uuid.UUID
+	Owner      Owner
+	URL        string
+	EventTypes []string
+	// Secret is the HMAC key shared with the receiver. It is only returned
+	// to the owner when the endpoint is registered.
+	Secret    string
+	CreatedAt time.Time
+}
+
+// Accepts reports whether the endpoint subscribed to eventType. An endpoint
+// without event types receives every event.
+func (e *WebhookEndpoint) Accepts(eventType string) bool {
+	if len(e.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range e.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery records a single HTTP attempt to deliver an event.
+type WebhookDelivery struct {
+	ID          uuid.UUID
+	EndpointID  uuid.UUID
+	EventID     string
+	EventType   string
+	Attempt     int
+	StatusCode  int
+	Error       string
+	Succeeded   bool
+	Duration    time.Duration
+	AttemptedAt time.Time
+}
+
+// PendingDelivery is an event waiting for its next attempt at an endpoint.
+// The endpoint is looked up again before every attempt, so deleting it or
+// its secret changing takes effect on deliveries already pending.
+type PendingDelivery struct {
+	// ID is shared by all attempts of the delivery.
+	ID         uuid.UUID
+	EndpointID uuid.UUID
+	EventID    string
+	EventType  string
+	// Body is the enveloped event as it is posted.
+	Body    []byte
+	Attempt int
+	DueAt   time.Time
+}
@@ -0,0 +1,54 @@
+package notification
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type CapturedMessage struct {
+	Recipient string
+	Message   Message
+	SentAt    time.Time
+}
+
+// CaptureSender keeps sent messages in memory. It is meant for tests and
+// for running the notifier without a mail server.
+type CaptureSender struct {
+	mu       sync.Mutex
+	messages []CapturedMessage
+}
+
+func NewCaptureSender() *CaptureSender {
+	return &CaptureSender{}
+}
+
+func (s *CaptureSender) Send(ctx context.Context, recipient string, message *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, CapturedMessage{
+		Recipient: recipient,
+		Message:   *message,
+		SentAt:    time.Now(),
+	})
+	return nil
+}
+
+// Messages returns a copy of the messages captured so far.
+func (s *CaptureSender) Messages() []CapturedMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]CapturedMessage, len(s.messages))
+	copy(out, s.messages)
+	return out
+}
+
+func (s *CaptureSender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+}
@@ -2,39 +2,174 @@ package notification
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/smtp"
 
 	"go.uber.org/zap"
 	"gopkg.in/gomail.v2"
 )
 
+type TLSMode string
+
+const (
+	// TLSStartTLS connects in plain text and upgrades with STARTTLS.
+	TLSStartTLS TLSMode = "starttls"
+	// TLSImplicit connects over TLS from the start, usually on port 465.
+	TLSImplicit TLSMode = "tls"
+)
+
+type AuthMethod string
+
+const (
+	AuthPlain   AuthMethod = "plain"
+	AuthLogin   AuthMethod = "login"
+	AuthCRAMMD5 AuthMethod = "cram-md5"
+	AuthNone    AuthMethod = "none"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// From defaults to Username when empty.
+	From    string
+	TLSMode TLSMode
+	// Auth defaults to AuthPlain with a Username, and to AuthNone without.
+	Auth               AuthMethod
+	InsecureSkipVerify bool
+}
+
+// GmailConfig returns the settings previously hard-coded for Gmail.
+func GmailConfig(source, appPass string) SMTPConfig {
+	return SMTPConfig{
+		Host:     "smtp.gmail.com",
+		Port:     587,
+		Username: source,
+		Password: appPass,
+		From:     source,
+		TLSMode:  TLSStartTLS,
+		Auth:     AuthPlain,
+	}
+}
+
 type EmailSender struct {
 	source string
 	logger *zap.SugaredLogger
 	dialer *gomail.Dialer
 }
 
-func NewEmailSender(sourceEmail, appPass string, logger *zap.SugaredLogger) *EmailSender {
-	dialer := gomail.NewDialer("smtp.gmail.com", 587, sourceEmail, appPass)
+func NewEmailSender(cfg SMTPConfig, logger *zap.SugaredLogger) (*EmailSender, error) {
+	if cfg.Host == "" || cfg.Port == 0 {
+		return nil, errors.New("smtp host and port are required")
+	}
+	if cfg.From == "" {
+		cfg.From = cfg.Username
+	}
+	if cfg.From == "" {
+		return nil, errors.New("smtp sender address is required")
+	}
+
+	dialer := gomail.NewDialer(cfg.Host, cfg.Port, cfg.Username, cfg.Password)
+	dialer.TLSConfig = &tls.Config{
+		ServerName:         cfg.Host,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	switch cfg.TLSMode {
+	case TLSStartTLS, "":
+		dialer.SSL = false
+	case TLSImplicit:
+		dialer.SSL = true
+	default:
+		return nil, fmt.Errorf("unsupported smtp tls mode %q", cfg.TLSMode)
+	}
+
+	if cfg.Auth == "" {
+		cfg.Auth = AuthPlain
+		if cfg.Username == "" {
+			cfg.Auth = AuthNone
+		}
+	}
+	switch cfg.Auth {
+	case AuthPlain:
+		dialer.Auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	case AuthLogin:
+		dialer.Auth = &loginAuth{username: cfg.Username, password: cfg.Password, host: cfg.Host}
+	case AuthCRAMMD5:
+		dialer.Auth = smtp.CRAMMD5Auth(cfg.Username, cfg.Password)
+	case AuthNone:
+		// gomail only authenticates when a username is set.
+		dialer.Username = ""
+		dialer.Password = ""
+	default:
+		return nil, fmt.Errorf("unsupported smtp auth method %q", cfg.Auth)
+	}
+
 	return &EmailSender{
-		source: sourceEmail,
+		source: cfg.From,
 		dialer: dialer,
 		logger: logger,
+	}, nil
+}
+
+func (s *EmailSender) Send(ctx context.Context, recipient string, message *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
+
+	if err := s.dialer.DialAndSend(buildMessage(s.source, recipient, message)); err != nil {
+		s.logger.Errorw("Failed sending email notification", "error", err, "recipient", recipient)
+		return fmt.Errorf("send email to %s: %w", recipient, err)
+	}
+
+	s.logger.Infow("Email sent successfully", "recipient", recipient)
+	return nil
 }
 
-func (s *EmailSender) Send(ctx context.Context, recipient string, message string) error {
-	messageStructure := gomail.NewMessage()
+func buildMessage(from, recipient string, message *Message) *gomail.Message {
+	m := gomail.NewMessage()
 
-	messageStructure.SetHeader("From", s.source)
-	messageStructure.SetHeader("To", recipient)
-	messageStructure.SetHeader("Subject", "New Task Assigned")
+	m.SetHeader("From", from)
+	m.SetHeader("To", recipient)
+	m.SetHeader("Subject", message.Subject)
 
-	messageStructure.SetBody("text/plain", message)
+	m.SetBody("text/plain", message.Text)
+	if message.HTML != "" {
+		m.AddAlternative("text/html", message.HTML)
+	}
+	return m
+}
+
+// loginAuth implements the LOGIN mechanism, which net/smtp does not provide.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
 
-	if err := s.dialer.DialAndSend(messageStructure); err != nil {
-		s.logger.Panicw("Failed sending notification over gmail", "error", err, "recipient", recipient)
-	} else {
-		s.logger.Infow("Email sent successfully", "recipient", recipient)
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && server.Name != "localhost" && server.Name != "127.0.0.1" {
+		return "", nil, errors.New("smtp LOGIN auth requires an encrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("smtp LOGIN auth: wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch string(fromServer) {
+	case "Username:":
+		return []byte(a.username), nil
+	case "Password:":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("smtp LOGIN auth: unexpected server challenge %q", fromServer)
 	}
-	return nil
 }
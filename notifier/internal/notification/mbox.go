@@ -0,0 +1,80 @@
+package notification
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// FileSender appends every message to a local mbox file instead of sending
+// it, so notifications can be inspected with any mail client.
+type FileSender struct {
+	mu     sync.Mutex
+	path   string
+	source string
+	logger *zap.SugaredLogger
+}
+
+func NewFileSender(path, sourceEmail string, logger *zap.SugaredLogger) *FileSender {
+	if sourceEmail == "" {
+		sourceEmail = "taskflow@localhost"
+	}
+	return &FileSender{
+		path:   path,
+		source: sourceEmail,
+		logger: logger,
+	}
+}
+
+func (s *FileSender) Send(ctx context.Context, recipient string, message *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var raw bytes.Buffer
+	if _, err := buildMessage(s.source, recipient, message).WriteTo(&raw); err != nil {
+		return fmt.Errorf("encode message: %w", err)
+	}
+
+	var entry bytes.Buffer
+	fmt.Fprintf(&entry, "From %s %s\n", s.source, time.Now().UTC().Format(time.ANSIC))
+	writeMboxrd(&entry, raw.Bytes())
+	entry.WriteString("\n")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open mbox %s: %w", s.path, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(entry.Bytes()); err != nil {
+		return fmt.Errorf("write mbox %s: %w", s.path, err)
+	}
+
+	s.logger.Infow("Email written to mbox", "recipient", recipient, "path", s.path)
+	return nil
+}
+
+// writeMboxrd copies the message with LF line endings and quotes any line
+// matching ^>*From so it is not mistaken for a message separator.
+func writeMboxrd(buf *bytes.Buffer, raw []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(raw)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSuffix(scanner.Bytes(), []byte("\r"))
+		if bytes.HasPrefix(bytes.TrimLeft(line, ">"), []byte("From ")) {
+			buf.WriteByte('>')
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+}
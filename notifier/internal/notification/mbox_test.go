@@ -0,0 +1,49 @@
+package notification_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/notification"
+	"go.uber.org/zap"
+)
+
+func TestFileSender_Send(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.mbox")
+	sender := notification.NewFileSender(path, "noreply@example.com", zap.NewNop().Sugar())
+
+	messages := []*notification.Message{
+		{Subject: "First", Text: "Hello\nFrom the team\n", HTML: "<p>Hello</p>"},
+		{Subject: "Second", Text: "Bye\n"},
+	}
+	for _, m := range messages {
+		if err := sender.Send(context.Background(), "jane@example.com", m); err != nil {
+			t.Fatalf("Send() failed: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read mbox: %v", err)
+	}
+	mbox := string(data)
+
+	separators := 0
+	for _, line := range strings.Split(mbox, "\n") {
+		if strings.HasPrefix(line, "From noreply@example.com ") {
+			separators++
+		}
+	}
+	if separators != len(messages) {
+		t.Errorf("expected %d message separators, found %d:\n%s", len(messages), separators, mbox)
+	}
+	if !strings.Contains(mbox, "\n>From the team") {
+		t.Errorf("body line starting with From was not quoted:\n%s", mbox)
+	}
+	if !strings.Contains(mbox, "Subject: Second") {
+		t.Errorf("second message missing:\n%s", mbox)
+	}
+}
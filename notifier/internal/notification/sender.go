@@ -2,8 +2,27 @@ package notification
 
 import (
 	"context"
+	"errors"
 )
 
+// ErrNotConfigured is returned by UnconfiguredSender for every message.
+var ErrNotConfigured = errors.New("no email server is configured")
+
+// Message is a rendered notification with a plain-text and an HTML part.
+type Message struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
 type Sender interface {
-	Send(ctx context.Context, recipient string, message string) error
+	Send(ctx context.Context, recipient string, message *Message) error
+}
+
+// UnconfiguredSender fails every message. It stands in for the email sender
+// when the notifier runs without SMTP or Gmail settings.
+type UnconfiguredSender struct{}
+
+func (UnconfiguredSender) Send(ctx context.Context, recipient string, message *Message) error {
+	return ErrNotConfigured
 }
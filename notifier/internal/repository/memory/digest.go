@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/repository"
+	"github.com/google/uuid"
+)
+
+type pendingDigest struct {
+	dueAt   time.Time
+	entries []model.DigestEntry
+}
+
+type claimedDigest struct {
+	digest     model.Digest
+	leaseUntil time.Time
+}
+
+type sentDigest struct {
+	userID uuid.UUID
+	period int64
+}
+
+type DigestRepository struct {
+	mu      sync.Mutex
+	pending map[uuid.UUID]*pendingDigest
+	claimed map[string]*claimedDigest
+	sent    map[sentDigest]struct{}
+	seq     int
+}
+
+func NewDigestRepository() *DigestRepository {
+	return &DigestRepository{
+		pending: make(map[uuid.UUID]*pendingDigest),
+		claimed: make(map[string]*claimedDigest),
+		sent:    make(map[sentDigest]struct{}),
+	}
+}
+
+func (r *DigestRepository) Add(ctx context.Context, entry *model.DigestEntry, dueAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.pending[entry.UserID]
+	if !ok {
+		p = &pendingDigest{dueAt: dueAt}
+		r.pending[entry.UserID] = p
+	}
+	if dueAt.Before(p.dueAt) {
+		p.dueAt = dueAt
+	}
+	p.entries = append(p.entries, *entry)
+	return nil
+}
+
+func (r *DigestRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.Digest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	claimed := []model.Digest{}
+	full := func() bool { return limit > 0 && len(claimed) >= limit }
+
+	expired := make([]string, 0)
+	for id, c := range r.claimed {
+		if !c.leaseUntil.After(now) {
+			expired = append(expired, id)
+		}
+	}
+	sort.Strings(expired)
+	for _, id := range expired {
+		if full() {
+			return claimed, nil
+		}
+		c := r.claimed[id]
+		c.leaseUntil = now.Add(lease)
+		claimed = append(claimed, c.digest)
+	}
+
+	due := make([]uuid.UUID, 0)
+	for userID, p := range r.pending {
+		if !p.dueAt.After(now) {
+			due = append(due, userID)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return r.pending[due[i]].dueAt.Before(r.pending[due[j]].dueAt)
+	})
+	for _, userID := range due {
+		if full() {
+			break
+		}
+		r.seq++
+		p := r.pending[userID]
+		digest := model.Digest{
+			ID:      fmt.Sprintf("%s:%d:%d", userID, p.dueAt.Unix(), r.seq),
+			UserID:  userID,
+			Period:  time.Unix(p.dueAt.Unix(), 0),
+			Entries: p.entries,
+		}
+		delete(r.pending, userID)
+		r.claimed[digest.ID] = &claimedDigest{digest: digest, leaseUntil: now.Add(lease)}
+		claimed = append(claimed, digest)
+	}
+	return claimed, nil
+}
+
+func (r *DigestRepository) Ack(ctx context.Context, digestID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.claimed[digestID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.claimed, digestID)
+	r.sent[sentDigest{userID: c.digest.UserID, period: c.digest.Period.Unix()}] = struct{}{}
+	return nil
+}
+
+func (r *DigestRepository) Sent(ctx context.Context, userID uuid.UUID, period time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.sent[sentDigest{userID: userID, period: period.Unix()}]
+	return ok, nil
+}
@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/repository"
+	"github.com/google/uuid"
+)
+
+// maxNotificationsPerUser bounds each inbox; the oldest items are dropped.
+const maxNotificationsPerUser = 1000
+
+type InboxRepository struct {
+	mu sync.RWMutex
+	// inboxes holds each user's notifications, newest first.
+	inboxes map[uuid.UUID][]*model.Notification
+}
+
+func NewInboxRepository() *InboxRepository {
+	return &InboxRepository{
+		inboxes: make(map[uuid.UUID][]*model.Notification),
+	}
+}
+
+func (r *InboxRepository) Add(ctx context.Context, notification *model.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := *notification
+	for _, stored := range r.inboxes[n.UserID] {
+		if stored.ID == n.ID {
+			return nil
+		}
+	}
+	inbox := append(r.inboxes[n.UserID], &n)
+	sort.SliceStable(inbox, func(i, j int) bool {
+		return newerThan(inbox[i], inbox[j].CreatedAt, inbox[j].ID)
+	})
+	if len(inbox) > maxNotificationsPerUser {
+		inbox = inbox[:maxNotificationsPerUser]
+	}
+	r.inboxes[n.UserID] = inbox
+	return nil
+}
+
+func (r *InboxRepository) List(ctx context.Context, userID uuid.UUID, query repository.InboxQuery) ([]model.Notification, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	page := []model.Notification{}
+	for _, n := range r.inboxes[userID] {
+		if query.Limit > 0 && len(page) >= query.Limit {
+			break
+		}
+		if query.Before != nil && !newerThan(&model.Notification{CreatedAt: query.Before.CreatedAt, ID: query.Before.ID}, n.CreatedAt, n.ID) {
+			continue
+		}
+		if query.UnreadOnly && n.Read() {
+			continue
+		}
+		page = append(page, *n)
+	}
+	return page, nil
+}
+
+func (r *InboxRepository) MarkRead(ctx context.Context, userID uuid.UUID, ids []uuid.UUID, at time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wanted := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	updated := 0
+	for _, n := range r.inboxes[userID] {
+		if wanted[n.ID] && !n.Read() {
+			readAt := at
+			n.ReadAt = &readAt
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+func (r *InboxRepository) MarkAllRead(ctx context.Context, userID uuid.UUID, at time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	updated := 0
+	for _, n := range r.inboxes[userID] {
+		if !n.Read() {
+			readAt := at
+			n.ReadAt = &readAt
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+func (r *InboxRepository) UnreadCount(ctx context.Context, userID uuid.UUID) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, n := range r.inboxes[userID] {
+		if !n.Read() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// newerThan orders notifications by creation time, then by ID.
+func newerThan(n *model.Notification, createdAt time.Time, id uuid.UUID) bool {
+	if !n.CreatedAt.Equal(createdAt) {
+		return n.CreatedAt.After(createdAt)
+	}
+	return n.ID.String() > id.String()
+}
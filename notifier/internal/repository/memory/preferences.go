@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/repository"
+	"github.com/google/uuid"
+)
+
+type PreferencesRepository struct {
+	mu    sync.RWMutex
+	prefs map[uuid.UUID]model.Preferences
+}
+
+func NewPreferencesRepository() *PreferencesRepository {
+	return &PreferencesRepository{
+		prefs: make(map[uuid.UUID]model.Preferences),
+	}
+}
+
+func (r *PreferencesRepository) Get(ctx context.Context, userID uuid.UUID) (*model.Preferences, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if p, ok := r.prefs[userID]; ok {
+		return &p, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *PreferencesRepository) Save(ctx context.Context, prefs *model.Preferences) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prefs[prefs.UserID] = *prefs
+	return nil
+}
+
+type claimedNotification struct {
+	held       model.HeldNotification
+	leaseUntil time.Time
+}
+
+type HeldNotificationRepository struct {
+	mu      sync.Mutex
+	held    map[uuid.UUID]model.HeldNotification
+	claimed map[uuid.UUID]*claimedNotification
+}
+
+func NewHeldNotificationRepository() *HeldNotificationRepository {
+	return &HeldNotificationRepository{
+		held:    make(map[uuid.UUID]model.HeldNotification),
+		claimed: make(map[uuid.UUID]*claimedNotification),
+	}
+}
+
+func (r *HeldNotificationRepository) Hold(ctx context.Context, held *model.HeldNotification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.held[held.ID] = *held
+	return nil
+}
+
+func (r *HeldNotificationRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.HeldNotification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	claimed := []model.HeldNotification{}
+	full := func() bool { return limit > 0 && len(claimed) >= limit }
+
+	expired := make([]*claimedNotification, 0)
+	for _, c := range r.claimed {
+		if !c.leaseUntil.After(now) {
+			expired = append(expired, c)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].held.ReleaseAt.Before(expired[j].held.ReleaseAt)
+	})
+	for _, c := range expired {
+		if full() {
+			return claimed, nil
+		}
+		c.leaseUntil = now.Add(lease)
+		claimed = append(claimed, c.held)
+	}
+
+	due := []model.HeldNotification{}
+	for _, h := range r.held {
+		if !h.ReleaseAt.After(now) {
+			due = append(due, h)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].ReleaseAt.Before(due[j].ReleaseAt)
+	})
+	for _, h := range due {
+		if full() {
+			break
+		}
+		delete(r.held, h.ID)
+		r.claimed[h.ID] = &claimedNotification{held: h, leaseUntil: now.Add(lease)}
+		claimed = append(claimed, h)
+	}
+	return claimed, nil
+}
+
+func (r *HeldNotificationRepository) Ack(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.claimed[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.claimed, id)
+	return nil
+}
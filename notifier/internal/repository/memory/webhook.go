@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/repository"
+	"github.com/google/uuid"
+)
+
+// maxDeliveriesPerEndpoint bounds the delivery log kept for each endpoint.
+const maxDeliveriesPerEndpoint = 500
+
+type pendingDelivery struct {
+	delivery   model.PendingDelivery
+	claimed    bool
+	leaseUntil time.Time
+}
+
+type WebhookRepository struct {
+	mu         sync.RWMutex
+	endpoints  map[uuid.UUID]*model.WebhookEndpoint
+	deliveries map[uuid.UUID][]model.WebhookDelivery
+	pending    map[uuid.UUID]*pendingDelivery
+}
+
+func NewWebhookRepository() *WebhookRepository {
+	return &WebhookRepository{
+		endpoints:  make(map[uuid.UUID]*model.WebhookEndpoint),
+		deliveries: make(map[uuid.UUID][]model.WebhookDelivery),
+		pending:    make(map[uuid.UUID]*pendingDelivery),
+	}
+}
+
+func (r *WebhookRepository) CreateEndpoint(ctx context.Context, endpoint *model.WebhookEndpoint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e := *endpoint
+	r.endpoints[endpoint.ID] = &e
+	return nil
+}
+
+func (r *WebhookRepository) GetEndpoint(ctx context.Context, id uuid.UUID) (*model.WebhookEndpoint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if e, ok := r.endpoints[id]; ok {
+		endpoint := *e
+		return &endpoint, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *WebhookRepository) ListEndpoints(ctx context.Context, owner model.Owner) ([]model.WebhookEndpoint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	endpoints := []model.WebhookEndpoint{}
+	for _, e := range r.endpoints {
+		if e.Owner == owner {
+			endpoints = append(endpoints, *e)
+		}
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		return endpoints[i].CreatedAt.Before(endpoints[j].CreatedAt)
+	})
+	return endpoints, nil
+}
+
+func (r *WebhookRepository) DeleteEndpoint(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.endpoints[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.endpoints, id)
+	delete(r.deliveries, id)
+	return nil
+}
+
+func (r *WebhookRepository) MatchEndpoints(ctx context.Context, owners []model.Owner, eventType string) ([]model.WebhookEndpoint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	endpoints := []model.WebhookEndpoint{}
+	for _, e := range r.endpoints {
+		if !e.Accepts(eventType) {
+			continue
+		}
+		for _, owner := range owners {
+			if e.Owner == owner {
+				endpoints = append(endpoints, *e)
+				break
+			}
+		}
+	}
+	return endpoints, nil
+}
+
+func (r *WebhookRepository) RecordDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	log := append(r.deliveries[delivery.EndpointID], *delivery)
+	if len(log) > maxDeliveriesPerEndpoint {
+		log = log[len(log)-maxDeliveriesPerEndpoint:]
+	}
+	r.deliveries[delivery.EndpointID] = log
+	return nil
+}
+
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, endpointID uuid.UUID, limit int) ([]model.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	log := r.deliveries[endpointID]
+	if limit <= 0 || limit > len(log) {
+		limit = len(log)
+	}
+
+	deliveries := make([]model.WebhookDelivery, 0, limit)
+	for i := len(log) - 1; i >= len(log)-limit; i-- {
+		deliveries = append(deliveries, log[i])
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) Schedule(ctx context.Context, delivery *model.PendingDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending[delivery.ID] = &pendingDelivery{delivery: *delivery}
+	return nil
+}
+
+func (r *WebhookRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.PendingDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	due := make([]*pendingDelivery, 0)
+	for _, p := range r.pending {
+		if p.claimed && !p.leaseUntil.After(now) || !p.claimed && !p.delivery.DueAt.After(now) {
+			due = append(due, p)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].delivery.DueAt.Before(due[j].delivery.DueAt)
+	})
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]model.PendingDelivery, 0, len(due))
+	for _, p := range due {
+		p.claimed = true
+		p.leaseUntil = now.Add(lease)
+		claimed = append(claimed, p.delivery)
+	}
+	return claimed, nil
+}
+
+func (r *WebhookRepository) Ack(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.pending[id]; !ok || !p.claimed {
+		return repository.ErrNotFound
+	}
+	delete(r.pending, id)
+	return nil
+}
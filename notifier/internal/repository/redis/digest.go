@@ -0,0 +1,204 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/repository"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// The hash tag keeps every digest key in one cluster slot, since the claim
+// script derives the per-user keys from the due set.
+const (
+	digestKeyPrefix  = "notifier:{digest}:"
+	digestDueKey     = digestKeyPrefix + "due"
+	digestClaimedKey = digestKeyPrefix + "claimed"
+	digestSeqKey     = digestKeyPrefix + "seq"
+)
+
+// digestSentTTL is how long a sent digest is remembered, well past any lease
+// another instance may still hold on it.
+const digestSentTTL = 24 * time.Hour
+
+func digestPendingKey(userID uuid.UUID) string {
+	return digestKeyPrefix + "pending:" + userID.String()
+}
+
+func digestBatchKey(digestID string) string {
+	return digestKeyPrefix + "batch:" + digestID
+}
+
+func digestSentKey(userID uuid.UUID, period time.Time) string {
+	return digestKeyPrefix + "sent:" + userID.String() + ":" + strconv.FormatInt(period.Unix(), 10)
+}
+
+// DigestRepository keeps each user's pending entries in a list and the time
+// the batch is due in a sorted set. Claiming renames the list to a batch key
+// named after the user and due time and leases it in a second sorted set;
+// the batch is only deleted by Ack, so entries survive a restart between
+// claiming and sending. Ack also leaves a marker for the user and period that
+// Sent reports.
+type DigestRepository struct {
+	rdb *redis.Client
+}
+
+func NewDigestRepository(rdb *redis.Client) *DigestRepository {
+	return &DigestRepository{rdb: rdb}
+}
+
+func (r *DigestRepository) Add(ctx context.Context, entry *model.DigestEntry, dueAt time.Time) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, digestPendingKey(entry.UserID), data)
+		// LT keeps the earliest due time of the pending batch.
+		pipe.ZAddLT(ctx, digestDueKey, redis.Z{Score: float64(dueAt.Unix()), Member: entry.UserID.String()})
+		return nil
+	})
+	return err
+}
+
+// claimDigestScript re-leases expired batches first, then turns due pending
+// lists into batches. It returns alternating batch IDs and batch entries.
+var claimDigestScript = redis.NewScript(`
+local out = {}
+local limit = tonumber(ARGV[3])
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, limit)
+for _, id in ipairs(expired) do
+	redis.call('ZADD', KEYS[2], ARGV[2], id)
+	table.insert(out, id)
+	table.insert(out, redis.call('LRANGE', ARGV[4] .. 'batch:' .. id, 0, -1))
+end
+local remaining = limit - #expired
+if remaining > 0 then
+	local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'WITHSCORES', 'LIMIT', 0, remaining)
+	for i = 1, #due, 2 do
+		local user = due[i]
+		redis.call('ZREM', KEYS[1], user)
+		local pending = ARGV[4] .. 'pending:' .. user
+		if redis.call('EXISTS', pending) == 1 then
+			local id = user .. ':' .. string.format('%d', tonumber(due[i + 1])) .. ':' .. redis.call('INCR', KEYS[3])
+			redis.call('RENAME', pending, ARGV[4] .. 'batch:' .. id)
+			redis.call('ZADD', KEYS[2], ARGV[2], id)
+			table.insert(out, id)
+			table.insert(out, redis.call('LRANGE', ARGV[4] .. 'batch:' .. id, 0, -1))
+		end
+	end
+end
+return out
+`)
+
+func (r *DigestRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.Digest, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	res, err := claimDigestScript.Run(ctx, r.rdb,
+		[]string{digestDueKey, digestClaimedKey, digestSeqKey},
+		strconv.FormatInt(now.Unix(), 10),
+		strconv.FormatInt(now.Add(lease).Unix(), 10),
+		limit,
+		digestKeyPrefix,
+	).Slice()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return []model.Digest{}, nil
+		}
+		return nil, err
+	}
+
+	digests := make([]model.Digest, 0, len(res)/2)
+	for i := 0; i+1 < len(res); i += 2 {
+		digest, err := parseDigest(res[i], res[i+1])
+		if err != nil {
+			return nil, err
+		}
+		digests = append(digests, digest)
+	}
+	return digests, nil
+}
+
+func (r *DigestRepository) Ack(ctx context.Context, digestID string) error {
+	userID, period, err := parseDigestID(digestID)
+	if err != nil {
+		return err
+	}
+
+	var removed *redis.IntCmd
+	_, err = r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		removed = pipe.ZRem(ctx, digestClaimedKey, digestID)
+		pipe.Del(ctx, digestBatchKey(digestID))
+		pipe.Set(ctx, digestSentKey(userID, period), digestID, digestSentTTL)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if removed.Val() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *DigestRepository) Sent(ctx context.Context, userID uuid.UUID, period time.Time) (bool, error) {
+	n, err := r.rdb.Exists(ctx, digestSentKey(userID, period)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// parseDigestID returns the user and due time in a batch ID, which has the
+// form <user>:<due unix seconds>:<seq>.
+func parseDigestID(id string) (uuid.UUID, time.Time, error) {
+	parts := strings.Split(id, ":")
+	if len(parts) != 3 {
+		return uuid.Nil, time.Time{}, fmt.Errorf("invalid digest id %q", id)
+	}
+	userID, err := uuid.Parse(parts[0])
+	if err != nil {
+		return uuid.Nil, time.Time{}, fmt.Errorf("invalid digest id %q: %w", id, err)
+	}
+	due, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return uuid.Nil, time.Time{}, fmt.Errorf("invalid digest id %q: %w", id, err)
+	}
+	return userID, time.Unix(due, 0), nil
+}
+
+func parseDigest(rawID, rawEntries any) (model.Digest, error) {
+	id, ok := rawID.(string)
+	if !ok {
+		return model.Digest{}, fmt.Errorf("unexpected digest id %v", rawID)
+	}
+	userID, period, err := parseDigestID(id)
+	if err != nil {
+		return model.Digest{}, err
+	}
+
+	digest := model.Digest{ID: id, UserID: userID, Period: period}
+	items, _ := rawEntries.([]any)
+	for _, item := range items {
+		data, ok := item.(string)
+		if !ok {
+			continue
+		}
+		var entry model.DigestEntry
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			continue
+		}
+		digest.Entries = append(digest.Entries, entry)
+	}
+	return digest, nil
+}
@@ -0,0 +1,122 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/repository"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	heldQueueKey   = "notifier:held"
+	heldClaimedKey = "notifier:held:claimed"
+	heldDataKey    = "notifier:held:data"
+)
+
+// HeldNotificationRepository keeps held notifications in a sorted set scored
+// by release time, so they survive restarts and can be shared by several
+// notifier instances. Claiming moves them to a second sorted set scored by
+// lease expiry; their data is only deleted by Ack, so a notification whose
+// sender failed or crashed is claimed again once the lease expires.
+type HeldNotificationRepository struct {
+	rdb *redis.Client
+}
+
+func NewHeldNotificationRepository(rdb *redis.Client) *HeldNotificationRepository {
+	return &HeldNotificationRepository{rdb: rdb}
+}
+
+func (r *HeldNotificationRepository) Hold(ctx context.Context, held *model.HeldNotification) error {
+	data, err := json.Marshal(held)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, heldDataKey, held.ID.String(), data)
+		pipe.ZAdd(ctx, heldQueueKey, redis.Z{Score: float64(held.ReleaseAt.Unix()), Member: held.ID.String()})
+		return nil
+	})
+	return err
+}
+
+// claimHeldScript re-leases expired claims first, then moves due
+// notifications to the claimed set. It returns their data.
+var claimHeldScript = redis.NewScript(`
+local out = {}
+local limit = tonumber(ARGV[3])
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, limit)
+for _, id in ipairs(expired) do
+	local data = redis.call('HGET', KEYS[3], id)
+	if data then
+		redis.call('ZADD', KEYS[2], ARGV[2], id)
+		table.insert(out, data)
+	else
+		redis.call('ZREM', KEYS[2], id)
+	end
+end
+local remaining = limit - #expired
+if remaining > 0 then
+	local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, remaining)
+	for _, id in ipairs(ids) do
+		redis.call('ZREM', KEYS[1], id)
+		local data = redis.call('HGET', KEYS[3], id)
+		if data then
+			redis.call('ZADD', KEYS[2], ARGV[2], id)
+			table.insert(out, data)
+		end
+	end
+end
+return out
+`)
+
+func (r *HeldNotificationRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.HeldNotification, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	res, err := claimHeldScript.Run(ctx, r.rdb,
+		[]string{heldQueueKey, heldClaimedKey, heldDataKey},
+		strconv.FormatInt(now.Unix(), 10),
+		strconv.FormatInt(now.Add(lease).Unix(), 10),
+		limit,
+	).StringSlice()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return []model.HeldNotification{}, nil
+		}
+		return nil, err
+	}
+
+	held := make([]model.HeldNotification, 0, len(res))
+	for _, data := range res {
+		var h model.HeldNotification
+		if err := json.Unmarshal([]byte(data), &h); err != nil {
+			continue
+		}
+		held = append(held, h)
+	}
+	return held, nil
+}
+
+func (r *HeldNotificationRepository) Ack(ctx context.Context, id uuid.UUID) error {
+	var removed *redis.IntCmd
+	_, err := r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		removed = pipe.ZRem(ctx, heldClaimedKey, id.String())
+		pipe.HDel(ctx, heldDataKey, id.String())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if removed.Val() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/repository"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	inboxKeyPrefix = "notifier:inbox:"
+
+	// maxNotificationsPerUser bounds each inbox; the oldest items are dropped.
+	maxNotificationsPerUser = 1000
+)
+
+// inboxKeys are the sorted set of a user's notification IDs scored by
+// creation time in microseconds, the hash of their data, and the hash of
+// the read times of those that were read.
+func inboxKeys(userID uuid.UUID) []string {
+	key := inboxKeyPrefix + userID.String()
+	return []string{key, key + ":data", key + ":read"}
+}
+
+// InboxRepository keeps each user's inbox in Redis, so it survives restarts
+// and all notifier instances serve the same one. Notifications with the same
+// score are ordered by ID, newest first as in the memory repository.
+type InboxRepository struct {
+	rdb *redis.Client
+}
+
+func NewInboxRepository(rdb *redis.Client) *InboxRepository {
+	return &InboxRepository{rdb: rdb}
+}
+
+// addInboxScript adds a notification unless it is stored already, and drops
+// the oldest ones beyond the limit.
+var addInboxScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[2], ARGV[2]) == 1 then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[1], ARGV[2])
+redis.call('HSET', KEYS[2], ARGV[2], ARGV[3])
+if ARGV[4] ~= '' then
+	redis.call('HSET', KEYS[3], ARGV[2], ARGV[4])
+end
+local excess = redis.call('ZCARD', KEYS[1]) - tonumber(ARGV[5])
+if excess > 0 then
+	local oldest = redis.call('ZRANGE', KEYS[1], 0, excess - 1)
+	for _, id in ipairs(oldest) do
+		redis.call('ZREM', KEYS[1], id)
+		redis.call('HDEL', KEYS[2], id)
+		redis.call('HDEL', KEYS[3], id)
+	end
+end
+return 0
+`)
+
+func (r *InboxRepository) Add(ctx context.Context, notification *model.Notification) error {
+	n := *notification
+	var readAt string
+	if n.ReadAt != nil {
+		readAt = n.ReadAt.Format(time.RFC3339Nano)
+	}
+	n.ReadAt = nil
+	data, err := json.Marshal(&n)
+	if err != nil {
+		return err
+	}
+
+	return addInboxScript.Run(ctx, r.rdb, inboxKeys(n.UserID),
+		n.CreatedAt.UnixMicro(), n.ID.String(), data, readAt, maxNotificationsPerUser,
+	).Err()
+}
+
+// listInboxScript walks the inbox newest first from the cursor, if any, and
+// returns the data and read time, empty if unread, of each notification on the
+// page.
+var listInboxScript = redis.NewScript(`
+local max = ARGV[1]
+local cursorScore = tonumber(ARGV[1])
+local cursorID = ARGV[2]
+local limit = tonumber(ARGV[3])
+local unreadOnly = ARGV[4] == '1'
+local out = {}
+local offset = 0
+while true do
+	local batch = redis.call('ZREVRANGEBYSCORE', KEYS[1], max, '-inf', 'WITHSCORES', 'LIMIT', offset, 100)
+	if #batch == 0 then
+		return out
+	end
+	offset = offset + #batch / 2
+	for i = 1, #batch, 2 do
+		local id = batch[i]
+		local newer = cursorID ~= '' and tonumber(batch[i + 1]) == cursorScore and id >= cursorID
+		local readAt = redis.call('HGET', KEYS[3], id) or ''
+		local data = redis.call('HGET', KEYS[2], id)
+		if data and not newer and not (unreadOnly and readAt ~= '') then
+			table.insert(out, data)
+			table.insert(out, readAt)
+			if limit > 0 and #out >= limit * 2 then
+				return out
+			end
+		end
+	end
+end
+`)
+
+func (r *InboxRepository) List(ctx context.Context, userID uuid.UUID, query repository.InboxQuery) ([]model.Notification, error) {
+	maxScore, cursorID := "+inf", ""
+	if query.Before != nil {
+		maxScore = strconv.FormatInt(query.Before.CreatedAt.UnixMicro(), 10)
+		cursorID = query.Before.ID.String()
+	}
+	unreadOnly := "0"
+	if query.UnreadOnly {
+		unreadOnly = "1"
+	}
+
+	res, err := listInboxScript.Run(ctx, r.rdb, inboxKeys(userID),
+		maxScore, cursorID, query.Limit, unreadOnly,
+	).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	page := make([]model.Notification, 0, len(res)/2)
+	for i := 0; i+1 < len(res); i += 2 {
+		var n model.Notification
+		if err := json.Unmarshal([]byte(res[i]), &n); err != nil {
+			continue
+		}
+		if res[i+1] != "" {
+			readAt, err := time.Parse(time.RFC3339Nano, res[i+1])
+			if err == nil {
+				n.ReadAt = &readAt
+			}
+		}
+		page = append(page, n)
+	}
+	return page, nil
+}
+
+// markReadScript sets the read time of the listed notifications, or of all
+// of them without arguments after the time, and returns how many were
+// unread.
+var markReadScript = redis.NewScript(`
+local ids = {}
+for i = 2, #ARGV do
+	table.insert(ids, ARGV[i])
+end
+if #ARGV == 1 then
+	ids = redis.call('ZRANGE', KEYS[1], 0, -1)
+end
+local updated = 0
+for _, id in ipairs(ids) do
+	if redis.call('ZSCORE', KEYS[1], id) then
+		updated = updated + redis.call('HSETNX', KEYS[3], id, ARGV[1])
+	end
+end
+return updated
+`)
+
+func (r *InboxRepository) MarkRead(ctx context.Context, userID uuid.UUID, ids []uuid.UUID, at time.Time) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	args := make([]any, 0, len(ids)+1)
+	args = append(args, at.Format(time.RFC3339Nano))
+	for _, id := range ids {
+		args = append(args, id.String())
+	}
+	return markReadScript.Run(ctx, r.rdb, inboxKeys(userID), args...).Int()
+}
+
+func (r *InboxRepository) MarkAllRead(ctx context.Context, userID uuid.UUID, at time.Time) (int, error) {
+	return markReadScript.Run(ctx, r.rdb, inboxKeys(userID), at.Format(time.RFC3339Nano)).Int()
+}
+
+func (r *InboxRepository) UnreadCount(ctx context.Context, userID uuid.UUID) (int, error) {
+	keys := inboxKeys(userID)
+	var total, read *redis.IntCmd
+	_, err := r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		total = pipe.ZCard(ctx, keys[0])
+		read = pipe.HLen(ctx, keys[2])
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(total.Val() - read.Val()), nil
+}
@@ -0,0 +1,46 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/repository"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const preferencesKeyPrefix = "notifier:preferences:"
+
+type PreferencesRepository struct {
+	rdb *redis.Client
+}
+
+func NewPreferencesRepository(rdb *redis.Client) *PreferencesRepository {
+	return &PreferencesRepository{rdb: rdb}
+}
+
+func (r *PreferencesRepository) Get(ctx context.Context, userID uuid.UUID) (*model.Preferences, error) {
+	data, err := r.rdb.Get(ctx, preferencesKeyPrefix+userID.String()).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+
+	var prefs model.Preferences
+	if err := json.Unmarshal(data, &prefs); err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+func (r *PreferencesRepository) Save(ctx context.Context, prefs *model.Preferences) error {
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		return err
+	}
+	return r.rdb.Set(ctx, preferencesKeyPrefix+prefs.UserID.String(), data, 0).Err()
+}
@@ -0,0 +1,287 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/repository"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	webhookEndpointsKey        = "notifier:webhook:endpoints"
+	webhookOwnerKeyPrefix      = "notifier:webhook:owner:"
+	webhookDeliveriesKeyPrefix = "notifier:webhook:deliveries:"
+	webhookPendingKey          = "notifier:webhook:pending"
+	webhookClaimedKey          = "notifier:webhook:pending:claimed"
+	webhookPendingDataKey      = "notifier:webhook:pending:data"
+
+	// maxDeliveriesPerEndpoint bounds the delivery log kept for each endpoint.
+	maxDeliveriesPerEndpoint = 500
+)
+
+// WebhookRepository keeps endpoints in a hash by ID, with a sorted set of
+// endpoint IDs per owner scored by creation time. The delivery log of each
+// endpoint is a capped list, newest first.
+//
+// Pending deliveries are kept like held notifications: a sorted set scored by
+// due time in milliseconds, and once claimed a second one scored by lease
+// expiry, with their data in a hash until Ack.
+type WebhookRepository struct {
+	rdb *redis.Client
+}
+
+func NewWebhookRepository(rdb *redis.Client) *WebhookRepository {
+	return &WebhookRepository{rdb: rdb}
+}
+
+func (r *WebhookRepository) CreateEndpoint(ctx context.Context, endpoint *model.WebhookEndpoint) error {
+	data, err := json.Marshal(endpoint)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, webhookEndpointsKey, endpoint.ID.String(), data)
+		pipe.ZAdd(ctx, ownerKey(endpoint.Owner), redis.Z{
+			Score:  float64(endpoint.CreatedAt.UnixMilli()),
+			Member: endpoint.ID.String(),
+		})
+		return nil
+	})
+	return err
+}
+
+func (r *WebhookRepository) GetEndpoint(ctx context.Context, id uuid.UUID) (*model.WebhookEndpoint, error) {
+	data, err := r.rdb.HGet(ctx, webhookEndpointsKey, id.String()).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+
+	var endpoint model.WebhookEndpoint
+	if err := json.Unmarshal(data, &endpoint); err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (r *WebhookRepository) ListEndpoints(ctx context.Context, owner model.Owner) ([]model.WebhookEndpoint, error) {
+	return r.ownerEndpoints(ctx, owner)
+}
+
+func (r *WebhookRepository) DeleteEndpoint(ctx context.Context, id uuid.UUID) error {
+	endpoint, err := r.GetEndpoint(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, webhookEndpointsKey, id.String())
+		pipe.ZRem(ctx, ownerKey(endpoint.Owner), id.String())
+		pipe.Del(ctx, webhookDeliveriesKeyPrefix+id.String())
+		return nil
+	})
+	return err
+}
+
+func (r *WebhookRepository) MatchEndpoints(ctx context.Context, owners []model.Owner, eventType string) ([]model.WebhookEndpoint, error) {
+	endpoints := []model.WebhookEndpoint{}
+	for _, owner := range owners {
+		owned, err := r.ownerEndpoints(ctx, owner)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range owned {
+			if e.Accepts(eventType) {
+				endpoints = append(endpoints, e)
+			}
+		}
+	}
+	return endpoints, nil
+}
+
+// recordDeliveryScript only logs deliveries of endpoints that still exist,
+// so a delivery finishing after DeleteEndpoint leaves no orphaned log.
+var recordDeliveryScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('LPUSH', KEYS[2], ARGV[2])
+redis.call('LTRIM', KEYS[2], 0, tonumber(ARGV[3]) - 1)
+return 1
+`)
+
+func (r *WebhookRepository) RecordDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+
+	return recordDeliveryScript.Run(ctx, r.rdb,
+		[]string{webhookEndpointsKey, webhookDeliveriesKeyPrefix + delivery.EndpointID.String()},
+		delivery.EndpointID.String(), data, maxDeliveriesPerEndpoint,
+	).Err()
+}
+
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, endpointID uuid.UUID, limit int) ([]model.WebhookDelivery, error) {
+	stop := int64(-1)
+	if limit > 0 {
+		stop = int64(limit) - 1
+	}
+
+	items, err := r.rdb.LRange(ctx, webhookDeliveriesKeyPrefix+endpointID.String(), 0, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]model.WebhookDelivery, 0, len(items))
+	for _, data := range items {
+		var d model.WebhookDelivery
+		if err := json.Unmarshal([]byte(data), &d); err != nil {
+			continue
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, nil
+}
+
+// ownerEndpoints returns the endpoints of owner, oldest first.
+func (r *WebhookRepository) ownerEndpoints(ctx context.Context, owner model.Owner) ([]model.WebhookEndpoint, error) {
+	ids, err := r.rdb.ZRange(ctx, ownerKey(owner), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []model.WebhookEndpoint{}, nil
+	}
+
+	values, err := r.rdb.HMGet(ctx, webhookEndpointsKey, ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := make([]model.WebhookEndpoint, 0, len(values))
+	for _, v := range values {
+		data, ok := v.(string)
+		if !ok {
+			// Deleted since the IDs were read
+			continue
+		}
+		var e model.WebhookEndpoint
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			continue
+		}
+		endpoints = append(endpoints, e)
+	}
+	return endpoints, nil
+}
+
+func ownerKey(owner model.Owner) string {
+	return fmt.Sprintf("%s%d:%s", webhookOwnerKeyPrefix, owner.Type, owner.ID)
+}
+
+// scheduleScript stores the delivery and moves it from the claimed set, if
+// it was claimed, to the pending set at its due time.
+var scheduleScript = redis.NewScript(`
+redis.call('HSET', KEYS[3], ARGV[1], ARGV[3])
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+return 1
+`)
+
+func (r *WebhookRepository) Schedule(ctx context.Context, delivery *model.PendingDelivery) error {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	return scheduleScript.Run(ctx, r.rdb, pendingKeys(),
+		delivery.ID.String(), delivery.DueAt.UnixMilli(), data,
+	).Err()
+}
+
+// claimPendingScript re-leases expired claims first, then moves due
+// deliveries to the claimed set. It returns their data.
+var claimPendingScript = redis.NewScript(`
+local out = {}
+local limit = tonumber(ARGV[3])
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, limit)
+for _, id in ipairs(expired) do
+	local data = redis.call('HGET', KEYS[3], id)
+	if data then
+		redis.call('ZADD', KEYS[2], ARGV[2], id)
+		table.insert(out, data)
+	else
+		redis.call('ZREM', KEYS[2], id)
+	end
+end
+local remaining = limit - #expired
+if remaining > 0 then
+	local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, remaining)
+	for _, id in ipairs(ids) do
+		redis.call('ZREM', KEYS[1], id)
+		local data = redis.call('HGET', KEYS[3], id)
+		if data then
+			redis.call('ZADD', KEYS[2], ARGV[2], id)
+			table.insert(out, data)
+		end
+	end
+end
+return out
+`)
+
+func (r *WebhookRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.PendingDelivery, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	res, err := claimPendingScript.Run(ctx, r.rdb, pendingKeys(),
+		strconv.FormatInt(now.UnixMilli(), 10),
+		strconv.FormatInt(now.Add(lease).UnixMilli(), 10),
+		limit,
+	).StringSlice()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return []model.PendingDelivery{}, nil
+		}
+		return nil, err
+	}
+
+	deliveries := make([]model.PendingDelivery, 0, len(res))
+	for _, data := range res {
+		var d model.PendingDelivery
+		if err := json.Unmarshal([]byte(data), &d); err != nil {
+			continue
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) Ack(ctx context.Context, id uuid.UUID) error {
+	var removed *redis.IntCmd
+	_, err := r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		removed = pipe.ZRem(ctx, webhookClaimedKey, id.String())
+		pipe.HDel(ctx, webhookPendingDataKey, id.String())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if removed.Val() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func pendingKeys() []string {
+	return []string{webhookPendingKey, webhookClaimedKey, webhookPendingDataKey}
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/google/uuid"
+)
+
+var ErrNotFound = errors.New("resource not found")
+
+type WebhookRepository interface {
+	CreateEndpoint(ctx context.Context, endpoint *model.WebhookEndpoint) error
+	GetEndpoint(ctx context.Context, id uuid.UUID) (*model.WebhookEndpoint, error)
+	ListEndpoints(ctx context.Context, owner model.Owner) ([]model.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, id uuid.UUID) error
+	// MatchEndpoints returns the endpoints of any of the owners subscribed to eventType.
+	MatchEndpoints(ctx context.Context, owners []model.Owner, eventType string) ([]model.WebhookEndpoint, error)
+
+	RecordDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	// ListDeliveries returns the most recent attempts first.
+	ListDeliveries(ctx context.Context, endpointID uuid.UUID, limit int) ([]model.WebhookDelivery, error)
+
+	// Schedule stores a delivery until it is due. Scheduling a claimed
+	// delivery again, for its next attempt, releases the claim.
+	Schedule(ctx context.Context, delivery *model.PendingDelivery) error
+	// ClaimDue leases up to limit due deliveries, including those whose
+	// previous lease expired without an acknowledgement.
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.PendingDelivery, error)
+	// Ack deletes a claimed delivery once it succeeded or was given up.
+	Ack(ctx context.Context, id uuid.UUID) error
+}
+
+type PreferencesRepository interface {
+	// Get returns ErrNotFound for users who never saved preferences.
+	Get(ctx context.Context, userID uuid.UUID) (*model.Preferences, error)
+	Save(ctx context.Context, prefs *model.Preferences) error
+}
+
+// HeldNotificationRepository keeps notifications held during quiet hours.
+// Claimed notifications stay stored until acknowledged, so one whose sender
+// failed or crashed is handed out again once its lease expires.
+type HeldNotificationRepository interface {
+	Hold(ctx context.Context, held *model.HeldNotification) error
+	// ClaimDue leases up to limit notifications whose release time has
+	// passed, including those whose previous lease expired without an
+	// acknowledgement.
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.HeldNotification, error)
+	// Ack deletes a claimed notification once it was sent.
+	Ack(ctx context.Context, id uuid.UUID) error
+}
+
+// DigestRepository collects events for users who receive digests. Claimed
+// batches stay stored until acknowledged, so a batch whose sender crashed is
+// handed out again once its lease expires.
+type DigestRepository interface {
+	// Add appends entry to the user's pending batch. The batch becomes due at
+	// dueAt unless an earlier due time was already set.
+	Add(ctx context.Context, entry *model.DigestEntry, dueAt time.Time) error
+	// ClaimDue leases up to limit due batches, including batches whose
+	// previous lease expired without an acknowledgement.
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.Digest, error)
+	// Ack deletes a claimed batch once its digest was sent, and records that
+	// the user's digest for its period was sent in the same step.
+	Ack(ctx context.Context, digestID string) error
+	// Sent reports whether the user's digest for period was acknowledged,
+	// so a batch claimed again after its lease expired is not sent twice.
+	Sent(ctx context.Context, userID uuid.UUID, period time.Time) (bool, error)
+}
+
+// InboxQuery selects a page of a user's inbox, newest first.
+type InboxQuery struct {
+	// Before, when set, continues after the notification with this position.
+	Before *InboxCursor
+	Limit  int
+	// UnreadOnly skips notifications that were already read.
+	UnreadOnly bool
+}
+
+// InboxCursor is the position of a notification in the inbox order.
+type InboxCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+type InboxRepository interface {
+	// Add stores the notification unless one with its ID is stored already.
+	Add(ctx context.Context, notification *model.Notification) error
+	List(ctx context.Context, userID uuid.UUID, query InboxQuery) ([]model.Notification, error)
+	// MarkRead marks the user's notifications with the given IDs as read and
+	// returns how many were unread before. Unknown IDs are ignored.
+	MarkRead(ctx context.Context, userID uuid.UUID, ids []uuid.UUID, at time.Time) (int, error)
+	MarkAllRead(ctx context.Context, userID uuid.UUID, at time.Time) (int, error)
+	UnreadCount(ctx context.Context, userID uuid.UUID) (int, error)
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/inbox"
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/repository"
+	"github.com/CP-Payne/taskflow/notifier/internal/templates"
+	"github.com/CP-Payne/taskflow/pkg/events"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultInboxPageSize = 20
+	maxInboxPageSize     = 100
+)
+
+// inboxNamespace derives notification IDs from the event and recipient, so
+// every notifier instance handling the event stores the same notification.
+var inboxNamespace = uuid.MustParse("0d6f1c3e-6a52-4f0e-9a53-3f0c2b8f6e41")
+
+type InboxService struct {
+	repo     repository.InboxRepository
+	broker   *inbox.Broker
+	renderer *templates.Renderer
+	logger   *zap.SugaredLogger
+}
+
+func NewInboxService(repo repository.InboxRepository, broker *inbox.Broker, renderer *templates.Renderer, logger *zap.SugaredLogger) *InboxService {
+	return &InboxService{
+		repo:     repo,
+		broker:   broker,
+		renderer: renderer,
+		logger:   logger,
+	}
+}
+
+// NotifyTaskAssigned adds an inbox item for the assignee, titled with the
+// subject of the task.assigned email in the user's language.
+func (s *InboxService) NotifyTaskAssigned(ctx context.Context, env *events.Envelope, prefs *model.Preferences, task *model.Task) error {
+	msg, err := s.renderer.Render(events.TypeTaskAssigned, prefs.Language, templates.TaskAssignedData{
+		User: &model.User{UserID: prefs.UserID},
+		Task: task,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render notification: %w", err)
+	}
+
+	return s.Deliver(ctx, &model.Notification{
+		ID:        uuid.NewSHA1(inboxNamespace, []byte(env.ID+"/"+prefs.UserID.String())),
+		UserID:    prefs.UserID,
+		EventType: env.Type,
+		EventID:   env.ID,
+		Title:     msg.Subject,
+		Body:      task.Description,
+		TaskID:    task.TaskID,
+	})
+}
+
+// Deliver stores the notification and pushes it to the user's watchers.
+func (s *InboxService) Deliver(ctx context.Context, n *model.Notification) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+	if err := s.repo.Add(ctx, n); err != nil {
+		return fmt.Errorf("failed to store notification: %w", err)
+	}
+
+	if dropped := s.broker.Publish(*n); dropped > 0 {
+		s.logger.Warnw("Slow inbox watchers missed a notification", "userID", n.UserID, "notificationID", n.ID, "dropped", dropped)
+	}
+	return nil
+}
+
+// List returns a page of the user's inbox, newest first, and the token of the
+// next page, which is empty on the last page.
+func (s *InboxService) List(ctx context.Context, userID uuid.UUID, pageSize int, pageToken string, unreadOnly bool) ([]model.Notification, string, error) {
+	if pageSize <= 0 {
+		pageSize = defaultInboxPageSize
+	}
+	if pageSize > maxInboxPageSize {
+		pageSize = maxInboxPageSize
+	}
+
+	query := repository.InboxQuery{Limit: pageSize + 1, UnreadOnly: unreadOnly}
+	if pageToken != "" {
+		cursor, err := decodePageToken(pageToken)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: invalid page token", ErrInvalidArgument)
+		}
+		query.Before = cursor
+	}
+
+	notifications, err := s.repo.List(ctx, userID, query)
+	if err != nil {
+		return nil, "", ErrInternal
+	}
+	if len(notifications) <= pageSize {
+		return notifications, "", nil
+	}
+
+	notifications = notifications[:pageSize]
+	last := notifications[pageSize-1]
+	return notifications, encodePageToken(repository.InboxCursor{CreatedAt: last.CreatedAt, ID: last.ID}), nil
+}
+
+func (s *InboxService) MarkRead(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) (int, error) {
+	if len(ids) == 0 {
+		return 0, fmt.Errorf("%w: no notification IDs", ErrInvalidArgument)
+	}
+	updated, err := s.repo.MarkRead(ctx, userID, ids, time.Now())
+	if err != nil {
+		return 0, ErrInternal
+	}
+	return updated, nil
+}
+
+func (s *InboxService) MarkAllRead(ctx context.Context, userID uuid.UUID) (int, error) {
+	updated, err := s.repo.MarkAllRead(ctx, userID, time.Now())
+	if err != nil {
+		return 0, ErrInternal
+	}
+	return updated, nil
+}
+
+func (s *InboxService) UnreadCount(ctx context.Context, userID uuid.UUID) (int, error) {
+	count, err := s.repo.UnreadCount(ctx, userID)
+	if err != nil {
+		return 0, ErrInternal
+	}
+	return count, nil
+}
+
+// Watch subscribes to the user's new notifications. The returned function
+// must be called to release the subscription.
+func (s *InboxService) Watch(userID uuid.UUID) (<-chan model.Notification, func()) {
+	return s.broker.Subscribe(userID)
+}
+
+func encodePageToken(cursor repository.InboxCursor) string {
+	raw := strconv.FormatInt(cursor.CreatedAt.UnixNano(), 10) + "." + cursor.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodePageToken(token string) (*repository.InboxCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	nanos, id, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return nil, fmt.Errorf("malformed page token")
+	}
+	ns, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	notificationID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	return &repository.InboxCursor{CreatedAt: time.Unix(0, ns), ID: notificationID}, nil
+}
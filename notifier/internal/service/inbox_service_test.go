@@ -0,0 +1,122 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/inbox"
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/repository/memory"
+	"github.com/CP-Payne/taskflow/notifier/internal/service"
+	"github.com/CP-Payne/taskflow/notifier/internal/templates"
+	"github.com/CP-Payne/taskflow/pkg/events"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestInboxService(t *testing.T) {
+	ctx := context.Background()
+	renderer, err := templates.New("")
+	if err != nil {
+		t.Fatalf("templates.New() failed: %v", err)
+	}
+	srv := service.NewInboxService(memory.NewInboxRepository(), inbox.NewBroker(10), renderer, zap.NewNop().Sugar())
+
+	userID := uuid.New()
+	watch, stop := srv.Watch(userID)
+	defer stop()
+
+	start := time.Now()
+	var ids []uuid.UUID
+	for i := 0; i < 5; i++ {
+		n := &model.Notification{UserID: userID, Title: "n", CreatedAt: start.Add(time.Duration(i) * time.Second)}
+		if err := srv.Deliver(ctx, n); err != nil {
+			t.Fatalf("Deliver() failed: %v", err)
+		}
+		ids = append(ids, n.ID)
+		if got := <-watch; got.ID != n.ID {
+			t.Errorf("watcher got %v, want %v", got.ID, n.ID)
+		}
+	}
+
+	// Page through newest first.
+	var listed []uuid.UUID
+	token := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination did not terminate")
+		}
+		page, next, err := srv.List(ctx, userID, 2, token, false)
+		if err != nil {
+			t.Fatalf("List() failed: %v", err)
+		}
+		for _, n := range page {
+			listed = append(listed, n.ID)
+		}
+		if next == "" {
+			break
+		}
+		token = next
+	}
+	if len(listed) != 5 {
+		t.Fatalf("expected 5 notifications, got %d", len(listed))
+	}
+	for i, id := range listed {
+		if id != ids[4-i] {
+			t.Errorf("position %d: got %v, want %v", i, id, ids[4-i])
+		}
+	}
+
+	if _, _, err := srv.List(ctx, userID, 2, "not-a-token", false); !errors.Is(err, service.ErrInvalidArgument) {
+		t.Errorf("expected ErrInvalidArgument for a bad token, got %v", err)
+	}
+
+	updated, err := srv.MarkRead(ctx, userID, []uuid.UUID{ids[0], ids[1], uuid.New()})
+	if err != nil || updated != 2 {
+		t.Fatalf("MarkRead() = %d, %v; want 2, nil", updated, err)
+	}
+	if updated, _ := srv.MarkRead(ctx, userID, []uuid.UUID{ids[0]}); updated != 0 {
+		t.Errorf("expected marking a read notification to update nothing, got %d", updated)
+	}
+	if count, _ := srv.UnreadCount(ctx, userID); count != 3 {
+		t.Errorf("unread count: got %d, want 3", count)
+	}
+	unread, _, err := srv.List(ctx, userID, 10, "", true)
+	if err != nil || len(unread) != 3 {
+		t.Errorf("expected 3 unread notifications, got %d (%v)", len(unread), err)
+	}
+
+	if updated, _ := srv.MarkAllRead(ctx, userID); updated != 3 {
+		t.Errorf("MarkAllRead() updated %d, want 3", updated)
+	}
+	if count, _ := srv.UnreadCount(ctx, userID); count != 0 {
+		t.Errorf("unread count after MarkAllRead: got %d, want 0", count)
+	}
+}
+
+func TestInboxService_NotifyTaskAssignedOnce(t *testing.T) {
+	ctx := context.Background()
+	renderer, err := templates.New("")
+	if err != nil {
+		t.Fatalf("templates.New() failed: %v", err)
+	}
+	srv := service.NewInboxService(memory.NewInboxRepository(), inbox.NewBroker(10), renderer, zap.NewNop().Sugar())
+
+	userID := uuid.New()
+	env, err := events.DefaultRegistry.Wrap(&events.TaskAssignedEvent{TaskID: uuid.NewString(), UserID: userID.String()})
+	if err != nil {
+		t.Fatalf("Wrap() failed: %v", err)
+	}
+	// Every notifier instance receives the event and stores its notification
+	for range 2 {
+		if err := srv.NotifyTaskAssigned(ctx, env, model.DefaultPreferences(userID), &model.Task{TaskID: uuid.New()}); err != nil {
+			t.Fatalf("NotifyTaskAssigned() failed: %v", err)
+		}
+	}
+
+	if count, _ := srv.UnreadCount(ctx, userID); count != 1 {
+		t.Errorf("expected one notification per event, got %d", count)
+	}
+}
@@ -2,33 +2,271 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
-	"github.com/CP-Payne/taskflow/notifier/internal/gateway/user"
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
 	"github.com/CP-Payne/taskflow/notifier/internal/notification"
+	"github.com/CP-Payne/taskflow/notifier/internal/repository"
+	"github.com/CP-Payne/taskflow/notifier/internal/templates"
+	"github.com/CP-Payne/taskflow/pkg/events"
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
-// TODO: Turn userGateway into interface
+type UserGateway interface {
+	GetUserDetails(ctx context.Context, userID uuid.UUID) (*model.User, error)
+}
+
 type NotificationService struct {
-	userGateway *user.Gateway
+	userGateway UserGateway
 	emailSender notification.Sender
+	renderer    *templates.Renderer
+	preferences repository.PreferencesRepository
+	held        repository.HeldNotificationRepository
+	digests     repository.DigestRepository
+	logger      *zap.SugaredLogger
+	now         func() time.Time
 }
 
-func NewNotificationService(userGateway *user.Gateway, sender notification.Sender) *NotificationService {
+func NewNotificationService(
+	userGateway UserGateway,
+	sender notification.Sender,
+	renderer *templates.Renderer,
+	preferences repository.PreferencesRepository,
+	held repository.HeldNotificationRepository,
+	digests repository.DigestRepository,
+	logger *zap.SugaredLogger,
+) *NotificationService {
 	return &NotificationService{
 		userGateway: userGateway,
 		emailSender: sender,
+		renderer:    renderer,
+		preferences: preferences,
+		held:        held,
+		digests:     digests,
+		logger:      logger,
+		now:         time.Now,
 	}
 }
 
-func (s *NotificationService) NotifyUserToCompleteTask(ctx context.Context, userID, taskID uuid.UUID) error {
+// Preferences returns the user's saved preferences, or the defaults.
+func (s *NotificationService) Preferences(ctx context.Context, userID uuid.UUID) (*model.Preferences, error) {
+	prefs, err := s.preferences.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return model.DefaultPreferences(userID), nil
+		}
+		return nil, fmt.Errorf("failed to load preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+// NotifyUserToCompleteTask emails the assignee unless their preferences opt
+// out. Users on a digest get the task in their next summary instead, and
+// emails falling in the user's quiet hours are held until the window ends.
+func (s *NotificationService) NotifyUserToCompleteTask(ctx context.Context, userID uuid.UUID, task *model.Task) error {
+	prefs, err := s.Preferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !prefs.Allows(events.TypeTaskAssigned, model.ChannelEmail) {
+		s.logger.Infow("Email notification disabled by user preferences", "userID", userID, "taskID", task.TaskID)
+		return nil
+	}
+
+	if prefs.Digest != model.DigestImmediate {
+		return s.collect(ctx, prefs, events.TypeTaskAssigned, task)
+	}
+
+	if releaseAt, quiet := prefs.QuietHours.ReleaseTime(s.now()); quiet {
+		err := s.held.Hold(ctx, &model.HeldNotification{
+			ID:        uuid.New(),
+			UserID:    userID,
+			EventType: events.TypeTaskAssigned,
+			Task:      *task,
+			ReleaseAt: releaseAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to hold notification: %w", err)
+		}
+		s.logger.Infow("Notification held during quiet hours", "userID", userID, "taskID", task.TaskID, "releaseAt", releaseAt)
+		return nil
+	}
+
+	return s.sendTaskAssigned(ctx, userID, task, prefs.Language)
+}
+
+// NotifyLockedOut emails the user that their account was locked out after
+// failed logins. Being a security notice, it ignores the user's preferences
+// and quiet hours.
+func (s *NotificationService) NotifyLockedOut(ctx context.Context, userID uuid.UUID, event *events.UserLockedOutEvent) error {
+	prefs, err := s.Preferences(ctx, userID)
+	if err != nil {
+		return err
+	}
 	user, err := s.userGateway.GetUserDetails(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to fetch user: %w", err)
 	}
 
-	// TODO: Update Redis event to include additional task information, then updated task message
-	msg := fmt.Sprintf("Hi %s, you have a task (%s) to complete!", user.Username, taskID)
+	msg, err := s.renderer.Render(events.TypeUserLockedOut, prefs.Language, templates.LockedOutData{
+		User:        user,
+		LockedUntil: event.LockedUntil,
+		IP:          event.IP,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render notification: %w", err)
+	}
+
+	return s.emailSender.Send(ctx, user.Email, msg)
+}
+
+// ReleaseHeld sends the held notifications whose quiet hours have ended. A
+// notification is acknowledged only after it was sent; one that fails is
+// claimed again once its lease expires.
+func (s *NotificationService) ReleaseHeld(ctx context.Context) error {
+	due, err := s.held.ClaimDue(ctx, s.now(), claimLease, 100)
+	if err != nil {
+		return fmt.Errorf("failed to claim held notifications: %w", err)
+	}
+
+	var errs []error
+	for _, h := range due {
+		prefs, err := s.Preferences(ctx, h.UserID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.sendTaskAssigned(ctx, h.UserID, &h.Task, prefs.Language); err != nil {
+			s.logger.Errorw("Failed to send held notification", "userID", h.UserID, "taskID", h.Task.TaskID, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.held.Ack(ctx, h.ID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to acknowledge held notification %s: %w", h.ID, err))
+			continue
+		}
+		s.logger.Infow("Released held notification", "userID", h.UserID, "taskID", h.Task.TaskID)
+	}
+	return errors.Join(errs...)
+}
+
+// collect adds the event to the user's digest, due at the end of the current
+// period or, if that falls in quiet hours, when they end.
+func (s *NotificationService) collect(ctx context.Context, prefs *model.Preferences, eventType string, task *model.Task) error {
+	now := s.now()
+	dueAt := prefs.Digest.NextPeriod(now, prefs.Location())
+	if releaseAt, quiet := prefs.QuietHours.ReleaseTime(dueAt); quiet {
+		dueAt = releaseAt
+	}
+
+	err := s.digests.Add(ctx, &model.DigestEntry{
+		ID:         uuid.New(),
+		UserID:     prefs.UserID,
+		EventType:  eventType,
+		Task:       *task,
+		OccurredAt: now,
+	}, dueAt)
+	if err != nil {
+		return fmt.Errorf("failed to add notification to digest: %w", err)
+	}
+	s.logger.Infow("Notification added to digest", "userID", prefs.UserID, "taskID", task.TaskID, "dueAt", dueAt)
+	return nil
+}
+
+// SendDueDigests sends one summary per user whose digest period has ended.
+// A digest is acknowledged only after it was sent; one that fails is claimed
+// again once its lease expires. Delivery is at least once: a digest that was
+// sent but not acknowledged, because the instance crashed or lost Redis in
+// between, is sent again. Digests already acknowledged by another instance
+// whose lease had expired are skipped.
+func (s *NotificationService) SendDueDigests(ctx context.Context) error {
+	due, err := s.digests.ClaimDue(ctx, s.now(), claimLease, 100)
+	if err != nil {
+		return fmt.Errorf("failed to claim digests: %w", err)
+	}
+
+	var errs []error
+	for _, d := range due {
+		sent, err := s.digests.Sent(ctx, d.UserID, d.Period)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to check digest %s: %w", d.ID, err))
+			continue
+		}
+		if sent {
+			s.logger.Infow("Skipped digest that was already sent", "userID", d.UserID, "digestID", d.ID)
+			continue
+		}
+		if err := s.sendDigest(ctx, &d); err != nil {
+			s.logger.Errorw("Failed to send digest", "userID", d.UserID, "digestID", d.ID, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.digests.Ack(ctx, d.ID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to acknowledge digest %s: %w", d.ID, err))
+			continue
+		}
+		s.logger.Infow("Sent digest", "userID", d.UserID, "digestID", d.ID, "entries", len(d.Entries))
+	}
+	return errors.Join(errs...)
+}
+
+// claimLease is how long a claimed digest or held notification may take to
+// send before another scheduler run claims it again.
+const claimLease = 5 * time.Minute
+
+func (s *NotificationService) sendDigest(ctx context.Context, digest *model.Digest) error {
+	prefs, err := s.Preferences(ctx, digest.UserID)
+	if err != nil {
+		return err
+	}
+	user, err := s.userGateway.GetUserDetails(ctx, digest.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	msg, err := s.renderer.Render(templates.Digest, prefs.Language, templates.NewDigestData(user, digest.Entries))
+	if err != nil {
+		return fmt.Errorf("failed to render digest: %w", err)
+	}
+	return s.emailSender.Send(ctx, user.Email, msg)
+}
+
+// RunScheduler releases held notifications and sends due digests every
+// interval until ctx is cancelled.
+func (s *NotificationService) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.ReleaseHeld(ctx); err != nil {
+				s.logger.Warnw("Failed to release held notifications", "error", err)
+			}
+			if err := s.SendDueDigests(ctx); err != nil {
+				s.logger.Warnw("Failed to send digests", "error", err)
+			}
+		}
+	}
+}
+
+func (s *NotificationService) sendTaskAssigned(ctx context.Context, userID uuid.UUID, task *model.Task, lang string) error {
+	user, err := s.userGateway.GetUserDetails(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	msg, err := s.renderer.Render(events.TypeTaskAssigned, lang, templates.TaskAssignedData{
+		User: user,
+		Task: task,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render notification: %w", err)
+	}
+
 	return s.emailSender.Send(ctx, user.Email, msg)
 }
@@ -0,0 +1,447 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/notification"
+	"github.com/CP-Payne/taskflow/notifier/internal/repository/memory"
+	"github.com/CP-Payne/taskflow/notifier/internal/service"
+	"github.com/CP-Payne/taskflow/notifier/internal/templates"
+	"github.com/CP-Payne/taskflow/pkg/events"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type fakeUserGateway struct {
+	users map[uuid.UUID]*model.User
+}
+
+func (g *fakeUserGateway) GetUserDetails(ctx context.Context, userID uuid.UUID) (*model.User, error) {
+	if u, ok := g.users[userID]; ok {
+		return u, nil
+	}
+	return nil, errors.New("user not found")
+}
+
+func TestNotificationService_NotifyUserToCompleteTask(t *testing.T) {
+	user := &model.User{UserID: uuid.New(), Username: "jane", Email: "jane@example.com"}
+	gateway := &fakeUserGateway{users: map[uuid.UUID]*model.User{user.UserID: user}}
+
+	tests := []struct {
+		name        string
+		userID      uuid.UUID
+		task        *model.Task
+		wantSubject string
+		expectErr   bool
+	}{
+		{
+			name:        "Send rendered message to assignee",
+			userID:      user.UserID,
+			task:        &model.Task{TaskID: uuid.New(), Title: "Write docs", Priority: "low"},
+			wantSubject: "New task assigned: Write docs",
+		},
+		{
+			name:      "Fail when user cannot be fetched",
+			userID:    uuid.New(),
+			task:      &model.Task{TaskID: uuid.New(), Title: "Write docs"},
+			expectErr: true,
+		},
+	}
+
+	renderer, err := templates.New("")
+	if err != nil {
+		t.Fatalf("templates.New() failed: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := notification.NewCaptureSender()
+			srv := service.NewNotificationService(gateway, sender, renderer,
+				memory.NewPreferencesRepository(), memory.NewHeldNotificationRepository(), memory.NewDigestRepository(), zap.NewNop().Sugar())
+
+			err := srv.NotifyUserToCompleteTask(context.Background(), tt.userID, tt.task)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got %v", tt.expectErr, err)
+			}
+
+			messages := sender.Messages()
+			if tt.expectErr {
+				if len(messages) != 0 {
+					t.Errorf("expected no messages, got %d", len(messages))
+				}
+				return
+			}
+
+			if len(messages) != 1 {
+				t.Fatalf("expected 1 message, got %d", len(messages))
+			}
+			got := messages[0]
+			if got.Recipient != user.Email {
+				t.Errorf("recipient: got %q, want %q", got.Recipient, user.Email)
+			}
+			if got.Message.Subject != tt.wantSubject {
+				t.Errorf("subject: got %q, want %q", got.Message.Subject, tt.wantSubject)
+			}
+			if !strings.Contains(got.Message.Text, tt.task.Title) || !strings.Contains(got.Message.HTML, tt.task.Title) {
+				t.Errorf("message parts do not mention the task title: %+v", got.Message)
+			}
+		})
+	}
+}
+
+func TestNotificationService_Preferences(t *testing.T) {
+	user := &model.User{UserID: uuid.New(), Username: "jane", Email: "jane@example.com"}
+	gateway := &fakeUserGateway{users: map[uuid.UUID]*model.User{user.UserID: user}}
+
+	// A UTC window around the current time, so the test is always inside it.
+	minute := time.Now().UTC().Hour()*60 + time.Now().UTC().Minute()
+	quiet := &model.QuietHours{
+		Start:    (minute + 24*60 - 60) % (24 * 60),
+		End:      (minute + 60) % (24 * 60),
+		TimeZone: "UTC",
+	}
+
+	tests := []struct {
+		name         string
+		prefs        *model.Preferences
+		wantMessages int
+		wantHeld     int
+		wantDigested int
+	}{
+		{
+			name:         "Send with default preferences",
+			wantMessages: 1,
+		},
+		{
+			name:  "Skip when email channel is disabled",
+			prefs: &model.Preferences{UserID: user.UserID, Channels: []model.Channel{model.ChannelWebhook}},
+		},
+		{
+			name: "Skip when event type is not selected",
+			prefs: &model.Preferences{
+				UserID:     user.UserID,
+				EventTypes: []string{events.TypeTaskCreated},
+				Channels:   []model.Channel{model.ChannelEmail},
+			},
+		},
+		{
+			name: "Hold during quiet hours",
+			prefs: &model.Preferences{
+				UserID:     user.UserID,
+				Channels:   []model.Channel{model.ChannelEmail},
+				QuietHours: quiet,
+			},
+			wantHeld: 1,
+		},
+		{
+			name: "Collect into digest",
+			prefs: &model.Preferences{
+				UserID:     user.UserID,
+				Channels:   []model.Channel{model.ChannelEmail},
+				QuietHours: quiet,
+				Digest:     model.DigestHourly,
+			},
+			wantDigested: 1,
+		},
+	}
+
+	renderer, err := templates.New("")
+	if err != nil {
+		t.Fatalf("templates.New() failed: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			sender := notification.NewCaptureSender()
+			prefsRepo := memory.NewPreferencesRepository()
+			heldRepo := memory.NewHeldNotificationRepository()
+			if tt.prefs != nil {
+				if err := prefsRepo.Save(ctx, tt.prefs); err != nil {
+					t.Fatalf("Save() failed: %v", err)
+				}
+			}
+			digestRepo := memory.NewDigestRepository()
+			srv := service.NewNotificationService(gateway, sender, renderer, prefsRepo, heldRepo, digestRepo, zap.NewNop().Sugar())
+
+			task := &model.Task{TaskID: uuid.New(), Title: "Write docs"}
+			if err := srv.NotifyUserToCompleteTask(ctx, user.UserID, task); err != nil {
+				t.Fatalf("NotifyUserToCompleteTask() failed: %v", err)
+			}
+
+			if got := len(sender.Messages()); got != tt.wantMessages {
+				t.Errorf("expected %d messages, got %d", tt.wantMessages, got)
+			}
+
+			held, err := heldRepo.ClaimDue(ctx, time.Now().Add(24*time.Hour), time.Minute, 0)
+			if err != nil {
+				t.Fatalf("ClaimDue() failed: %v", err)
+			}
+			if len(held) != tt.wantHeld {
+				t.Errorf("expected %d held notifications, got %d", tt.wantHeld, len(held))
+			}
+
+			digests, err := digestRepo.ClaimDue(ctx, time.Now().Add(48*time.Hour), time.Minute, 0)
+			if err != nil {
+				t.Fatalf("ClaimDue() failed: %v", err)
+			}
+			var digested int
+			for _, d := range digests {
+				digested += len(d.Entries)
+			}
+			if digested != tt.wantDigested {
+				t.Errorf("expected %d digest entries, got %d", tt.wantDigested, digested)
+			}
+		})
+	}
+}
+
+func TestNotificationService_NotifyLockedOut(t *testing.T) {
+	ctx := context.Background()
+	user := &model.User{UserID: uuid.New(), Username: "jane", Email: "jane@example.com"}
+	gateway := &fakeUserGateway{users: map[uuid.UUID]*model.User{user.UserID: user}}
+	renderer, err := templates.New("")
+	if err != nil {
+		t.Fatalf("templates.New() failed: %v", err)
+	}
+
+	// Security notices ignore disabled channels and quiet hours
+	prefsRepo := memory.NewPreferencesRepository()
+	err = prefsRepo.Save(ctx, &model.Preferences{
+		UserID:     user.UserID,
+		Channels:   []model.Channel{model.ChannelWebhook},
+		Language:   "es",
+		QuietHours: &model.QuietHours{Start: 0, End: 24*60 - 1, TimeZone: "UTC"},
+	})
+	if err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	sender := notification.NewCaptureSender()
+	srv := service.NewNotificationService(gateway, sender, renderer,
+		prefsRepo, memory.NewHeldNotificationRepository(), memory.NewDigestRepository(), zap.NewNop().Sugar())
+
+	event := &events.UserLockedOutEvent{
+		UserID:      user.UserID.String(),
+		LockedUntil: time.Now().Add(15 * time.Minute),
+		IP:          "203.0.113.7",
+		Failures:    5,
+	}
+	if err := srv.NotifyLockedOut(ctx, user.UserID, event); err != nil {
+		t.Fatalf("NotifyLockedOut() failed: %v", err)
+	}
+
+	messages := sender.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	got := messages[0]
+	if got.Recipient != user.Email || !strings.Contains(got.Message.Subject, "bloqueado") {
+		t.Errorf("expected the Spanish notice to %s, got %q to %q", user.Email, got.Message.Subject, got.Recipient)
+	}
+	if !strings.Contains(got.Message.Text, event.IP) || !strings.Contains(got.Message.HTML, event.IP) {
+		t.Errorf("message parts do not mention the IP: %+v", got.Message)
+	}
+}
+
+func TestNotificationService_ReleaseHeld(t *testing.T) {
+	ctx := context.Background()
+	user := &model.User{UserID: uuid.New(), Username: "jane", Email: "jane@example.com"}
+	gateway := &fakeUserGateway{users: map[uuid.UUID]*model.User{user.UserID: user}}
+
+	renderer, err := templates.New("")
+	if err != nil {
+		t.Fatalf("templates.New() failed: %v", err)
+	}
+
+	sender := notification.NewCaptureSender()
+	heldRepo := memory.NewHeldNotificationRepository()
+	srv := service.NewNotificationService(gateway, sender, renderer,
+		memory.NewPreferencesRepository(), heldRepo, memory.NewDigestRepository(), zap.NewNop().Sugar())
+
+	for _, releaseAt := range []time.Time{time.Now().Add(-time.Minute), time.Now().Add(time.Hour)} {
+		err := heldRepo.Hold(ctx, &model.HeldNotification{
+			ID:        uuid.New(),
+			UserID:    user.UserID,
+			EventType: events.TypeTaskAssigned,
+			Task:      model.Task{TaskID: uuid.New(), Title: "Write docs"},
+			ReleaseAt: releaseAt,
+		})
+		if err != nil {
+			t.Fatalf("Hold() failed: %v", err)
+		}
+	}
+
+	if err := srv.ReleaseHeld(ctx); err != nil {
+		t.Fatalf("ReleaseHeld() failed: %v", err)
+	}
+	if got := len(sender.Messages()); got != 1 {
+		t.Fatalf("expected only the due notification to be sent, got %d", got)
+	}
+
+	// Released notifications are not sent twice.
+	if err := srv.ReleaseHeld(ctx); err != nil {
+		t.Fatalf("ReleaseHeld() failed: %v", err)
+	}
+	if got := len(sender.Messages()); got != 1 {
+		t.Errorf("expected 1 message after second release, got %d", got)
+	}
+
+	// Sending fails for a user the gateway does not know. The notification
+	// stays leased rather than dropped, and is handed out again once the
+	// lease expires.
+	unknown := &model.HeldNotification{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		EventType: events.TypeTaskAssigned,
+		Task:      model.Task{TaskID: uuid.New(), Title: "Write docs"},
+		ReleaseAt: time.Now().Add(-time.Minute),
+	}
+	if err := heldRepo.Hold(ctx, unknown); err != nil {
+		t.Fatalf("Hold() failed: %v", err)
+	}
+	if err := srv.ReleaseHeld(ctx); err == nil {
+		t.Fatal("expected an error for the unknown user")
+	}
+	if err := srv.ReleaseHeld(ctx); err != nil {
+		t.Fatalf("expected the leased notification to be skipped, got %v", err)
+	}
+	retry, err := heldRepo.ClaimDue(ctx, time.Now().Add(10*time.Minute), time.Minute, 0)
+	if err != nil {
+		t.Fatalf("ClaimDue() failed: %v", err)
+	}
+	if len(retry) != 1 || retry[0].ID != unknown.ID {
+		t.Errorf("expected the failed notification to be claimed again, got %+v", retry)
+	}
+}
+
+func TestNotificationService_SendDueDigests(t *testing.T) {
+	ctx := context.Background()
+	user := &model.User{UserID: uuid.New(), Username: "jane", Email: "jane@example.com"}
+	unknown := uuid.New()
+	gateway := &fakeUserGateway{users: map[uuid.UUID]*model.User{user.UserID: user}}
+
+	renderer, err := templates.New("")
+	if err != nil {
+		t.Fatalf("templates.New() failed: %v", err)
+	}
+
+	sender := notification.NewCaptureSender()
+	digestRepo := memory.NewDigestRepository()
+	srv := service.NewNotificationService(gateway, sender, renderer,
+		memory.NewPreferencesRepository(), memory.NewHeldNotificationRepository(), digestRepo, zap.NewNop().Sugar())
+
+	task := model.Task{TaskID: uuid.New(), Title: "Write docs"}
+	add := func(userID uuid.UUID, eventType string, dueAt time.Time) {
+		t.Helper()
+		err := digestRepo.Add(ctx, &model.DigestEntry{
+			ID:         uuid.New(),
+			UserID:     userID,
+			EventType:  eventType,
+			Task:       task,
+			OccurredAt: time.Now(),
+		}, dueAt)
+		if err != nil {
+			t.Fatalf("Add() failed: %v", err)
+		}
+	}
+	add(user.UserID, events.TypeTaskAssigned, time.Now().Add(-time.Minute))
+	add(user.UserID, events.TypeTaskAssigned, time.Now().Add(time.Hour))
+	add(user.UserID, events.TypeTaskCreated, time.Now().Add(time.Hour))
+	// Sending fails for a user the gateway does not know.
+	add(unknown, events.TypeTaskAssigned, time.Now().Add(-time.Minute))
+
+	if err := srv.SendDueDigests(ctx); err == nil {
+		t.Fatal("expected an error for the unknown user")
+	}
+
+	messages := sender.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("expected one digest for all of the user's entries, got %d", len(messages))
+	}
+	if got, want := messages[0].Message.Subject, "Your Taskflow digest: 3 updates"; got != want {
+		t.Errorf("subject: got %q, want %q", got, want)
+	}
+	if !strings.Contains(messages[0].Message.Text, "Write docs x2") {
+		t.Errorf("expected the assignments to be grouped by task, got:\n%s", messages[0].Message.Text)
+	}
+
+	// The sent digest was acknowledged; the failed one is leased until it
+	// expires and then handed out again.
+	if err := srv.SendDueDigests(ctx); err != nil {
+		t.Fatalf("SendDueDigests() failed: %v", err)
+	}
+	if got := len(sender.Messages()); got != 1 {
+		t.Errorf("expected no further digests, got %d messages", got)
+	}
+	retry, err := digestRepo.ClaimDue(ctx, time.Now().Add(time.Hour), time.Minute, 0)
+	if err != nil {
+		t.Fatalf("ClaimDue() failed: %v", err)
+	}
+	if len(retry) != 1 || retry[0].UserID != unknown {
+		t.Errorf("expected the failed digest to be claimed again, got %+v", retry)
+	}
+}
+
+// ackingDigestRepository acknowledges an earlier claim of the same batch
+// right before the service checks it, as an instance whose lease expired
+// while it was still sending would.
+type ackingDigestRepository struct {
+	*memory.DigestRepository
+	earlier string
+}
+
+func (r *ackingDigestRepository) Sent(ctx context.Context, userID uuid.UUID, period time.Time) (bool, error) {
+	if err := r.Ack(ctx, r.earlier); err != nil {
+		return false, err
+	}
+	return r.DigestRepository.Sent(ctx, userID, period)
+}
+
+func TestNotificationService_SendDueDigests_SkipsDigestSentElsewhere(t *testing.T) {
+	ctx := context.Background()
+	user := &model.User{UserID: uuid.New(), Username: "jane", Email: "jane@example.com"}
+	gateway := &fakeUserGateway{users: map[uuid.UUID]*model.User{user.UserID: user}}
+
+	renderer, err := templates.New("")
+	if err != nil {
+		t.Fatalf("templates.New() failed: %v", err)
+	}
+
+	digestRepo := memory.NewDigestRepository()
+	err = digestRepo.Add(ctx, &model.DigestEntry{
+		ID:         uuid.New(),
+		UserID:     user.UserID,
+		EventType:  events.TypeTaskAssigned,
+		Task:       model.Task{TaskID: uuid.New(), Title: "Write docs"},
+		OccurredAt: time.Now(),
+	}, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	// The earlier claim's lease has already expired.
+	earlier, err := digestRepo.ClaimDue(ctx, time.Now(), 0, 0)
+	if err != nil || len(earlier) != 1 {
+		t.Fatalf("ClaimDue() = %+v, %v", earlier, err)
+	}
+
+	sender := notification.NewCaptureSender()
+	srv := service.NewNotificationService(gateway, sender, renderer, memory.NewPreferencesRepository(),
+		memory.NewHeldNotificationRepository(), &ackingDigestRepository{DigestRepository: digestRepo, earlier: earlier[0].ID},
+		zap.NewNop().Sugar())
+
+	if err := srv.SendDueDigests(ctx); err != nil {
+		t.Fatalf("SendDueDigests() failed: %v", err)
+	}
+	if got := len(sender.Messages()); got != 0 {
+		t.Errorf("expected the digest sent elsewhere to be skipped, got %d messages", got)
+	}
+	if sent, err := digestRepo.Sent(ctx, user.UserID, earlier[0].Period); err != nil || !sent {
+		t.Errorf("Sent() = %v, %v; want true", sent, err)
+	}
+}
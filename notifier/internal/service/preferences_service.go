@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/repository"
+	"github.com/CP-Payne/taskflow/pkg/events"
+	"github.com/google/uuid"
+)
+
+type PreferencesService struct {
+	repo repository.PreferencesRepository
+}
+
+func NewPreferencesService(repo repository.PreferencesRepository) *PreferencesService {
+	return &PreferencesService{repo: repo}
+}
+
+func (s *PreferencesService) Get(ctx context.Context, userID uuid.UUID) (*model.Preferences, error) {
+	prefs, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return model.DefaultPreferences(userID), nil
+		}
+		return nil, ErrInternal
+	}
+	return prefs, nil
+}
+
+func (s *PreferencesService) Update(ctx context.Context, prefs *model.Preferences) (*model.Preferences, error) {
+	if err := validatePreferences(prefs); err != nil {
+		return nil, err
+	}
+
+	prefs.UpdatedAt = time.Now()
+	if err := s.repo.Save(ctx, prefs); err != nil {
+		return nil, ErrInternal
+	}
+	return prefs, nil
+}
+
+func validatePreferences(prefs *model.Preferences) error {
+	for _, t := range prefs.EventTypes {
+		if _, ok := events.DefaultRegistry.Schema(t); !ok {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidArgument, t)
+		}
+	}
+	for _, c := range prefs.Channels {
+		switch c {
+		case model.ChannelEmail, model.ChannelWebhook, model.ChannelInApp:
+		default:
+			return fmt.Errorf("%w: unknown channel %q", ErrInvalidArgument, c)
+		}
+	}
+	if err := prefs.Digest.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+	if prefs.QuietHours != nil {
+		if err := prefs.QuietHours.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/repository"
+	"github.com/CP-Payne/taskflow/notifier/internal/webhook"
+	"github.com/CP-Payne/taskflow/pkg/events"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNotFound        = errors.New("resource not found")
+	ErrInvalidArgument = errors.New("invalid argument")
+	ErrInternal        = errors.New("internal server error")
+)
+
+type WebhookService struct {
+	repo       repository.WebhookRepository
+	dispatcher *webhook.Dispatcher
+	logger     *zap.SugaredLogger
+}
+
+func NewWebhookService(repo repository.WebhookRepository, dispatcher *webhook.Dispatcher, logger *zap.SugaredLogger) *WebhookService {
+	return &WebhookService{
+		repo:       repo,
+		dispatcher: dispatcher,
+		logger:     logger,
+	}
+}
+
+func (s *WebhookService) RegisterEndpoint(ctx context.Context, owner model.Owner, rawURL string, eventTypes []string) (*model.WebhookEndpoint, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return nil, fmt.Errorf("%w: endpoint url must be an absolute https url without credentials", ErrInvalidArgument)
+	}
+	if err := webhook.CheckHost(ctx, u.Hostname()); err != nil {
+		if errors.Is(err, webhook.ErrForbiddenAddress) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+		}
+		return nil, fmt.Errorf("%w: endpoint host cannot be resolved", ErrInvalidArgument)
+	}
+	for _, t := range eventTypes {
+		if _, ok := events.DefaultRegistry.Schema(t); !ok {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidArgument, t)
+		}
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	endpoint := &model.WebhookEndpoint{
+		ID:         uuid.New(),
+		Owner:      owner,
+		URL:        u.String(),
+		EventTypes: eventTypes,
+		Secret:     secret,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.repo.CreateEndpoint(ctx, endpoint); err != nil {
+		return nil, ErrInternal
+	}
+	return endpoint, nil
+}
+
+func (s *WebhookService) ListEndpoints(ctx context.Context, owner model.Owner) ([]model.WebhookEndpoint, error) {
+	endpoints, err := s.repo.ListEndpoints(ctx, owner)
+	if err != nil {
+		return nil, ErrInternal
+	}
+	return endpoints, nil
+}
+
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, owner model.Owner, endpointID uuid.UUID) error {
+	if _, err := s.ownedEndpoint(ctx, owner, endpointID); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteEndpoint(ctx, endpointID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNotFound
+		}
+		return ErrInternal
+	}
+	return nil
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, owner model.Owner, endpointID uuid.UUID, limit int) ([]model.WebhookDelivery, error) {
+	if _, err := s.ownedEndpoint(ctx, owner, endpointID); err != nil {
+		return nil, err
+	}
+	deliveries, err := s.repo.ListDeliveries(ctx, endpointID, limit)
+	if err != nil {
+		return nil, ErrInternal
+	}
+	return deliveries, nil
+}
+
+// Dispatch queues the event for every endpoint of the owners that subscribed to it.
+func (s *WebhookService) Dispatch(ctx context.Context, envelope *events.Envelope, owners ...model.Owner) error {
+	endpoints, err := s.repo.MatchEndpoints(ctx, owners, envelope.Type)
+	if err != nil {
+		return fmt.Errorf("match webhook endpoints: %w", err)
+	}
+
+	var errs []error
+	for _, endpoint := range endpoints {
+		if err := s.dispatcher.Enqueue(ctx, endpoint, envelope); err != nil {
+			s.logger.Warnw("Failed to queue webhook delivery", "endpointID", endpoint.ID, "eventID", envelope.ID, "error", err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ownedEndpoint hides endpoints of other owners behind ErrNotFound.
+func (s *WebhookService) ownedEndpoint(ctx context.Context, owner model.Owner, endpointID uuid.UUID) (*model.WebhookEndpoint, error) {
+	endpoint, err := s.repo.GetEndpoint(ctx, endpointID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, ErrInternal
+	}
+	if endpoint.Owner != owner {
+		return nil, ErrNotFound
+	}
+	return endpoint, nil
+}
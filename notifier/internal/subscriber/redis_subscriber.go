@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
 	"github.com/CP-Payne/taskflow/notifier/internal/service"
 	"github.com/CP-Payne/taskflow/pkg/events"
 	"github.com/google/uuid"
@@ -13,14 +14,32 @@ import (
 	"go.uber.org/zap"
 )
 
+// channels lists the event channels the notifier consumes.
+var channels = []string{events.ChannelTaskAssigned, events.ChannelTaskCreated, events.ChannelUserUpdated, events.ChannelUserSecurity}
+
+// UserCache holds user details that must be dropped when a user changes.
+type UserCache interface {
+	Invalidate(userID uuid.UUID)
+}
+
 type RedisSubscriber struct {
 	rdb             *redis.Client
 	logger          *zap.SugaredLogger
 	notificationSrv *service.NotificationService
+	webhookSrv      *service.WebhookService
+	inboxSrv        *service.InboxService
+	users           UserCache
 }
 
-func NewRedisSubscriber(rdb *redis.Client, srv *service.NotificationService, logger *zap.SugaredLogger) *RedisSubscriber {
-	return &RedisSubscriber{rdb: rdb, notificationSrv: srv, logger: logger}
+func NewRedisSubscriber(
+	rdb *redis.Client,
+	srv *service.NotificationService,
+	webhookSrv *service.WebhookService,
+	inboxSrv *service.InboxService,
+	users UserCache,
+	logger *zap.SugaredLogger,
+) *RedisSubscriber {
+	return &RedisSubscriber{rdb: rdb, notificationSrv: srv, webhookSrv: webhookSrv, inboxSrv: inboxSrv, users: users, logger: logger}
 }
 
 // func (s *RedisSubscriber) SubscribeAndProcess(ctx context.Context) error {
@@ -69,7 +88,7 @@ func NewRedisSubscriber(rdb *redis.Client, srv *service.NotificationService, log
 //
 
 func (s *RedisSubscriber) SubscribeAndProcess(ctx context.Context) error {
-	pubsub := s.rdb.Subscribe(ctx, events.ChannelTaskAssigned)
+	pubsub := s.rdb.Subscribe(ctx, channels...)
 
 	defer func() {
 		if err := pubsub.Close(); err != nil {
@@ -84,15 +103,15 @@ func (s *RedisSubscriber) SubscribeAndProcess(ctx context.Context) error {
 	receiveCancel()
 	if err != nil {
 		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
-			s.logger.Infow("Subscription cancelled during initial receive", "channel", events.ChannelTaskAssigned)
+			s.logger.Infow("Subscription cancelled during initial receive", "channels", channels)
 			return err
 		}
-		s.logger.Errorw("Failed to subscribe or receive confirmation", "channel", events.ChannelTaskAssigned, "error", err)
-		return fmt.Errorf("failed to subscribe to Redis channel %s: %w", events.ChannelTaskAssigned, err)
+		s.logger.Errorw("Failed to subscribe or receive confirmation", "channels", channels, "error", err)
+		return fmt.Errorf("failed to subscribe to Redis channels %v: %w", channels, err)
 	}
 
 	ch := pubsub.Channel()
-	s.logger.Infof("Subscribed to %s. Waiting for messages or cancellation...", events.ChannelTaskAssigned)
+	s.logger.Infof("Subscribed to %v. Waiting for messages or cancellation...", channels)
 
 	for {
 		select {
@@ -110,35 +129,154 @@ func (s *RedisSubscriber) SubscribeAndProcess(ctx context.Context) error {
 
 			s.logger.Infof("Received message on %s", msg.Channel)
 
-			event, err := events.UnmarshalTaskAssignedEvent([]byte(msg.Payload))
+			env, payload, err := events.DefaultRegistry.Decode([]byte(msg.Payload))
 			if err != nil {
-				s.logger.Errorw("Failed to unmarshal TaskAssignedEvent", "error", err, "payload", msg.Payload)
+				s.logger.Errorw("Failed to decode event", "channel", msg.Channel, "error", err, "payload", msg.Payload)
 				continue // Skip this message, continue loop
 			}
 
-			userID, err := uuid.Parse(event.UserID)
-			if err != nil {
-				s.logger.Warnw("Failed to parse userID, skipping notification", "userID", event.UserID, "error", err)
-				continue // Skip if ID is invalid
+			switch event := payload.(type) {
+			case *events.TaskAssignedEvent:
+				s.handleTaskAssigned(ctx, env, event)
+			case *events.TaskCreatedEvent:
+				s.handleTaskCreated(ctx, env, event)
+			case *events.UserUpdatedEvent:
+				s.handleUserUpdated(event)
+			case *events.UserLockedOutEvent:
+				s.handleUserLockedOut(ctx, event)
+			default:
+				s.logger.Warnw("Ignoring unhandled event type", "type", env.Type, "channel", msg.Channel)
 			}
+		}
+	}
+}
 
-			taskID, err := uuid.Parse(event.TaskID)
-			if err != nil {
-				s.logger.Warnw("Failed to parse taskID, skipping notification", "taskID", event.TaskID, "error", err)
-				continue // Skip if ID is invalid
-			}
-			err = s.notificationSrv.NotifyUserToCompleteTask(ctx, userID, taskID)
-			if err != nil {
-				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
-					s.logger.Warnw("Notification sending cancelled", "TaskID", event.TaskID, "RecipientID", event.UserID, "error", err)
-				} else {
-					s.logger.Errorw("Failed to send notification", "TaskID", event.TaskID, "RecipientID", event.UserID, "error", err)
-				}
-			} else {
-				s.logger.Infof("Successfully processed notification for TaskID %s to UserID %s", event.TaskID, event.UserID)
-			}
+func (s *RedisSubscriber) handleTaskAssigned(ctx context.Context, env *events.Envelope, event *events.TaskAssignedEvent) {
+	userID, err := uuid.Parse(event.UserID)
+	if err != nil {
+		s.logger.Warnw("Failed to parse userID, skipping notification", "userID", event.UserID, "error", err)
+		return // Skip if ID is invalid
+	}
+
+	s.dispatchTaskWebhooks(ctx, env, userID, event.Task)
+
+	task, err := taskFromEvent(event)
+	if err != nil {
+		s.logger.Warnw("Failed to parse task, skipping notification", "taskID", event.TaskID, "error", err)
+		return // Skip if ID is invalid
+	}
+	s.deliverInApp(ctx, env, userID, task)
+
+	err = s.notificationSrv.NotifyUserToCompleteTask(ctx, userID, task)
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			s.logger.Warnw("Notification sending cancelled", "TaskID", event.TaskID, "RecipientID", event.UserID, "error", err)
+		} else {
+			s.logger.Errorw("Failed to send notification", "TaskID", event.TaskID, "RecipientID", event.UserID, "error", err)
 		}
+	} else {
+		s.logger.Infof("Successfully processed notification for TaskID %s to UserID %s", event.TaskID, event.UserID)
+	}
+}
+
+func (s *RedisSubscriber) handleTaskCreated(ctx context.Context, env *events.Envelope, event *events.TaskCreatedEvent) {
+	creatorID, err := uuid.Parse(event.Task.CreatedBy)
+	if err != nil {
+		s.logger.Warnw("Failed to parse creatorID, skipping webhooks", "createdBy", event.Task.CreatedBy, "error", err)
+		return
+	}
+	s.dispatchTaskWebhooks(ctx, env, creatorID, &event.Task)
+}
+
+func (s *RedisSubscriber) handleUserUpdated(event *events.UserUpdatedEvent) {
+	userID, err := uuid.Parse(event.UserID)
+	if err != nil {
+		s.logger.Warnw("Failed to parse userID, skipping cache invalidation", "userID", event.UserID, "error", err)
+		return
+	}
+	s.users.Invalidate(userID)
+	s.logger.Infow("Invalidated cached user details", "userID", userID)
+}
+
+func (s *RedisSubscriber) handleUserLockedOut(ctx context.Context, event *events.UserLockedOutEvent) {
+	userID, err := uuid.Parse(event.UserID)
+	if err != nil {
+		s.logger.Warnw("Failed to parse userID, skipping lockout notification", "userID", event.UserID, "error", err)
+		return
+	}
+
+	if err := s.notificationSrv.NotifyLockedOut(ctx, userID, event); err != nil {
+		s.logger.Errorw("Failed to send lockout notification", "userID", event.UserID, "error", err)
+		return
+	}
+	s.logger.Infow("Sent lockout notification", "userID", event.UserID, "lockedUntil", event.LockedUntil)
+}
+
+// dispatchTaskWebhooks dispatches to the user's endpoints unless their
+// preferences opt out of the event or the webhook channel, and to the
+// endpoints of the task's workspace. task is nil for events without a
+// snapshot.
+func (s *RedisSubscriber) dispatchTaskWebhooks(ctx context.Context, env *events.Envelope, userID uuid.UUID, task *events.TaskSnapshot) {
+	var owners []model.Owner
+	prefs, err := s.notificationSrv.Preferences(ctx, userID)
+	switch {
+	case err != nil:
+		s.logger.Errorw("Failed to load preferences, skipping user webhooks", "userID", userID, "error", err)
+	case !prefs.Allows(env.Type, model.ChannelWebhook):
+		s.logger.Infow("Webhooks disabled by user preferences", "userID", userID, "type", env.Type)
+	default:
+		owners = append(owners, model.Owner{Type: model.OwnerUser, ID: userID.String()})
+	}
+
+	if task != nil && task.WorkspaceID != "" {
+		owners = append(owners, model.Owner{Type: model.OwnerWorkspace, ID: task.WorkspaceID})
+	}
+	if len(owners) > 0 {
+		s.dispatchWebhooks(ctx, env, owners...)
+	}
+}
+
+// deliverInApp adds the assignment to the user's inbox unless their
+// preferences opt out of in-app notifications.
+func (s *RedisSubscriber) deliverInApp(ctx context.Context, env *events.Envelope, userID uuid.UUID, task *model.Task) {
+	prefs, err := s.notificationSrv.Preferences(ctx, userID)
+	if err != nil {
+		s.logger.Errorw("Failed to load preferences, skipping inbox", "userID", userID, "error", err)
+		return
+	}
+	if !prefs.Allows(env.Type, model.ChannelInApp) {
+		return
+	}
+	if err := s.inboxSrv.NotifyTaskAssigned(ctx, env, prefs, task); err != nil {
+		s.logger.Errorw("Failed to add notification to inbox", "userID", userID, "taskID", task.TaskID, "error", err)
+	}
+}
+
+func (s *RedisSubscriber) dispatchWebhooks(ctx context.Context, env *events.Envelope, owners ...model.Owner) {
+	if err := s.webhookSrv.Dispatch(ctx, env, owners...); err != nil {
+		s.logger.Errorw("Failed to dispatch webhooks", "eventID", env.ID, "type", env.Type, "error", err)
+	}
+}
+
+func taskFromEvent(event *events.TaskAssignedEvent) (*model.Task, error) {
+	taskID, err := uuid.Parse(event.TaskID)
+	if err != nil {
+		return nil, err
+	}
+
+	task := &model.Task{TaskID: taskID}
+	if event.Task == nil {
+		return task, nil
+	}
+
+	task.Title = event.Task.Title
+	task.Description = event.Task.Description
+	task.Priority = event.Task.Priority
+	task.DueDate = event.Task.DueDate
+	if createdBy, err := uuid.Parse(event.Task.CreatedBy); err == nil {
+		task.CreatedBy = createdBy
 	}
+	return task, nil
 }
 
 func (s *RedisSubscriber) Close() error {
@@ -0,0 +1,129 @@
+package templates
+
+import (
+	"sort"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/pkg/events"
+	"github.com/google/uuid"
+)
+
+// TaskAssignedData is the data passed to task.assigned templates.
+type TaskAssignedData struct {
+	User *model.User
+	Task *model.Task
+}
+
+// LockedOutData is the data passed to user.locked_out templates.
+type LockedOutData struct {
+	User        *model.User
+	LockedUntil time.Time
+	// IP is the client address of the last failed login, if known.
+	IP string
+}
+
+// Digest is the name of the digest templates, which summarise several events.
+const Digest = "digest"
+
+// DigestData is the data passed to digest templates.
+type DigestData struct {
+	User  *model.User
+	Count int
+	// Since and Until bound the time the summarised events occurred.
+	Since  time.Time
+	Until  time.Time
+	Groups []DigestGroup
+}
+
+// DigestGroup holds the tasks affected by one event type.
+type DigestGroup struct {
+	EventType string
+	Tasks     []DigestTask
+}
+
+// DigestTask is a task and how many of the group's events concern it.
+type DigestTask struct {
+	Task  model.Task
+	Count int
+}
+
+// NewDigestData groups entries by event type and task, keeping the order in
+// which they first occurred. The latest snapshot of each task is kept.
+func NewDigestData(user *model.User, entries []model.DigestEntry) DigestData {
+	sorted := make([]model.DigestEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].OccurredAt.Before(sorted[j].OccurredAt)
+	})
+
+	data := DigestData{User: user, Count: len(sorted)}
+	groups := make(map[string]int)
+	tasks := make(map[string]map[uuid.UUID]int)
+	for _, e := range sorted {
+		if data.Since.IsZero() {
+			data.Since = e.OccurredAt
+		}
+		data.Until = e.OccurredAt
+
+		g, ok := groups[e.EventType]
+		if !ok {
+			g = len(data.Groups)
+			groups[e.EventType] = g
+			tasks[e.EventType] = make(map[uuid.UUID]int)
+			data.Groups = append(data.Groups, DigestGroup{EventType: e.EventType})
+		}
+		group := &data.Groups[g]
+		if t, ok := tasks[e.EventType][e.Task.TaskID]; ok {
+			group.Tasks[t].Task = e.Task
+			group.Tasks[t].Count++
+			continue
+		}
+		tasks[e.EventType][e.Task.TaskID] = len(group.Tasks)
+		group.Tasks = append(group.Tasks, DigestTask{Task: e.Task, Count: 1})
+	}
+	return data
+}
+
+// Samples returns example data per event type, used by the preview command.
+func Samples() map[string]any {
+	dueDate := time.Now().Add(72 * time.Hour).Truncate(time.Hour)
+	user := &model.User{
+		UserID:   uuid.New(),
+		Username: "jane",
+		Email:    "jane@example.com",
+	}
+	report := model.Task{
+		TaskID:      uuid.New(),
+		Title:       "Review quarterly report",
+		Description: "Check the figures in section 3 and leave comments <before> Friday.",
+		CreatedBy:   uuid.New(),
+		Priority:    "high",
+		DueDate:     &dueDate,
+	}
+	docs := model.Task{
+		TaskID:    uuid.New(),
+		Title:     "Update onboarding docs",
+		CreatedBy: user.UserID,
+		Priority:  "low",
+	}
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Hour)
+	return map[string]any{
+		events.TypeTaskAssigned: TaskAssignedData{
+			User: user,
+			Task: &report,
+		},
+		events.TypeUserLockedOut: LockedOutData{
+			User:        user,
+			LockedUntil: time.Now().Add(15 * time.Minute).Truncate(time.Minute),
+			IP:          "203.0.113.7",
+		},
+		Digest: NewDigestData(user, []model.DigestEntry{
+			{EventType: events.TypeTaskAssigned, Task: report, OccurredAt: start.Add(5 * time.Minute)},
+			{EventType: events.TypeTaskCreated, Task: docs, OccurredAt: start.Add(20 * time.Minute)},
+			{EventType: events.TypeTaskAssigned, Task: docs, OccurredAt: start.Add(21 * time.Minute)},
+			{EventType: events.TypeTaskAssigned, Task: report, OccurredAt: start.Add(40 * time.Minute)},
+		}),
+	}
+}
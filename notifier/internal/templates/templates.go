@@ -0,0 +1,163 @@
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/notification"
+)
+
+// DefaultLanguage is used when no template exists for the requested language.
+const DefaultLanguage = "en"
+
+var ErrTemplateNotFound = errors.New("notification template not found")
+
+// Each event type has a directory per language holding subject.tmpl,
+// text.tmpl and html.tmpl.
+//
+//go:embed files
+var embedded embed.FS
+
+var funcs = map[string]any{
+	"date": func(t time.Time) string {
+		return t.Format("Mon, 02 Jan 2006 15:04 MST")
+	},
+}
+
+type Renderer struct {
+	fsys fs.FS
+
+	mu    sync.Mutex
+	cache map[string]*templateSet
+}
+
+type templateSet struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// New returns a renderer over the embedded templates. Files found under
+// overrideDir, using the same layout, take precedence over embedded ones.
+func New(overrideDir string) (*Renderer, error) {
+	base, err := fs.Sub(embedded, "files")
+	if err != nil {
+		return nil, err
+	}
+
+	fsys := base
+	if overrideDir != "" {
+		info, err := os.Stat(overrideDir)
+		if err != nil {
+			return nil, fmt.Errorf("template override dir: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("template override dir %s is not a directory", overrideDir)
+		}
+		fsys = overlayFS{upper: os.DirFS(overrideDir), lower: base}
+	}
+
+	return &Renderer{
+		fsys:  fsys,
+		cache: make(map[string]*templateSet),
+	}, nil
+}
+
+// Render renders the template for eventType in the closest available
+// language: the exact tag, then its base language, then DefaultLanguage.
+func (r *Renderer) Render(eventType, lang string, data any) (*notification.Message, error) {
+	set, err := r.lookup(eventType, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := set.subject.Execute(&subject, data); err != nil {
+		return nil, fmt.Errorf("render %s subject: %w", eventType, err)
+	}
+	if err := set.text.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("render %s text: %w", eventType, err)
+	}
+	if err := set.html.Execute(&html, data); err != nil {
+		return nil, fmt.Errorf("render %s html: %w", eventType, err)
+	}
+
+	return &notification.Message{
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+func (r *Renderer) lookup(eventType, lang string) (*templateSet, error) {
+	for _, candidate := range languageCandidates(lang) {
+		dir := path.Join(eventType, candidate)
+		if _, err := fs.Stat(r.fsys, path.Join(dir, "subject.tmpl")); err != nil {
+			continue
+		}
+		return r.load(dir)
+	}
+	return nil, fmt.Errorf("%w: %s (%s)", ErrTemplateNotFound, eventType, lang)
+}
+
+func (r *Renderer) load(dir string) (*templateSet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if set, ok := r.cache[dir]; ok {
+		return set, nil
+	}
+
+	subject, err := texttemplate.New("subject.tmpl").Funcs(funcs).ParseFS(r.fsys, path.Join(dir, "subject.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	text, err := texttemplate.New("text.tmpl").Funcs(funcs).ParseFS(r.fsys, path.Join(dir, "text.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	html, err := htmltemplate.New("html.tmpl").Funcs(funcs).ParseFS(r.fsys, path.Join(dir, "html.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+
+	set := &templateSet{subject: subject, text: text, html: html}
+	r.cache[dir] = set
+	return set, nil
+}
+
+func languageCandidates(lang string) []string {
+	lang = strings.ToLower(strings.ReplaceAll(lang, "_", "-"))
+	var candidates []string
+	if lang != "" {
+		candidates = append(candidates, lang)
+		if base, _, ok := strings.Cut(lang, "-"); ok {
+			candidates = append(candidates, base)
+		}
+	}
+	return append(candidates, DefaultLanguage)
+}
+
+// overlayFS serves files from upper when present and from lower otherwise.
+type overlayFS struct {
+	upper fs.FS
+	lower fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.upper.Open(name)
+	if err == nil {
+		return f, nil
+	}
+	return o.lower.Open(name)
+}
@@ -0,0 +1,175 @@
+package templates_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/templates"
+	"github.com/CP-Payne/taskflow/pkg/events"
+	"github.com/google/uuid"
+)
+
+func TestRenderer_Render(t *testing.T) {
+	sample := templates.Samples()[events.TypeTaskAssigned]
+	legacy := templates.TaskAssignedData{
+		User: &model.User{Username: "jane"},
+		Task: &model.Task{TaskID: uuid.New()},
+	}
+
+	tests := []struct {
+		name        string
+		eventType   string
+		lang        string
+		data        any
+		wantSubject string
+		wantText    string
+		wantHTML    string
+		expectErr   error
+	}{
+		{
+			name:        "Render English template",
+			eventType:   events.TypeTaskAssigned,
+			lang:        "en",
+			data:        sample,
+			wantSubject: "New task assigned: Review quarterly report",
+			wantText:    "Priority: high",
+			wantHTML:    "&lt;before&gt;",
+		},
+		{
+			name:        "Fall back to base language",
+			eventType:   events.TypeTaskAssigned,
+			lang:        "es_MX",
+			data:        sample,
+			wantSubject: "Nueva tarea asignada: Review quarterly report",
+			wantText:    "Prioridad: high",
+		},
+		{
+			name:        "Fall back to default language",
+			eventType:   events.TypeTaskAssigned,
+			lang:        "fr",
+			data:        sample,
+			wantSubject: "New task assigned: Review quarterly report",
+		},
+		{
+			name:        "Render event without task snapshot",
+			eventType:   events.TypeTaskAssigned,
+			lang:        "en",
+			data:        legacy,
+			wantSubject: "New Task Assigned",
+			wantText:    legacy.Task.TaskID.String(),
+		},
+		{
+			name:      "Fail on unknown event type",
+			eventType: "task.archived",
+			lang:      "en",
+			data:      sample,
+			expectErr: templates.ErrTemplateNotFound,
+		},
+	}
+
+	renderer, err := templates.New("")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := renderer.Render(tt.eventType, tt.lang, tt.data)
+			if !errors.Is(err, tt.expectErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if msg.Subject != tt.wantSubject {
+				t.Errorf("subject: got %q, want %q", msg.Subject, tt.wantSubject)
+			}
+			if !strings.Contains(msg.Text, tt.wantText) {
+				t.Errorf("text part does not contain %q:\n%s", tt.wantText, msg.Text)
+			}
+			if !strings.Contains(msg.HTML, tt.wantHTML) {
+				t.Errorf("html part does not contain %q:\n%s", tt.wantHTML, msg.HTML)
+			}
+		})
+	}
+}
+
+func TestRenderer_Override(t *testing.T) {
+	dir := t.TempDir()
+	langDir := filepath.Join(dir, events.TypeTaskAssigned, "en")
+	if err := os.MkdirAll(langDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(langDir, "subject.tmpl"), []byte("[Acme] {{.Task.Title}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	renderer, err := templates.New(dir)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	msg, err := renderer.Render(events.TypeTaskAssigned, "en", templates.Samples()[events.TypeTaskAssigned])
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+
+	if msg.Subject != "[Acme] Review quarterly report" {
+		t.Errorf("override not applied, got subject %q", msg.Subject)
+	}
+	// Parts without an override keep using the embedded template.
+	if !strings.Contains(msg.Text, "You have been assigned a new task") {
+		t.Errorf("embedded text part not used:\n%s", msg.Text)
+	}
+}
+
+func TestNewDigestData(t *testing.T) {
+	start := time.Date(2025, time.March, 10, 9, 0, 0, 0, time.UTC)
+	a := model.Task{TaskID: uuid.New(), Title: "A"}
+	b := model.Task{TaskID: uuid.New(), Title: "B"}
+	renamed := a
+	renamed.Title = "A (renamed)"
+
+	entries := []model.DigestEntry{
+		{EventType: events.TypeTaskCreated, Task: b, OccurredAt: start.Add(10 * time.Minute)},
+		{EventType: events.TypeTaskAssigned, Task: a, OccurredAt: start},
+		{EventType: events.TypeTaskAssigned, Task: b, OccurredAt: start.Add(20 * time.Minute)},
+		{EventType: events.TypeTaskAssigned, Task: renamed, OccurredAt: start.Add(30 * time.Minute)},
+	}
+
+	data := templates.NewDigestData(&model.User{Username: "jane"}, entries)
+	if data.Count != 4 {
+		t.Errorf("count: got %d, want 4", data.Count)
+	}
+	if !data.Since.Equal(start) || !data.Until.Equal(start.Add(30*time.Minute)) {
+		t.Errorf("period: got %v - %v", data.Since, data.Until)
+	}
+
+	type task struct {
+		title string
+		count int
+	}
+	want := map[string][]task{
+		events.TypeTaskAssigned: {{"A (renamed)", 2}, {"B", 1}},
+		events.TypeTaskCreated:  {{"B", 1}},
+	}
+	if len(data.Groups) != 2 || data.Groups[0].EventType != events.TypeTaskAssigned {
+		t.Fatalf("expected groups in order of first occurrence, got %+v", data.Groups)
+	}
+	for _, g := range data.Groups {
+		var got []task
+		for _, dt := range g.Tasks {
+			got = append(got, task{dt.Task.Title, dt.Count})
+		}
+		if !reflect.DeepEqual(got, want[g.EventType]) {
+			t.Errorf("%s: got %v, want %v", g.EventType, got, want[g.EventType])
+		}
+	}
+}
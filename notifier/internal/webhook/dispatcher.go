@@ -0,0 +1,326 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/repository"
+	"github.com/CP-Payne/taskflow/pkg/events"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 6,
+	BaseDelay:   time.Second,
+	MaxDelay:    5 * time.Minute,
+}
+
+// Backoff returns the delay before the given retry (1-based) using
+// exponential backoff with full jitter.
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	delay := p.BaseDelay << (retry - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+const (
+	// claimLease is how long a claimed delivery may take before another
+	// claim hands it out again. It covers the wait in the queue and the
+	// attempt itself.
+	claimLease = 2 * time.Minute
+	// pollInterval is how often due deliveries are claimed, e.g. those
+	// scheduled by other instances or whose lease expired.
+	pollInterval = time.Second
+)
+
+// Dispatcher delivers events to webhook endpoints. Deliveries are kept in
+// the repository by due time and claimed with a lease, so pending attempts
+// and retries survive restarts, and a delivery whose instance stopped is
+// claimed again once its lease expires. A failed attempt is scheduled again
+// rather than waited for in a worker, so one dead endpoint does not hold up
+// the deliveries to the others, and slow receivers never block the event
+// subscriber. A delivery may therefore be attempted more than once;
+// receivers deduplicate by the delivery header.
+type Dispatcher struct {
+	repo    repository.WebhookRepository
+	client  *http.Client
+	policy  RetryPolicy
+	workers int
+	// queue holds the claimed deliveries waiting for a worker.
+	queue  chan model.PendingDelivery
+	wake   chan struct{}
+	logger *zap.SugaredLogger
+}
+
+type Option func(*Dispatcher)
+
+func WithHTTPClient(client *http.Client) Option {
+	return func(d *Dispatcher) {
+		d.client = client
+	}
+}
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(d *Dispatcher) {
+		d.policy = policy
+	}
+}
+
+// WithWorkers sets the number of concurrent attempts and how many claimed
+// deliveries may wait for them.
+func WithWorkers(workers, queueSize int) Option {
+	return func(d *Dispatcher) {
+		d.workers = workers
+		d.queue = make(chan model.PendingDelivery, max(queueSize, 1))
+	}
+}
+
+func NewDispatcher(repo repository.WebhookRepository, logger *zap.SugaredLogger, opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		repo:    repo,
+		client:  NewHTTPClient(10 * time.Second),
+		policy:  DefaultRetryPolicy,
+		workers: 4,
+		queue:   make(chan model.PendingDelivery, 16),
+		wake:    make(chan struct{}, 1),
+		logger:  logger,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Enqueue stores a delivery of the event to the endpoint, due at once.
+func (d *Dispatcher) Enqueue(ctx context.Context, endpoint model.WebhookEndpoint, envelope *events.Envelope) error {
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("encode webhook payload: %w", err)
+	}
+
+	delivery := &model.PendingDelivery{
+		ID:         uuid.New(),
+		EndpointID: endpoint.ID,
+		EventID:    envelope.ID,
+		EventType:  envelope.Type,
+		Body:       body,
+		Attempt:    1,
+		DueAt:      time.Now(),
+	}
+	if err := d.repo.Schedule(ctx, delivery); err != nil {
+		return fmt.Errorf("schedule webhook delivery: %w", err)
+	}
+	d.notify()
+	return nil
+}
+
+// Run claims and delivers due deliveries until ctx is cancelled. Deliveries
+// claimed but not yet attempted on shutdown are claimed again once their
+// lease expires.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i := 0; i < d.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case p := <-d.queue:
+					d.deliver(ctx, p)
+					// There is room in the queue again
+					d.notify()
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.claimDue(ctx)
+	}()
+	wg.Wait()
+	return ctx.Err()
+}
+
+// claimDue claims as many due deliveries as the queue has room for, every
+// pollInterval and whenever one is scheduled to be due sooner. Only this
+// goroutine fills the queue, so claimed deliveries never wait for room.
+func (d *Dispatcher) claimDue(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		if free := cap(d.queue) - len(d.queue); free > 0 {
+			due, err := d.repo.ClaimDue(ctx, time.Now(), claimLease, free)
+			if err != nil && ctx.Err() == nil {
+				d.logger.Warnw("Failed to claim webhook deliveries", "error", err)
+			}
+			for _, p := range due {
+				d.queue <- p
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// notify makes claimDue look for due deliveries now.
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// notifyAt makes claimDue look for due deliveries at t, when that is before
+// its next poll.
+func (d *Dispatcher) notifyAt(t time.Time) {
+	if wait := time.Until(t); wait < pollInterval {
+		time.AfterFunc(wait, d.notify)
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, p model.PendingDelivery) {
+	endpoint, err := d.repo.GetEndpoint(ctx, p.EndpointID)
+	if errors.Is(err, repository.ErrNotFound) {
+		d.logger.Infow("Webhook endpoint deleted, dropping delivery", "endpointID", p.EndpointID, "eventID", p.EventID)
+		d.ack(ctx, p)
+		return
+	} else if err != nil {
+		// The delivery is claimed again once its lease expires
+		d.logger.Warnw("Failed to load webhook endpoint", "endpointID", p.EndpointID, "error", err)
+		return
+	}
+
+	record := d.attempt(ctx, endpoint, p)
+	if !record.Succeeded && ctx.Err() != nil {
+		// Shutting down; the attempt is made again after the lease
+		return
+	}
+	// The outcome is stored even if shutdown began during the attempt
+	ctx = context.WithoutCancel(ctx)
+	if err := d.repo.RecordDelivery(ctx, record); err != nil {
+		d.logger.Warnw("Failed to record webhook delivery", "deliveryID", p.ID, "error", err)
+	}
+
+	if record.Succeeded {
+		d.logger.Infow("Webhook delivered", "endpointID", p.EndpointID, "eventID", p.EventID, "attempt", p.Attempt)
+		d.ack(ctx, p)
+		return
+	}
+	if !retryable(record.StatusCode) {
+		d.logger.Warnw("Webhook rejected, not retrying",
+			"endpointID", p.EndpointID,
+			"eventID", p.EventID,
+			"status", record.StatusCode,
+		)
+		d.ack(ctx, p)
+		return
+	}
+	if p.Attempt >= d.policy.MaxAttempts {
+		d.logger.Errorw("Webhook delivery gave up", "endpointID", p.EndpointID, "eventID", p.EventID, "attempts", p.Attempt)
+		d.ack(ctx, p)
+		return
+	}
+
+	d.logger.Warnw("Webhook delivery failed",
+		"endpointID", p.EndpointID,
+		"eventID", p.EventID,
+		"attempt", p.Attempt,
+		"status", record.StatusCode,
+		"error", record.Error,
+	)
+	retry := p
+	retry.Attempt++
+	retry.DueAt = time.Now().Add(d.policy.Backoff(p.Attempt))
+	if err := d.repo.Schedule(ctx, &retry); err != nil {
+		d.logger.Warnw("Failed to schedule webhook retry, retrying after the lease", "deliveryID", p.ID, "error", err)
+		return
+	}
+	d.notifyAt(retry.DueAt)
+}
+
+func (d *Dispatcher) ack(ctx context.Context, p model.PendingDelivery) {
+	if err := d.repo.Ack(ctx, p.ID); err != nil {
+		d.logger.Warnw("Failed to acknowledge webhook delivery", "deliveryID", p.ID, "error", err)
+	}
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, endpoint *model.WebhookEndpoint, p model.PendingDelivery) *model.WebhookDelivery {
+	record := &model.WebhookDelivery{
+		ID:          p.ID,
+		EndpointID:  p.EndpointID,
+		EventID:     p.EventID,
+		EventType:   p.EventType,
+		Attempt:     p.Attempt,
+		AttemptedAt: time.Now(),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(p.Body))
+	if err != nil {
+		record.Error = err.Error()
+		return record
+	}
+
+	timestamp := record.AttemptedAt.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "taskflow-webhooks/1")
+	req.Header.Set(HeaderEvent, p.EventType)
+	req.Header.Set(HeaderDelivery, p.ID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(endpoint.Secret, timestamp, p.Body))
+
+	resp, err := d.client.Do(req)
+	record.Duration = time.Since(record.AttemptedAt)
+	if err != nil {
+		record.Error = err.Error()
+		return record
+	}
+	resp.Body.Close()
+
+	record.StatusCode = resp.StatusCode
+	record.Succeeded = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !record.Succeeded {
+		record.Error = fmt.Sprintf("unexpected status %s", resp.Status)
+	}
+	return record
+}
+
+// retryable reports whether a failed attempt should be retried. Transport
+// errors have no status code and are always retried.
+func retryable(statusCode int) bool {
+	switch {
+	case statusCode == 0:
+		return true
+	case statusCode == http.StatusRequestTimeout, statusCode == http.StatusTooManyRequests:
+		return true
+	case statusCode >= 500:
+		return true
+	default:
+		return false
+	}
+}
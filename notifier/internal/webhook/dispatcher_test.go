@@ -0,0 +1,318 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CP-Payne/taskflow/notifier/internal/model"
+	"github.com/CP-Payne/taskflow/notifier/internal/repository/memory"
+	"github.com/CP-Payne/taskflow/notifier/internal/webhook"
+	"github.com/CP-Payne/taskflow/pkg/events"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestDispatcher_Deliver(t *testing.T) {
+	tests := []struct {
+		name          string
+		responses     []int
+		wantAttempts  int
+		wantSucceeded bool
+	}{
+		{
+			name:          "Deliver on first attempt",
+			responses:     []int{http.StatusOK},
+			wantAttempts:  1,
+			wantSucceeded: true,
+		},
+		{
+			name:          "Retry server errors until success",
+			responses:     []int{http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusNoContent},
+			wantAttempts:  3,
+			wantSucceeded: true,
+		},
+		{
+			name:          "Do not retry client errors",
+			responses:     []int{http.StatusBadRequest},
+			wantAttempts:  1,
+			wantSucceeded: false,
+		},
+		{
+			name:          "Give up after max attempts",
+			responses:     []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			wantAttempts:  3,
+			wantSucceeded: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const secret = "whsec_test"
+			var calls atomic.Int32
+			var badSignature atomic.Bool
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				err := webhook.Verify(secret, r.Header.Get(webhook.HeaderSignature), r.Header.Get(webhook.HeaderTimestamp), body, time.Minute, time.Now())
+				if err != nil || r.Header.Get(webhook.HeaderEvent) != events.TypeTaskCreated {
+					badSignature.Store(true)
+				}
+				n := int(calls.Add(1))
+				w.WriteHeader(tt.responses[min(n, len(tt.responses))-1])
+			}))
+			defer srv.Close()
+
+			repo := memory.NewWebhookRepository()
+			endpoint := model.WebhookEndpoint{ID: uuid.New(), URL: srv.URL, Secret: secret}
+			if err := repo.CreateEndpoint(context.Background(), &endpoint); err != nil {
+				t.Fatalf("CreateEndpoint() failed: %v", err)
+			}
+			dispatcher := webhook.NewDispatcher(repo, zap.NewNop().Sugar(),
+				webhook.WithRetryPolicy(webhook.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}),
+				// The test server listens on loopback, which the default client refuses.
+				webhook.WithHTTPClient(srv.Client()),
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				dispatcher.Run(ctx)
+				close(done)
+			}()
+
+			env, err := events.DefaultRegistry.Wrap(&events.TaskCreatedEvent{Task: events.TaskSnapshot{ID: uuid.NewString()}})
+			if err != nil {
+				t.Fatalf("Wrap() failed: %v", err)
+			}
+			if err := dispatcher.Enqueue(ctx, endpoint, env); err != nil {
+				t.Fatalf("Enqueue() failed: %v", err)
+			}
+
+			var deliveries []model.WebhookDelivery
+			deadline := time.Now().Add(2 * time.Second)
+			for time.Now().Before(deadline) {
+				deliveries, _ = repo.ListDeliveries(ctx, endpoint.ID, 0)
+				if len(deliveries) == tt.wantAttempts && (deliveries[0].Succeeded || !tt.wantSucceeded) {
+					break
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+			// Give the dispatcher a chance to make unexpected extra attempts.
+			time.Sleep(20 * time.Millisecond)
+			cancel()
+			<-done
+
+			deliveries, _ = repo.ListDeliveries(context.Background(), endpoint.ID, 0)
+			if len(deliveries) != tt.wantAttempts {
+				t.Fatalf("expected %d recorded attempts, got %d", tt.wantAttempts, len(deliveries))
+			}
+			last := deliveries[0]
+			if last.Attempt != tt.wantAttempts || last.Succeeded != tt.wantSucceeded {
+				t.Errorf("unexpected last attempt: %+v", last)
+			}
+			if last.EventID != env.ID {
+				t.Errorf("delivery event ID: got %s, want %s", last.EventID, env.ID)
+			}
+			if badSignature.Load() {
+				t.Error("receiver got a request with an invalid signature or event header")
+			}
+		})
+	}
+}
+
+func TestDispatcher_RetriesDoNotBlockOtherEndpoints(t *testing.T) {
+	dead := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer dead.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer healthy.Close()
+
+	repo := memory.NewWebhookRepository()
+	deadEndpoint := model.WebhookEndpoint{ID: uuid.New(), URL: dead.URL, Secret: "a"}
+	healthyEndpoint := model.WebhookEndpoint{ID: uuid.New(), URL: healthy.URL, Secret: "b"}
+	// A single worker and retries due long after the test ends
+	dispatcher := webhook.NewDispatcher(repo, zap.NewNop().Sugar(),
+		webhook.WithWorkers(1, 8),
+		webhook.WithRetryPolicy(webhook.RetryPolicy{MaxAttempts: 6, BaseDelay: time.Hour, MaxDelay: time.Hour}),
+		webhook.WithHTTPClient(healthy.Client()),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		dispatcher.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	env, err := events.DefaultRegistry.Wrap(&events.TaskCreatedEvent{Task: events.TaskSnapshot{ID: uuid.NewString()}})
+	if err != nil {
+		t.Fatalf("Wrap() failed: %v", err)
+	}
+	for _, endpoint := range []model.WebhookEndpoint{deadEndpoint, healthyEndpoint} {
+		if err := repo.CreateEndpoint(ctx, &endpoint); err != nil {
+			t.Fatalf("CreateEndpoint() failed: %v", err)
+		}
+		if err := dispatcher.Enqueue(ctx, endpoint, env); err != nil {
+			t.Fatalf("Enqueue() failed: %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		deliveries, _ := repo.ListDeliveries(ctx, healthyEndpoint.ID, 0)
+		if len(deliveries) == 1 && deliveries[0].Succeeded {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("expected the healthy endpoint to be delivered to while the dead one waits for a retry")
+}
+
+func TestDispatcher_PendingDeliveriesSurviveRestart(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	repo := memory.NewWebhookRepository()
+	endpoint := model.WebhookEndpoint{ID: uuid.New(), URL: srv.URL, Secret: "a"}
+	if err := repo.CreateEndpoint(ctx, &endpoint); err != nil {
+		t.Fatalf("CreateEndpoint() failed: %v", err)
+	}
+	env, err := events.DefaultRegistry.Wrap(&events.TaskCreatedEvent{Task: events.TaskSnapshot{ID: uuid.NewString()}})
+	if err != nil {
+		t.Fatalf("Wrap() failed: %v", err)
+	}
+
+	// More events than the queue holds are accepted while no dispatcher runs.
+	stopped := webhook.NewDispatcher(repo, zap.NewNop().Sugar(), webhook.WithWorkers(1, 1))
+	const pending = 20
+	for i := 0; i < pending; i++ {
+		if err := stopped.Enqueue(ctx, endpoint, env); err != nil {
+			t.Fatalf("Enqueue() failed: %v", err)
+		}
+	}
+
+	dispatcher := webhook.NewDispatcher(repo, zap.NewNop().Sugar(), webhook.WithWorkers(2, 4), webhook.WithHTTPClient(srv.Client()))
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		dispatcher.Run(runCtx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for calls.Load() < pending && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+	if got := calls.Load(); got != pending {
+		t.Fatalf("expected %d deliveries after the restart, got %d", pending, got)
+	}
+	if left, _ := repo.ClaimDue(ctx, time.Now().Add(time.Hour), time.Minute, 0); len(left) != 0 {
+		t.Errorf("expected delivered events to be acknowledged, %d remain", len(left))
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	now := time.Now()
+	ts := now.Unix()
+	sig := webhook.Sign("secret", ts, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		body      []byte
+		now       time.Time
+		expectErr error
+	}{
+		{name: "Valid signature", secret: "secret", body: body, now: now},
+		{name: "Wrong secret", secret: "other", body: body, now: now, expectErr: webhook.ErrInvalidSignature},
+		{name: "Tampered body", secret: "secret", body: []byte(`{"id":"2"}`), now: now, expectErr: webhook.ErrInvalidSignature},
+		{name: "Replayed later", secret: "secret", body: body, now: now.Add(time.Hour), expectErr: webhook.ErrStaleTimestamp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := webhook.Verify(tt.secret, sig, strconv.FormatInt(ts, 10), tt.body, 5*time.Minute, tt.now)
+			if err != tt.expectErr {
+				t.Errorf("expected error %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestAllowedAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.0.0.5", false},
+		{"172.16.3.4", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.10", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"0.0.0.0", false},
+		{"::ffff:127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := webhook.AllowedAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("AllowedAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	ctx := context.Background()
+	for _, host := range []string{"127.0.0.1", "169.254.169.254", "localhost"} {
+		if err := webhook.CheckHost(ctx, host); !errors.Is(err, webhook.ErrForbiddenAddress) {
+			t.Errorf("CheckHost(%s): expected ErrForbiddenAddress, got %v", host, err)
+		}
+	}
+	if err := webhook.CheckHost(ctx, "93.184.216.34"); err != nil {
+		t.Errorf("CheckHost() of a public address failed: %v", err)
+	}
+}
+
+func TestNewHTTPClient_RefusesInternalAddresses(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer srv.Close()
+
+	_, err := webhook.NewHTTPClient(time.Second).Post(srv.URL, "application/json", nil)
+	if !errors.Is(err, webhook.ErrForbiddenAddress) {
+		t.Errorf("expected ErrForbiddenAddress, got %v", err)
+	}
+	if calls.Load() != 0 {
+		t.Error("expected no request to reach the loopback server")
+	}
+}
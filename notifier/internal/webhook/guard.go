@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned for endpoints on loopback, private or
+// link-local addresses, which would let callers reach internal services
+// with signed requests.
+var ErrForbiddenAddress = errors.New("webhook endpoint address is not public")
+
+// sharedAddressSpace is the carrier-grade NAT range, which clusters commonly
+// use for pod and service addresses.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// AllowedAddr reports whether webhooks may be delivered to addr.
+func AllowedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	switch {
+	case !addr.IsValid(), addr.IsUnspecified(), addr.IsLoopback(), addr.IsPrivate(),
+		addr.IsLinkLocalUnicast(), addr.IsLinkLocalMulticast(), addr.IsInterfaceLocalMulticast(),
+		addr.IsMulticast(), sharedAddressSpace.Contains(addr):
+		return false
+	default:
+		return true
+	}
+}
+
+// CheckHost resolves host and returns ErrForbiddenAddress unless all of its
+// addresses are allowed.
+func CheckHost(ctx context.Context, host string) error {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !AllowedAddr(addr) {
+			return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("resolve webhook host %q: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !AllowedAddr(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrForbiddenAddress, host, addr)
+		}
+	}
+	return nil
+}
+
+// NewHTTPClient returns the client deliveries are made with. It refuses to
+// connect to addresses AllowedAddr rejects, checked on the address actually
+// dialled so a host cannot resolve to a public address at registration and
+// to an internal one later. Proxies from the environment are not used and
+// redirects are not followed, so neither can lead elsewhere.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !AllowedAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -1,23 +1,32 @@
 package authkeys
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"os"
-	"path/filepath"
+	"sync"
 
 	"github.com/CP-Payne/taskflow/pkg/secrets"
+	"go.uber.org/zap"
 )
 
 type AuthKeys interface {
 	LoadFromPath(dirPath string) error
 	LoadFromSecretsManager(secretsManager secrets.Secrets, keyPath, keyName string, keyType KeyType) error
+	// Watch reloads the key whenever watcher reads a new version of the
+	// secret at keyPath. Versions without the key are logged and skipped.
+	Watch(watcher *secrets.Watcher, keyPath, keyName string, keyType KeyType, logger *zap.SugaredLogger)
+	// OnReload registers fn to be called after a watched key changed.
+	OnReload(fn func())
 	PrivateKey() []byte
 	PublicKey() []byte
 }
 
 type authKeys struct {
-	private []byte
-	public  []byte
+	mu       sync.RWMutex
+	private  []byte
+	public   []byte
+	onReload []func()
 }
 
 type KeyType int
@@ -31,45 +40,76 @@ func NewAuthKeys() *authKeys {
 	return &authKeys{}
 }
 
+// LoadFromPath reads the id_rsa.pem and id_rsa.pem.pub files of dirPath.
 func (a *authKeys) LoadFromPath(dirPath string) error {
-	privKeyPath := filepath.Join(dirPath, "id_rsa.pem")
-	pubKeyPath := filepath.Join(dirPath, "id_rsa.pem.pub")
+	files := secrets.NewFileProvider(dirPath)
 
-	fmt.Printf("Attempting to load private key from %s\n", privKeyPath)
-	prvKey, err := os.ReadFile(privKeyPath)
+	prvKey, err := secrets.GetValue(context.Background(), files, "", "id_rsa.pem")
 	if err != nil {
-		return fmt.Errorf("failed to read private key (%s): %w", privKeyPath, err)
+		return fmt.Errorf("failed to read private key from %s: %w", dirPath, err)
 	}
-
-	fmt.Printf("Attempting to load public key from %s\n", pubKeyPath)
-	pubKey, err := os.ReadFile(pubKeyPath)
+	pubKey, err := secrets.GetValue(context.Background(), files, "", "id_rsa.pem.pub")
 	if err != nil {
-		return fmt.Errorf("failed to read public key (%s): %w", pubKeyPath, err)
+		return fmt.Errorf("failed to read public key from %s: %w", dirPath, err)
 	}
 
-	a.private = prvKey
-	a.public = pubKey
-
-	fmt.Println("Successfullyu loaded keys.")
-
+	a.set(Private, []byte(prvKey))
+	a.set(Public, []byte(pubKey))
 	return nil
 }
 
 func (a *authKeys) LoadFromSecretsManager(secretsManager secrets.Secrets, keyPath, keyName string, keyType KeyType) error {
-	key, err := secretsManager.GetCryptoKey(keyPath, keyName)
+	key, err := secrets.GetValue(context.Background(), secretsManager, keyPath, keyName)
 	if err != nil {
 		return err
 	}
-	if keyType == Private {
-		a.private = key
-	} else {
-		a.public = key
-	}
+	a.set(keyType, []byte(key))
 
 	return nil
 }
 
+func (a *authKeys) Watch(watcher *secrets.Watcher, keyPath, keyName string, keyType KeyType, logger *zap.SugaredLogger) {
+	watcher.Watch(keyPath, func(secret *secrets.Secret) {
+		key, err := secret.Value(keyName)
+		if err != nil {
+			logger.Warnw("Keeping the current key, the new secret version has none", "path", keyPath, "version", secret.Version, "error", err)
+			return
+		}
+		if a.set(keyType, []byte(key)) {
+			a.mu.RLock()
+			callbacks := a.onReload
+			a.mu.RUnlock()
+			for _, fn := range callbacks {
+				fn()
+			}
+		}
+	})
+}
+
+func (a *authKeys) OnReload(fn func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onReload = append(a.onReload, fn)
+}
+
+// set stores the key and reports whether it changed.
+func (a *authKeys) set(keyType KeyType, key []byte) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	current := &a.public
+	if keyType == Private {
+		current = &a.private
+	}
+	if bytes.Equal(*current, key) {
+		return false
+	}
+	*current = key
+	return true
+}
+
 func (a *authKeys) PrivateKey() []byte {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	if a.private == nil {
 		return nil
 	}
@@ -79,6 +119,8 @@ func (a *authKeys) PrivateKey() []byte {
 }
 
 func (a *authKeys) PublicKey() []byte {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	if a.public == nil {
 		return nil
 	}
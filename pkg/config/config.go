@@ -0,0 +1,517 @@
+// Package config loads a service's typed configuration. Values come from, in
+// increasing priority: the defaults already set on the struct, a YAML file,
+// environment variables and command line flags.
+//
+// Fields are described with struct tags:
+//
+//	yaml:"name"      key in the config file; nested structs are nested mappings
+//	env:"NAME"       environment variable
+//	flag:"name"      command line flag
+//	usage:"text"     flag help
+//	required:"true"  must not be empty once loaded
+//	secret:"true"    redacted by --print-config, may reference a secret
+//
+// Supported field types are strings, booleans, integers, floats,
+// time.Duration, string slices (comma separated in env and flags), and types
+// implementing encoding.TextUnmarshaler.
+package config
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrPrinted is returned by Load after --print-config printed the
+// configuration. The program should exit successfully.
+var ErrPrinted = errors.New("configuration printed")
+
+// Redacted replaces secret values in printed configurations.
+const Redacted = "<redacted>"
+
+// Validator is implemented by configuration structs, at any depth, that check
+// more than required fields.
+type Validator interface {
+	Validate() error
+}
+
+type Loader struct {
+	// Name is the program name shown in flag usage.
+	Name string
+	// EnvFiles are optional .env files. Variables set in the environment take
+	// precedence, and missing files are skipped.
+	EnvFiles  []string
+	LookupEnv func(key string) (string, bool)
+	// Output receives --print-config and flag usage.
+	Output io.Writer
+}
+
+func New(name string, envFiles ...string) *Loader {
+	return &Loader{
+		Name:      name,
+		EnvFiles:  envFiles,
+		LookupEnv: os.LookupEnv,
+		Output:    os.Stdout,
+	}
+}
+
+type field struct {
+	// path is the dotted file key, used in error messages.
+	path     string
+	value    reflect.Value
+	env      string
+	flag     string
+	usage    string
+	required bool
+}
+
+// rawFlag records a flag value so that it is applied after the file and the
+// environment.
+type rawFlag struct {
+	def    string
+	value  string
+	isBool bool
+}
+
+func (f *rawFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.def
+}
+
+func (f *rawFlag) Set(s string) error {
+	f.value = s
+	return nil
+}
+
+func (f *rawFlag) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// Load fills cfg, a pointer to a struct holding the defaults, from the config
+// file, the environment and args. The file is named by the --config flag or
+// the CONFIG_FILE variable. Every invalid value and missing required field is
+// reported in the returned error.
+func (l *Loader) Load(cfg any, args []string) error {
+	root := reflect.ValueOf(cfg)
+	if root.Kind() != reflect.Pointer || root.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config must be a pointer to a struct, got %T", cfg)
+	}
+	fields, err := collect(root.Elem(), "")
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet(l.Name, flag.ContinueOnError)
+	fs.SetOutput(l.Output)
+	configFile := fs.String("config", "", "path to a YAML config file")
+	printConfig := fs.Bool("print-config", false, "print the configuration with secrets redacted, then exit")
+	flags := make(map[string]*rawFlag)
+	for _, f := range fields {
+		if f.flag == "" {
+			continue
+		}
+		raw := &rawFlag{def: format(f.value), isBool: f.value.Kind() == reflect.Bool}
+		flags[f.flag] = raw
+		fs.Var(raw, f.flag, f.usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	lookupEnv, err := l.envLookup()
+	if err != nil {
+		return err
+	}
+
+	if *configFile == "" {
+		*configFile, _ = lookupEnv("CONFIG_FILE")
+	}
+	if *configFile != "" {
+		data, err := os.ReadFile(*configFile)
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", *configFile, err)
+		}
+	}
+
+	var errs []error
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		if s, ok := lookupEnv(f.env); ok {
+			if err := set(f.value, s); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %w", f.env, err))
+			}
+		}
+	}
+
+	visited := make(map[string]bool)
+	fs.Visit(func(fl *flag.Flag) { visited[fl.Name] = true })
+	for _, f := range fields {
+		if f.flag == "" || !visited[f.flag] {
+			continue
+		}
+		if err := set(f.value, flags[f.flag].value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid -%s: %w", f.flag, err))
+		}
+	}
+
+	if *printConfig {
+		if err := Print(l.Output, cfg); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range fields {
+		if f.required && f.value.IsZero() {
+			errs = append(errs, fmt.Errorf("%s is required%s", f.path, sources(f)))
+		}
+	}
+	errs = append(errs, validate(root)...)
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if *printConfig {
+		return ErrPrinted
+	}
+	return nil
+}
+
+// envLookup looks variables up in the environment, then in the env files.
+func (l *Loader) envLookup() (func(string) (string, bool), error) {
+	lookupEnv := l.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+
+	files := make(map[string]string)
+	for _, path := range l.EnvFiles {
+		vars, err := godotenv.Read(path)
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read env file %s: %w", path, err)
+		}
+		for k, v := range vars {
+			if _, ok := files[k]; !ok {
+				files[k] = v
+			}
+		}
+	}
+
+	return func(key string) (string, bool) {
+		if v, ok := lookupEnv(key); ok {
+			return v, true
+		}
+		v, ok := files[key]
+		return v, ok
+	}, nil
+}
+
+// collect returns the settable leaf fields of v.
+func collect(v reflect.Value, prefix string) ([]field, error) {
+	var fields []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		key := yamlKey(sf)
+		if key == "-" {
+			continue
+		}
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && !isText(fv) {
+			nested, err := collect(fv, path)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, nested...)
+			continue
+		}
+		if !supported(fv) {
+			return nil, fmt.Errorf("unsupported config field %s of type %s", path, fv.Type())
+		}
+
+		fields = append(fields, field{
+			path:     path,
+			value:    fv,
+			env:      sf.Tag.Get("env"),
+			flag:     sf.Tag.Get("flag"),
+			usage:    sf.Tag.Get("usage"),
+			required: sf.Tag.Get("required") == "true",
+		})
+	}
+	return fields, nil
+}
+
+// yamlKey returns the key of a field in the file, following yaml.v3's rules.
+func yamlKey(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+	if name == "" {
+		return strings.ToLower(sf.Name)
+	}
+	return name
+}
+
+func sources(f field) string {
+	var names []string
+	if f.env != "" {
+		names = append(names, f.env)
+	}
+	if f.flag != "" {
+		names = append(names, "-"+f.flag)
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return " (set " + strings.Join(names, " or ") + ")"
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// isText reports whether v is set from text as a whole, even if it is a
+// struct.
+func isText(v reflect.Value) bool {
+	return reflect.PointerTo(v.Type()).Implements(textUnmarshalerType)
+}
+
+func supported(v reflect.Value) bool {
+	if isText(v) {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return v.Type().Elem().Kind() == reflect.String
+	default:
+		return false
+	}
+}
+
+// set parses s into v.
+func set(v reflect.Value, s string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			slice.Index(i).SetString(item)
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// format returns v as it would be set from a flag.
+func format(v reflect.Value) string {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		if err != nil {
+			return ""
+		}
+		return string(text)
+	}
+	if v.Kind() == reflect.Slice {
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = v.Index(i).String()
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// validate calls Validate on v and every nested struct implementing Validator.
+func validate(v reflect.Value) []error {
+	var errs []error
+	if val, ok := v.Interface().(Validator); ok {
+		if err := val.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	s := v
+	if s.Kind() == reflect.Pointer {
+		s = s.Elem()
+	}
+	for i := 0; i < s.NumField(); i++ {
+		fv := s.Field(i)
+		if s.Type().Field(i).IsExported() && fv.Kind() == reflect.Struct && !isText(fv) {
+			errs = append(errs, validate(fv.Addr())...)
+		}
+	}
+	return errs
+}
+
+// ResolveSecrets replaces the secret string fields of cfg, a pointer to a
+// struct, with what resolve returns for them, e.g. the value of a
+// "secret:path#key" reference read from Vault. Every failed field is reported
+// in the returned error.
+func ResolveSecrets(cfg any, resolve func(value string) (string, error)) error {
+	root := reflect.ValueOf(cfg)
+	if root.Kind() != reflect.Pointer || root.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config must be a pointer to a struct, got %T", cfg)
+	}
+	return errors.Join(resolveSecrets(root.Elem(), "", resolve)...)
+}
+
+func resolveSecrets(v reflect.Value, prefix string, resolve func(string) (string, error)) []error {
+	var errs []error
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := yamlKey(sf)
+		if !sf.IsExported() || key == "-" {
+			continue
+		}
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		fv := v.Field(i)
+		switch {
+		case fv.Kind() == reflect.Struct && !isText(fv):
+			errs = append(errs, resolveSecrets(fv, path, resolve)...)
+		case sf.Tag.Get("secret") == "true" && fv.Kind() == reflect.String && fv.String() != "":
+			s, err := resolve(fv.String())
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to resolve %s: %w", path, err))
+				continue
+			}
+			fv.SetString(s)
+		}
+	}
+	return errs
+}
+
+// Print writes cfg as YAML with secret values redacted.
+func Print(w io.Writer, cfg any) error {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	node, err := toNode(v)
+	if err != nil {
+		return err
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func toNode(v reflect.Value) (*yaml.Node, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := yamlKey(sf)
+		if !sf.IsExported() || key == "-" {
+			continue
+		}
+
+		fv := v.Field(i)
+		var value *yaml.Node
+		switch {
+		case sf.Tag.Get("secret") == "true":
+			text := ""
+			if !fv.IsZero() {
+				text = Redacted
+			}
+			value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: text}
+		case fv.Kind() == reflect.Struct && !isText(fv):
+			nested, err := toNode(fv)
+			if err != nil {
+				return nil, err
+			}
+			value = nested
+		default:
+			value = &yaml.Node{}
+			if err := value.Encode(fv.Interface()); err != nil {
+				return nil, fmt.Errorf("failed to encode %s: %w", key, err)
+			}
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	}
+	return node, nil
+}
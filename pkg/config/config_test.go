@@ -0,0 +1,178 @@
+package config_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CP-Payne/taskflow/pkg/config"
+)
+
+type testRedis struct {
+	Addr     string `yaml:"addr" env:"TEST_REDIS_ADDR" required:"true"`
+	Password string `yaml:"password" env:"TEST_REDIS_PASSWORD" secret:"true"`
+}
+
+type testConfig struct {
+	Port     int           `yaml:"port" env:"TEST_PORT" flag:"port" usage:"API handler port"`
+	Timeout  time.Duration `yaml:"timeout" env:"TEST_TIMEOUT" flag:"timeout"`
+	Tags     []string      `yaml:"tags" env:"TEST_TAGS"`
+	Debug    bool          `yaml:"debug" flag:"debug"`
+	Redis    testRedis     `yaml:"redis"`
+	Mode     string        `yaml:"mode" env:"TEST_MODE"`
+	internal string
+}
+
+func (c testConfig) Validate() error {
+	if c.Mode != "" && c.Mode != "fast" && c.Mode != "safe" {
+		return errors.New("mode must be fast or safe")
+	}
+	return nil
+}
+
+func defaults() testConfig {
+	return testConfig{Port: 9001, Timeout: time.Second}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func newLoader(env map[string]string, out *bytes.Buffer) *config.Loader {
+	l := config.New("test")
+	l.LookupEnv = func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+	l.Output = out
+	return l
+}
+
+func TestLoader_Load(t *testing.T) {
+	file := writeFile(t, "config.yaml", "port: 9100\ntimeout: 3s\nredis:\n  addr: file:6379\n  password: from-file\ntags: [a, b]\n")
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		want testConfig
+	}{
+		{
+			name: "Defaults",
+			env:  map[string]string{"TEST_REDIS_ADDR": "env:6379"},
+			want: testConfig{Port: 9001, Timeout: time.Second, Redis: testRedis{Addr: "env:6379"}},
+		},
+		{
+			name: "File overrides defaults",
+			args: []string{"-config", file},
+			want: testConfig{Port: 9100, Timeout: 3 * time.Second, Tags: []string{"a", "b"}, Redis: testRedis{Addr: "file:6379", Password: "from-file"}},
+		},
+		{
+			name: "Env overrides file",
+			env:  map[string]string{"CONFIG_FILE": file, "TEST_PORT": "9200", "TEST_TAGS": "x, y", "TEST_REDIS_ADDR": "env:6379"},
+			want: testConfig{Port: 9200, Timeout: 3 * time.Second, Tags: []string{"x", "y"}, Redis: testRedis{Addr: "env:6379", Password: "from-file"}},
+		},
+		{
+			name: "Flags override env",
+			env:  map[string]string{"TEST_PORT": "9200", "TEST_TIMEOUT": "5s", "TEST_REDIS_ADDR": "env:6379"},
+			args: []string{"--port", "9300", "--debug"},
+			want: testConfig{Port: 9300, Timeout: 5 * time.Second, Debug: true, Redis: testRedis{Addr: "env:6379"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaults()
+			if err := newLoader(tt.env, &bytes.Buffer{}).Load(&cfg, tt.args); err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+			if cfg.Port != tt.want.Port || cfg.Timeout != tt.want.Timeout || cfg.Debug != tt.want.Debug ||
+				cfg.Redis != tt.want.Redis || strings.Join(cfg.Tags, ",") != strings.Join(tt.want.Tags, ",") {
+				t.Errorf("expected %+v, got %+v", tt.want, cfg)
+			}
+		})
+	}
+}
+
+func TestLoader_Load_ReportsAllErrors(t *testing.T) {
+	cfg := defaults()
+	env := map[string]string{"TEST_PORT": "nine", "TEST_MODE": "slow"}
+	err := newLoader(env, &bytes.Buffer{}).Load(&cfg, []string{"-timeout", "soon"})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, want := range []string{"TEST_PORT", "-timeout", "redis.addr is required (set TEST_REDIS_ADDR)", "mode must be fast or safe"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %q, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoader_Load_EnvFiles(t *testing.T) {
+	envFile := writeFile(t, ".env", "TEST_REDIS_ADDR=dotenv:6379\nTEST_PORT=9400\n")
+
+	cfg := defaults()
+	l := newLoader(map[string]string{"TEST_PORT": "9500"}, &bytes.Buffer{})
+	l.EnvFiles = []string{filepath.Join(t.TempDir(), "missing.env"), envFile}
+	if err := l.Load(&cfg, nil); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.Redis.Addr != "dotenv:6379" {
+		t.Errorf("expected the address from the env file, got %q", cfg.Redis.Addr)
+	}
+	if cfg.Port != 9500 {
+		t.Errorf("expected the environment to win over the env file, got %d", cfg.Port)
+	}
+}
+
+func TestLoader_Load_PrintConfig(t *testing.T) {
+	cfg := defaults()
+	var out bytes.Buffer
+	env := map[string]string{"TEST_REDIS_ADDR": "env:6379", "TEST_REDIS_PASSWORD": "hunter2"}
+	err := newLoader(env, &out).Load(&cfg, []string{"--print-config"})
+	if !errors.Is(err, config.ErrPrinted) {
+		t.Fatalf("expected ErrPrinted, got %v", err)
+	}
+
+	printed := out.String()
+	if strings.Contains(printed, "hunter2") {
+		t.Errorf("expected the password to be redacted:\n%s", printed)
+	}
+	for _, want := range []string{"port: 9001", "timeout: 1s", "addr: env:6379", "password: " + config.Redacted} {
+		if !strings.Contains(printed, want) {
+			t.Errorf("expected output to contain %q:\n%s", want, printed)
+		}
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	cfg := testConfig{Mode: "secret:mode#value", Redis: testRedis{Addr: "secret:redis#addr", Password: "secret:redis#password"}}
+	err := config.ResolveSecrets(&cfg, func(value string) (string, error) {
+		return strings.ToUpper(value), nil
+	})
+	if err != nil {
+		t.Fatalf("ResolveSecrets() failed: %v", err)
+	}
+	if cfg.Redis.Password != "SECRET:REDIS#PASSWORD" {
+		t.Errorf("expected the password to be resolved, got %q", cfg.Redis.Password)
+	}
+	if cfg.Redis.Addr != "secret:redis#addr" || cfg.Mode != "secret:mode#value" {
+		t.Errorf("expected fields not tagged secret to be unchanged, got %+v", cfg)
+	}
+
+	failure := errors.New("permission denied")
+	err = config.ResolveSecrets(&cfg, func(string) (string, error) { return "", failure })
+	if !errors.Is(err, failure) || !strings.Contains(err.Error(), "redis.password") {
+		t.Errorf("expected an error naming redis.password, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+// Package backend builds the discovery.Registry a service is configured to
+// use.
+package backend
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/CP-Payne/taskflow/pkg/discovery"
+	"github.com/CP-Payne/taskflow/pkg/discovery/consul"
+	"github.com/CP-Payne/taskflow/pkg/discovery/memory"
+	"github.com/CP-Payne/taskflow/pkg/discovery/static"
+)
+
+const (
+	Consul = "consul"
+	Static = "static"
+	// Memory only discovers services running in the same process.
+	Memory = "memory"
+
+	DefaultConsulAddr = "localhost:8500"
+)
+
+type Config struct {
+	Backend    string         `yaml:"backend" env:"DISCOVERY_BACKEND" usage:"consul, static or memory"`
+	ConsulAddr string         `yaml:"consul_addr" env:"CONSUL_ADDR"`
+	Consul     consul.Options `yaml:"consul"`
+	// Services lists the addresses of each service for the static backend,
+	// e.g. "user=localhost:9001;task=localhost:9002".
+	Services static.Services `yaml:"services" env:"DISCOVERY_SERVICES"`
+
+	// Tags, Version and Zone describe the registering instance.
+	Tags    []string `yaml:"tags" env:"SERVICE_TAGS"`
+	Version string   `yaml:"version" env:"SERVICE_VERSION"`
+	Zone    string   `yaml:"zone" env:"SERVICE_ZONE"`
+}
+
+// DefaultConfig uses the local Consul agent.
+func DefaultConfig() Config {
+	return Config{
+		Backend:    Consul,
+		ConsulAddr: DefaultConsulAddr,
+		Consul:     consul.DefaultOptions(),
+	}
+}
+
+func (c Config) Validate() error {
+	switch c.Backend {
+	case "", Consul, Memory:
+		return nil
+	case Static:
+		if len(c.Services) == 0 {
+			return errors.New("the static discovery backend needs DISCOVERY_SERVICES")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown discovery backend %q", c.Backend)
+	}
+}
+
+// RegisterOptions returns the options registering the instance with its tags
+// and metadata.
+func (c Config) RegisterOptions() []discovery.RegisterOption {
+	var opts []discovery.RegisterOption
+	if len(c.Tags) > 0 {
+		opts = append(opts, discovery.WithTags(c.Tags...))
+	}
+	if c.Version != "" {
+		opts = append(opts, discovery.WithVersion(c.Version))
+	}
+	if c.Zone != "" {
+		opts = append(opts, discovery.WithZone(c.Zone))
+	}
+	return opts
+}
+
+func New(cfg Config) (discovery.Registry, error) {
+	switch cfg.Backend {
+	case "", Consul:
+		addr := cfg.ConsulAddr
+		if addr == "" {
+			addr = DefaultConsulAddr
+		}
+		return consul.NewRegistry(addr, cfg.Consul)
+	case Static:
+		return static.NewRegistry(cfg.Services), nil
+	case Memory:
+		return memory.NewRegistry(), nil
+	default:
+		return nil, fmt.Errorf("unknown discovery backend %q", cfg.Backend)
+	}
+}
@@ -4,18 +4,86 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/CP-Payne/taskflow/pkg/discovery"
 	consul "github.com/hashicorp/consul/api"
 )
 
+type CheckType string
+
+const (
+	// CheckTTL expects the instance to report its health through
+	// ReportHealthState before the TTL runs out.
+	CheckTTL CheckType = "ttl"
+	// CheckGRPC lets Consul call the standard gRPC health service of the
+	// instance.
+	CheckGRPC CheckType = "grpc"
+)
+
+const (
+	DefaultTTL                     = 5 * time.Second
+	DefaultCheckInterval           = 10 * time.Second
+	DefaultCheckTimeout            = 2 * time.Second
+	DefaultDeregisterCriticalAfter = time.Minute
+
+	// watchWait bounds each blocking query.
+	watchWait = 5 * time.Minute
+	// watchMaxBackoff caps the delay between failed watch queries.
+	watchMaxBackoff = 30 * time.Second
+)
+
+// Options configures how instances are checked. Zero values use the defaults.
+type Options struct {
+	Check CheckType `yaml:"check" env:"CONSUL_HEALTH_CHECK" usage:"ttl or grpc"`
+	// TTL is the deadline for health reports with CheckTTL.
+	TTL time.Duration `yaml:"ttl"`
+	// Interval and Timeout apply to CheckGRPC.
+	Interval time.Duration `yaml:"interval"`
+	Timeout  time.Duration `yaml:"timeout"`
+	// DeregisterCriticalAfter removes instances whose check stayed critical for
+	// this long, e.g. after a crash that skipped Deregister.
+	DeregisterCriticalAfter time.Duration `yaml:"deregister_critical_after" env:"CONSUL_DEREGISTER_CRITICAL_AFTER"`
+}
+
+// DefaultOptions returns the options used for zero values.
+func DefaultOptions() Options {
+	return Options{}.withDefaults()
+}
+
+func (o Options) withDefaults() Options {
+	if o.Check == "" {
+		o.Check = CheckTTL
+	}
+	if o.TTL == 0 {
+		o.TTL = DefaultTTL
+	}
+	if o.Interval == 0 {
+		o.Interval = DefaultCheckInterval
+	}
+	if o.Timeout == 0 {
+		o.Timeout = DefaultCheckTimeout
+	}
+	if o.DeregisterCriticalAfter == 0 {
+		o.DeregisterCriticalAfter = DefaultDeregisterCriticalAfter
+	}
+	return o
+}
+
 type Registry struct {
 	client *consul.Client
+	opts   Options
 }
 
-func NewRegistry(addr string) (*Registry, error) {
+func NewRegistry(addr string, opts Options) (*Registry, error) {
+	opts = opts.withDefaults()
+	if opts.Check != CheckTTL && opts.Check != CheckGRPC {
+		return nil, fmt.Errorf("unknown health check type %q", opts.Check)
+	}
+
 	config := consul.DefaultConfig()
 	config.Address = addr
 	client, err := consul.NewClient(config)
@@ -23,10 +91,10 @@ func NewRegistry(addr string) (*Registry, error) {
 		return nil, err
 	}
 
-	return &Registry{client: client}, nil
+	return &Registry{client: client, opts: opts}, nil
 }
 
-func (r *Registry) Register(ctx context.Context, instanceID, serviceName, hostPort string) error {
+func (r *Registry) Register(ctx context.Context, instanceID, serviceName, hostPort string, opts ...discovery.RegisterOption) error {
 	parts := strings.Split(hostPort, ":")
 	if len(parts) != 2 {
 		return errors.New("hostPort must be in a form of <host>:<port>, example: localhost:8081")
@@ -37,38 +105,109 @@ func (r *Registry) Register(ctx context.Context, instanceID, serviceName, hostPo
 		return err
 	}
 
-	return r.client.Agent().ServiceRegister(&consul.AgentServiceRegistration{
+	registration := discovery.NewRegistration(opts...)
+	return r.client.Agent().ServiceRegisterOpts(&consul.AgentServiceRegistration{
 		Address: parts[0],
 		ID:      instanceID,
 		Name:    serviceName,
 		Port:    port,
-		Check: &consul.AgentServiceCheck{
-			CheckID: instanceID,
-			TTL:     "5s",
-		},
-	})
+		Tags:    registration.Tags,
+		Meta:    registration.Meta,
+		Check:   r.check(instanceID, hostPort),
+	}, consul.ServiceRegisterOpts{}.WithContext(ctx))
+}
+
+func (r *Registry) check(instanceID, hostPort string) *consul.AgentServiceCheck {
+	check := &consul.AgentServiceCheck{
+		CheckID:                        instanceID,
+		DeregisterCriticalServiceAfter: r.opts.DeregisterCriticalAfter.String(),
+	}
+	switch r.opts.Check {
+	case CheckGRPC:
+		check.GRPC = hostPort
+		check.Interval = r.opts.Interval.String()
+		check.Timeout = r.opts.Timeout.String()
+	default:
+		check.TTL = r.opts.TTL.String()
+	}
+	return check
 }
 
 func (r *Registry) Deregister(ctx context.Context, instanceID string, _ string) error {
-	return r.client.Agent().ServiceDeregister(instanceID)
+	return r.client.Agent().ServiceDeregisterOpts(instanceID, (&consul.QueryOptions{}).WithContext(ctx))
 }
 
 func (r *Registry) ServiceAddresses(ctx context.Context, serviceName string) ([]string, error) {
-	entries, _, err := r.client.Health().Service(serviceName, "", true, nil)
+	entries, _, err := r.client.Health().Service(serviceName, "", true, (&consul.QueryOptions{}).WithContext(ctx))
 	if err != nil {
 		return nil, err
 	} else if len(entries) == 0 {
 		return nil, discovery.ErrNotFound
 	}
 
-	var res []string
+	return addresses(entries), nil
+}
 
-	for _, e := range entries {
-		res = append(res, fmt.Sprintf("%s:%d", e.Service.Address, e.Service.Port))
+// ReportHealthState passes the TTL check. With CheckGRPC Consul polls the
+// instance itself, so there is nothing to report.
+func (r *Registry) ReportHealthState(ctx context.Context, instanceID string, _ string) error {
+	if r.opts.Check != CheckTTL {
+		return nil
 	}
-	return res, nil
+	return r.client.Agent().UpdateTTLOpts(instanceID, "", consul.HealthPassing, (&consul.QueryOptions{}).WithContext(ctx))
 }
 
-func (r *Registry) ReportHealthState(ctx context.Context, instanceID string, _ string) error {
-	return r.client.Agent().PassTTL(instanceID, "")
+// Watch follows the healthy instances of serviceName with blocking queries.
+// Failed queries are retried with backoff until ctx is done.
+func (r *Registry) Watch(ctx context.Context, serviceName string) (<-chan []string, error) {
+	ch := make(chan []string, 1)
+	go func() {
+		defer close(ch)
+
+		var (
+			index   uint64
+			last    []string
+			sent    bool
+			backoff time.Duration
+		)
+		for ctx.Err() == nil {
+			opts := (&consul.QueryOptions{WaitIndex: index, WaitTime: watchWait}).WithContext(ctx)
+			entries, meta, err := r.client.Health().Service(serviceName, "", true, opts)
+			if err != nil {
+				backoff = min(max(2*backoff, time.Second), watchMaxBackoff)
+				select {
+				case <-ctx.Done():
+				case <-time.After(backoff):
+				}
+				continue
+			}
+			backoff = 0
+
+			// The index may go backwards, e.g. after a leader change; start over
+			// rather than wait on an index that will not be reached.
+			if meta.LastIndex < index {
+				index = 0
+			} else {
+				index = meta.LastIndex
+			}
+
+			addrs := addresses(entries)
+			if sent && slices.Equal(addrs, last) {
+				continue
+			}
+			discovery.SendLatest(ch, addrs)
+			last, sent = addrs, true
+		}
+	}()
+	return ch, nil
+}
+
+// addresses returns the sorted addresses of the entries.
+func addresses(entries []*consul.ServiceEntry) []string {
+	res := make([]string, 0, len(entries))
+	for _, e := range entries {
+		res = append(res, fmt.Sprintf("%s:%d", e.Service.Address, e.Service.Port))
+	}
+	slices.Sort(res)
+	return res
 }
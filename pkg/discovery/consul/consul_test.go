@@ -0,0 +1,26 @@
+package consul_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/CP-Payne/taskflow/pkg/discovery"
+	"github.com/CP-Payne/taskflow/pkg/discovery/consul"
+	"github.com/CP-Payne/taskflow/pkg/discovery/discoverytest"
+)
+
+// TestRegistry runs against the Consul agent at CONSUL_TEST_ADDR.
+func TestRegistry(t *testing.T) {
+	addr := os.Getenv("CONSUL_TEST_ADDR")
+	if addr == "" {
+		t.Skip("CONSUL_TEST_ADDR not set")
+	}
+
+	discoverytest.Run(t, func(t *testing.T) discovery.Registry {
+		r, err := consul.NewRegistry(addr, consul.Options{})
+		if err != nil {
+			t.Fatalf("NewRegistry() failed: %v", err)
+		}
+		return r
+	})
+}
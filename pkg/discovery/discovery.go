@@ -9,10 +9,14 @@ import (
 )
 
 type Registry interface {
-	Register(ctx context.Context, instanceID, serviceName, hostPort string) error
+	Register(ctx context.Context, instanceID, serviceName, hostPort string, opts ...RegisterOption) error
 	Deregister(ctx context.Context, instanceID, serviceName string) error
 	ServiceAddresses(ctx context.Context, serviceID string) ([]string, error)
 	ReportHealthState(ctx context.Context, instanceID string, serviceName string) error
+	// Watch sends the healthy addresses of serviceName, then every change to
+	// them, until ctx is done and the channel is closed. An empty list means no
+	// instance is available. Readers that fall behind only see the latest list.
+	Watch(ctx context.Context, serviceName string) (<-chan []string, error)
 }
 
 var ErrNotFound = errors.New("no service addresses found")
@@ -20,3 +24,66 @@ var ErrNotFound = errors.New("no service addresses found")
 func GenerateInstanceID(serviceName string) string {
 	return fmt.Sprintf("%s-%d", serviceName, rand.New(rand.NewSource(time.Now().UnixNano())).Int())
 }
+
+// Metadata keys set by WithVersion and WithZone.
+const (
+	MetaVersion = "version"
+	MetaZone    = "zone"
+)
+
+// Registration describes an instance beyond its address. Backends that cannot
+// store it ignore it.
+type Registration struct {
+	Tags []string
+	Meta map[string]string
+}
+
+type RegisterOption func(*Registration)
+
+func WithTags(tags ...string) RegisterOption {
+	return func(r *Registration) {
+		r.Tags = append(r.Tags, tags...)
+	}
+}
+
+func WithMeta(key, value string) RegisterOption {
+	return func(r *Registration) {
+		if r.Meta == nil {
+			r.Meta = make(map[string]string)
+		}
+		r.Meta[key] = value
+	}
+}
+
+// WithVersion records the version of the running build.
+func WithVersion(version string) RegisterOption {
+	return WithMeta(MetaVersion, version)
+}
+
+// WithZone records the availability zone the instance runs in.
+func WithZone(zone string) RegisterOption {
+	return WithMeta(MetaZone, zone)
+}
+
+func NewRegistration(opts ...RegisterOption) Registration {
+	var r Registration
+	for _, opt := range opts {
+		opt(&r)
+	}
+	return r
+}
+
+// SendLatest delivers addrs on a watch channel with a buffer of one, replacing
+// the previous list if it has not been read yet. ch must have a single sender.
+func SendLatest(ch chan []string, addrs []string) {
+	select {
+	case ch <- addrs:
+		return
+	default:
+	}
+	select {
+	case <-ch:
+	default:
+	}
+	ch <- addrs
+}
@@ -0,0 +1,162 @@
+// Package discoverytest holds the behaviour every discovery.Registry
+// implementation must provide.
+package discoverytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/CP-Payne/taskflow/pkg/discovery"
+)
+
+// Run runs the registry suite against registries returned by newRegistry.
+// Each subtest uses its own service names, so a shared backend may be reused.
+func Run(t *testing.T, newRegistry func(t *testing.T) discovery.Registry) {
+	t.Run("Unknown service", func(t *testing.T) {
+		r := newRegistry(t)
+		_, err := r.ServiceAddresses(context.Background(), serviceName())
+		if !errors.Is(err, discovery.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Register and deregister instances", func(t *testing.T) {
+		ctx := context.Background()
+		r := newRegistry(t)
+		name := serviceName()
+		first, second := discovery.GenerateInstanceID(name), discovery.GenerateInstanceID(name)
+		register(t, r, first, name, "127.0.0.1:7001")
+		register(t, r, second, name, "127.0.0.1:7002")
+
+		expectAddresses(t, r, name, "127.0.0.1:7001", "127.0.0.1:7002")
+
+		if err := r.Deregister(ctx, first, name); err != nil {
+			t.Fatalf("Deregister() failed: %v", err)
+		}
+		expectAddresses(t, r, name, "127.0.0.1:7002")
+	})
+
+	t.Run("Deregister last instance", func(t *testing.T) {
+		ctx := context.Background()
+		r := newRegistry(t)
+		name := serviceName()
+		id := discovery.GenerateInstanceID(name)
+		register(t, r, id, name, "127.0.0.1:7003")
+		if err := r.Deregister(ctx, id, name); err != nil {
+			t.Fatalf("Deregister() failed: %v", err)
+		}
+		if _, err := r.ServiceAddresses(ctx, name); !errors.Is(err, discovery.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Watch", func(t *testing.T) {
+		r := newRegistry(t)
+		name := serviceName()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		updates, err := r.Watch(ctx, name)
+		if err != nil {
+			t.Fatalf("Watch() failed: %v", err)
+		}
+		expectUpdate(t, updates)
+
+		id := discovery.GenerateInstanceID(name)
+		register(t, r, id, name, "127.0.0.1:7004")
+		expectUpdate(t, updates, "127.0.0.1:7004")
+
+		if err := r.Deregister(context.Background(), id, name); err != nil {
+			t.Fatalf("Deregister() failed: %v", err)
+		}
+		expectUpdate(t, updates)
+
+		cancel()
+		deadline := time.After(watchTimeout)
+		for {
+			select {
+			case _, ok := <-updates:
+				if !ok {
+					return
+				}
+			case <-deadline:
+				t.Fatal("expected the watch channel to close after cancellation")
+			}
+		}
+	})
+
+	t.Run("Invalid address", func(t *testing.T) {
+		r := newRegistry(t)
+		name := serviceName()
+		if err := r.Register(context.Background(), discovery.GenerateInstanceID(name), name, "localhost"); err == nil {
+			t.Error("expected an error for an address without port")
+		}
+	})
+
+	t.Run("Report health of unknown instance", func(t *testing.T) {
+		r := newRegistry(t)
+		name := serviceName()
+		if err := r.ReportHealthState(context.Background(), discovery.GenerateInstanceID(name), name); err == nil {
+			t.Error("expected an error for an unregistered instance")
+		}
+	})
+}
+
+func serviceName() string {
+	return fmt.Sprintf("discoverytest-%d", rand.Int())
+}
+
+// register registers a healthy instance and deregisters it when the test ends.
+func register(t *testing.T, r discovery.Registry, instanceID, name, addr string) {
+	t.Helper()
+	ctx := context.Background()
+	if err := r.Register(ctx, instanceID, name, addr); err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+	t.Cleanup(func() { r.Deregister(context.Background(), instanceID, name) })
+	if err := r.ReportHealthState(ctx, instanceID, name); err != nil {
+		t.Fatalf("ReportHealthState() failed: %v", err)
+	}
+}
+
+// watchTimeout bounds how long a backend may take to report a change.
+const watchTimeout = 10 * time.Second
+
+// expectUpdate reads updates until the addresses equal want. Intermediate
+// lists may be skipped by the registry or reported on the way.
+func expectUpdate(t *testing.T, updates <-chan []string, want ...string) {
+	t.Helper()
+	deadline := time.After(watchTimeout)
+	var last []string
+	for {
+		select {
+		case got, ok := <-updates:
+			if !ok {
+				t.Fatalf("watch channel closed, expected addresses %v", want)
+			}
+			last = slices.Sorted(slices.Values(got))
+			if slices.Equal(last, want) || (len(last) == 0 && len(want) == 0) {
+				return
+			}
+		case <-deadline:
+			t.Fatalf("expected addresses %v, last update was %v", want, last)
+		}
+	}
+}
+
+func expectAddresses(t *testing.T, r discovery.Registry, name string, want ...string) {
+	t.Helper()
+	got, err := r.ServiceAddresses(context.Background(), name)
+	if err != nil {
+		t.Fatalf("ServiceAddresses() failed: %v", err)
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Errorf("expected addresses %v, got %v", want, got)
+	}
+}
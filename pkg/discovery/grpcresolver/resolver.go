@@ -0,0 +1,98 @@
+// Package grpcresolver lets gRPC clients dial services by name through a
+// discovery.Registry, e.g. "discovery:///user", and balance across their
+// instances with gRPC's own load balancing.
+package grpcresolver
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/CP-Payne/taskflow/pkg/discovery"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/resolver"
+)
+
+const (
+	Scheme = "discovery"
+	// RoundRobin is a service config selecting round robin balancing.
+	RoundRobin = `{"loadBalancingConfig":[{"round_robin":{}}]}`
+)
+
+// Target returns the dial target of serviceName for this resolver.
+func Target(serviceName string) string {
+	return Scheme + ":///" + serviceName
+}
+
+// DialOptions returns the options to dial a Target: the resolver itself and
+// round robin balancing.
+func DialOptions(registry discovery.Registry) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithResolvers(NewBuilder(registry)),
+		grpc.WithDefaultServiceConfig(RoundRobin),
+	}
+}
+
+type Builder struct {
+	registry discovery.Registry
+}
+
+func NewBuilder(registry discovery.Registry) *Builder {
+	return &Builder{registry: registry}
+}
+
+func (b *Builder) Scheme() string {
+	return Scheme
+}
+
+func (b *Builder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	service := strings.TrimPrefix(target.Endpoint(), "/")
+	updates, err := b.registry.Watch(ctx, service)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	r := &registryResolver{
+		service: service,
+		cc:      cc,
+		cancel:  cancel,
+	}
+	r.wg.Add(1)
+	go r.watch(updates)
+	return r, nil
+}
+
+// registryResolver pushes the address changes the registry reports to gRPC.
+type registryResolver struct {
+	service string
+	cc      resolver.ClientConn
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// ResolveNow does nothing: the registry already reports every change.
+func (r *registryResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (r *registryResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+func (r *registryResolver) watch(updates <-chan []string) {
+	defer r.wg.Done()
+
+	for addrs := range updates {
+		if len(addrs) == 0 {
+			r.cc.ReportError(discovery.ErrNotFound)
+			continue
+		}
+
+		state := resolver.State{Addresses: make([]resolver.Address, len(addrs))}
+		for i, addr := range addrs {
+			state.Addresses[i] = resolver.Address{Addr: addr}
+		}
+		r.cc.UpdateState(state)
+	}
+}
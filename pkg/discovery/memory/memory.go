@@ -0,0 +1,145 @@
+// Package memory implements discovery.Registry inside the process, for tests
+// and for running services without Consul.
+package memory
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/CP-Payne/taskflow/pkg/discovery"
+)
+
+var ErrInstanceNotRegistered = errors.New("instance not registered")
+
+type instance struct {
+	addr         string
+	registration discovery.Registration
+}
+
+type Registry struct {
+	mu sync.RWMutex
+	// services maps service names to their instances by instance ID.
+	services map[string]map[string]instance
+	watchers map[string]map[chan []string]struct{}
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		services: make(map[string]map[string]instance),
+		watchers: make(map[string]map[chan []string]struct{}),
+	}
+}
+
+func (r *Registry) Register(ctx context.Context, instanceID, serviceName, hostPort string, opts ...discovery.RegisterOption) error {
+	if _, _, err := net.SplitHostPort(hostPort); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	instances, ok := r.services[serviceName]
+	if !ok {
+		instances = make(map[string]instance)
+		r.services[serviceName] = instances
+	}
+	instances[instanceID] = instance{addr: hostPort, registration: discovery.NewRegistration(opts...)}
+	r.notify(serviceName)
+	return nil
+}
+
+func (r *Registry) Deregister(ctx context.Context, instanceID, serviceName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.services[serviceName][instanceID]; !ok {
+		return nil
+	}
+	delete(r.services[serviceName], instanceID)
+	if len(r.services[serviceName]) == 0 {
+		delete(r.services, serviceName)
+	}
+	r.notify(serviceName)
+	return nil
+}
+
+func (r *Registry) ServiceAddresses(ctx context.Context, serviceName string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	addrs := r.addresses(serviceName)
+	if len(addrs) == 0 {
+		return nil, discovery.ErrNotFound
+	}
+	return addrs, nil
+}
+
+// Registration returns what the instance registered with, besides its address.
+func (r *Registry) Registration(instanceID, serviceName string) (discovery.Registration, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	inst, ok := r.services[serviceName][instanceID]
+	return inst.registration, ok
+}
+
+// ReportHealthState only checks that the instance is registered: instances
+// live as long as the process, so they never expire.
+func (r *Registry) ReportHealthState(ctx context.Context, instanceID, serviceName string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.services[serviceName][instanceID]; !ok {
+		return ErrInstanceNotRegistered
+	}
+	return nil
+}
+
+func (r *Registry) Watch(ctx context.Context, serviceName string) (<-chan []string, error) {
+	ch := make(chan []string, 1)
+
+	r.mu.Lock()
+	watchers, ok := r.watchers[serviceName]
+	if !ok {
+		watchers = make(map[chan []string]struct{})
+		r.watchers[serviceName] = watchers
+	}
+	watchers[ch] = struct{}{}
+	ch <- r.addresses(serviceName)
+	r.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.watchers[serviceName], ch)
+		if len(r.watchers[serviceName]) == 0 {
+			delete(r.watchers, serviceName)
+		}
+		close(ch)
+	}()
+	return ch, nil
+}
+
+// addresses returns the sorted addresses of serviceName. r.mu must be held.
+func (r *Registry) addresses(serviceName string) []string {
+	instances := r.services[serviceName]
+	res := make([]string, 0, len(instances))
+	for _, inst := range instances {
+		res = append(res, inst.addr)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// notify sends the addresses of serviceName to its watchers. r.mu must be
+// held for writing.
+func (r *Registry) notify(serviceName string) {
+	watchers := r.watchers[serviceName]
+	if len(watchers) == 0 {
+		return
+	}
+	addrs := r.addresses(serviceName)
+	for ch := range watchers {
+		discovery.SendLatest(ch, slices.Clone(addrs))
+	}
+}
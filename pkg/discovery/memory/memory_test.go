@@ -0,0 +1,37 @@
+package memory_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/CP-Payne/taskflow/pkg/discovery"
+	"github.com/CP-Payne/taskflow/pkg/discovery/discoverytest"
+	"github.com/CP-Payne/taskflow/pkg/discovery/memory"
+)
+
+func TestRegistry(t *testing.T) {
+	discoverytest.Run(t, func(t *testing.T) discovery.Registry {
+		return memory.NewRegistry()
+	})
+}
+
+func TestRegistry_Registration(t *testing.T) {
+	r := memory.NewRegistry()
+	err := r.Register(context.Background(), "user-1", "user", "localhost:9001",
+		discovery.WithTags("primary"), discovery.WithVersion("1.4.0"), discovery.WithZone("eu-west-1a"))
+	if err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+
+	reg, ok := r.Registration("user-1", "user")
+	if !ok {
+		t.Fatal("expected the instance to be registered")
+	}
+	if !slices.Equal(reg.Tags, []string{"primary"}) {
+		t.Errorf("expected tags [primary], got %v", reg.Tags)
+	}
+	if reg.Meta[discovery.MetaVersion] != "1.4.0" || reg.Meta[discovery.MetaZone] != "eu-west-1a" {
+		t.Errorf("unexpected metadata %v", reg.Meta)
+	}
+}
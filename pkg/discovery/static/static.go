@@ -0,0 +1,135 @@
+// Package static implements discovery.Registry over a fixed list of service
+// addresses, for deployments without Consul.
+package static
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/CP-Payne/taskflow/pkg/discovery"
+	"github.com/CP-Payne/taskflow/pkg/discovery/memory"
+)
+
+// Registry serves the configured addresses of each service. Instances that
+// register at runtime are added to them, so a service can still discover
+// peers started in the same process.
+type Registry struct {
+	services  map[string][]string
+	instances *memory.Registry
+}
+
+func NewRegistry(services map[string][]string) *Registry {
+	fixed := make(map[string][]string, len(services))
+	for name, addrs := range services {
+		fixed[name] = slices.Clone(addrs)
+	}
+	return &Registry{services: fixed, instances: memory.NewRegistry()}
+}
+
+// Services maps service names to their addresses. As text it has the form
+// accepted by ParseServices.
+type Services map[string][]string
+
+func (s *Services) UnmarshalText(text []byte) error {
+	services, err := ParseServices(string(text))
+	if err != nil {
+		return err
+	}
+	*s = services
+	return nil
+}
+
+func (s Services) MarshalText() ([]byte, error) {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	entries := make([]string, len(names))
+	for i, name := range names {
+		entries[i] = name + "=" + strings.Join(s[name], ",")
+	}
+	return []byte(strings.Join(entries, ";")), nil
+}
+
+// ParseServices parses service addresses in the form
+// "user=localhost:9001,localhost:9011;task=localhost:9002".
+func ParseServices(s string) (map[string][]string, error) {
+	services := make(map[string][]string)
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, list, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid service entry %q, expected <name>=<host:port>[,<host:port>]", entry)
+		}
+		for _, addr := range strings.Split(list, ",") {
+			addr = strings.TrimSpace(addr)
+			if _, _, err := net.SplitHostPort(addr); err != nil {
+				return nil, fmt.Errorf("invalid address for service %q: %w", name, err)
+			}
+			services[name] = append(services[name], addr)
+		}
+	}
+	return services, nil
+}
+
+func (r *Registry) Register(ctx context.Context, instanceID, serviceName, hostPort string, opts ...discovery.RegisterOption) error {
+	return r.instances.Register(ctx, instanceID, serviceName, hostPort, opts...)
+}
+
+func (r *Registry) Deregister(ctx context.Context, instanceID, serviceName string) error {
+	return r.instances.Deregister(ctx, instanceID, serviceName)
+}
+
+func (r *Registry) ServiceAddresses(ctx context.Context, serviceName string) ([]string, error) {
+	registered, _ := r.instances.ServiceAddresses(ctx, serviceName)
+	res := r.merge(serviceName, registered)
+	if len(res) == 0 {
+		return nil, discovery.ErrNotFound
+	}
+	return res, nil
+}
+
+func (r *Registry) ReportHealthState(ctx context.Context, instanceID, serviceName string) error {
+	return r.instances.ReportHealthState(ctx, instanceID, serviceName)
+}
+
+// Watch follows instances registered at runtime; configured addresses never
+// change.
+func (r *Registry) Watch(ctx context.Context, serviceName string) (<-chan []string, error) {
+	registered, err := r.instances.Watch(ctx, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan []string, 1)
+	go func() {
+		defer close(ch)
+		for addrs := range registered {
+			discovery.SendLatest(ch, r.merge(serviceName, addrs))
+		}
+	}()
+	return ch, nil
+}
+
+// merge returns the sorted configured addresses of serviceName together with
+// the registered ones.
+func (r *Registry) merge(serviceName string, registered []string) []string {
+	res := slices.Clone(r.services[serviceName])
+	for _, addr := range registered {
+		if !slices.Contains(res, addr) {
+			res = append(res, addr)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
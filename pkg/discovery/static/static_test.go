@@ -0,0 +1,116 @@
+package static_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/CP-Payne/taskflow/pkg/discovery"
+	"github.com/CP-Payne/taskflow/pkg/discovery/discoverytest"
+	"github.com/CP-Payne/taskflow/pkg/discovery/static"
+)
+
+func TestRegistry(t *testing.T) {
+	discoverytest.Run(t, func(t *testing.T) discovery.Registry {
+		return static.NewRegistry(map[string][]string{"user": {"localhost:9001"}})
+	})
+}
+
+func TestRegistry_ConfiguredAddresses(t *testing.T) {
+	ctx := context.Background()
+	r := static.NewRegistry(map[string][]string{"user": {"localhost:9011", "localhost:9001"}})
+
+	if err := r.Register(ctx, "user-1", "user", "localhost:9021"); err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+	got, err := r.ServiceAddresses(ctx, "user")
+	if err != nil {
+		t.Fatalf("ServiceAddresses() failed: %v", err)
+	}
+	want := []string{"localhost:9001", "localhost:9011", "localhost:9021"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Configured addresses stay after every registered instance is gone.
+	if err := r.Deregister(ctx, "user-1", "user"); err != nil {
+		t.Fatalf("Deregister() failed: %v", err)
+	}
+	got, err = r.ServiceAddresses(ctx, "user")
+	if err != nil {
+		t.Fatalf("ServiceAddresses() failed: %v", err)
+	}
+	if !slices.Equal(got, want[:2]) {
+		t.Errorf("expected %v, got %v", want[:2], got)
+	}
+}
+
+func TestParseServices(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string][]string
+		wantErr bool
+	}{
+		{
+			name:  "Empty",
+			input: "",
+			want:  map[string][]string{},
+		},
+		{
+			name:  "Several services",
+			input: "user=localhost:9001, localhost:9011; task=10.0.0.2:9002;",
+			want: map[string][]string{
+				"user": {"localhost:9001", "localhost:9011"},
+				"task": {"10.0.0.2:9002"},
+			},
+		},
+		{
+			name:    "Missing name",
+			input:   "localhost:9001",
+			wantErr: true,
+		},
+		{
+			name:    "Missing port",
+			input:   "user=localhost",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := static.ParseServices(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseServices() failed: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for name, addrs := range tt.want {
+				if !slices.Equal(got[name], addrs) {
+					t.Errorf("expected %v for %s, got %v", addrs, name, got[name])
+				}
+			}
+		})
+	}
+}
+
+func TestServices_Text(t *testing.T) {
+	var services static.Services
+	if err := services.UnmarshalText([]byte("user=localhost:9011,localhost:9001;task=localhost:9002")); err != nil {
+		t.Fatalf("UnmarshalText() failed: %v", err)
+	}
+	text, err := services.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText() failed: %v", err)
+	}
+	if want := "task=localhost:9002;user=localhost:9011,localhost:9001"; string(text) != want {
+		t.Errorf("expected %q, got %q", want, text)
+	}
+}
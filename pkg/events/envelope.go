@@ -0,0 +1,124 @@
+package events
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotEnvelope is returned when a message does not carry an envelope,
+// for example a bare JSON event published by an older producer.
+var ErrNotEnvelope = errors.New("message is not an event envelope")
+
+// TraceContext carries W3C trace context across the event bus.
+type TraceContext struct {
+	TraceParent string `json:"traceparent,omitempty"`
+	TraceState  string `json:"tracestate,omitempty"`
+}
+
+// Envelope wraps every event published on the bus. The payload is encoded
+// separately according to ContentType so that subscribers can inspect the
+// metadata without knowing the payload schema. An empty ContentType means
+// JSON.
+type Envelope struct {
+	ID            string       `json:"id"`
+	Type          string       `json:"type"`
+	SchemaVersion int          `json:"schemaVersion"`
+	OccurredAt    time.Time    `json:"occurredAt"`
+	Producer      string       `json:"producer"`
+	Trace         TraceContext `json:"trace"`
+	ContentType   string       `json:"contentType"`
+	Payload       []byte       `json:"-"`
+}
+
+type envelopeJSON struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	SchemaVersion int             `json:"schemaVersion"`
+	OccurredAt    time.Time       `json:"occurredAt"`
+	Producer      string          `json:"producer"`
+	Trace         TraceContext    `json:"trace"`
+	ContentType   string          `json:"contentType"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// NewEnvelope creates an envelope with a fresh ID and the current time.
+func NewEnvelope(eventType string, version int, producer string) *Envelope {
+	return &Envelope{
+		ID:            uuid.NewString(),
+		Type:          eventType,
+		SchemaVersion: version,
+		OccurredAt:    time.Now().UTC(),
+		Producer:      producer,
+	}
+}
+
+// MarshalJSON embeds JSON payloads as-is and base64 encodes binary ones.
+func (e Envelope) MarshalJSON() ([]byte, error) {
+	if e.ContentType == "" {
+		e.ContentType = ContentTypeJSON
+	}
+	out := envelopeJSON{
+		ID:            e.ID,
+		Type:          e.Type,
+		SchemaVersion: e.SchemaVersion,
+		OccurredAt:    e.OccurredAt,
+		Producer:      e.Producer,
+		Trace:         e.Trace,
+		ContentType:   e.ContentType,
+	}
+
+	if e.ContentType == ContentTypeJSON {
+		out.Payload = json.RawMessage(e.Payload)
+	} else {
+		encoded, err := json.Marshal(base64.StdEncoding.EncodeToString(e.Payload))
+		if err != nil {
+			return nil, err
+		}
+		out.Payload = encoded
+	}
+
+	return json.Marshal(out)
+}
+
+func (e *Envelope) UnmarshalJSON(data []byte) error {
+	var in envelopeJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if in.Type == "" || in.ID == "" {
+		return ErrNotEnvelope
+	}
+
+	*e = Envelope{
+		ID:            in.ID,
+		Type:          in.Type,
+		SchemaVersion: in.SchemaVersion,
+		OccurredAt:    in.OccurredAt,
+		Producer:      in.Producer,
+		Trace:         in.Trace,
+		ContentType:   in.ContentType,
+	}
+	if e.ContentType == "" {
+		e.ContentType = ContentTypeJSON
+	}
+
+	if e.ContentType == ContentTypeJSON {
+		e.Payload = []byte(in.Payload)
+		return nil
+	}
+
+	var encoded string
+	if err := json.Unmarshal(in.Payload, &encoded); err != nil {
+		return err
+	}
+	payload, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return err
+	}
+	e.Payload = payload
+	return nil
+}
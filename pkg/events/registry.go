@@ -0,0 +1,174 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrUnknownEventType    = errors.New("unknown event type")
+	ErrIncompatibleVersion = errors.New("incompatible event schema version")
+	ErrDuplicateEventType  = errors.New("event type already registered")
+)
+
+// Event is implemented by every payload that can be published on the bus.
+type Event interface {
+	EventType() string
+}
+
+// Schema describes one event type known to a registry.
+//
+// Versions only ever grow by adding optional fields, so a subscriber accepts
+// any version from MinVersion upwards. A breaking change needs a new event type.
+type Schema struct {
+	Type string
+	// Version is the schema version written by this build.
+	Version int
+	// MinVersion is the oldest version this build can still read.
+	MinVersion int
+	// Codec defaults to JSONCodec when nil.
+	Codec Codec
+	// New returns a pointer to an empty payload to decode into.
+	New func() any
+}
+
+type Registry struct {
+	mu      sync.RWMutex
+	schemas map[string]Schema
+}
+
+// DefaultRegistry holds the event types shared by all taskflow services.
+var DefaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{
+		schemas: make(map[string]Schema),
+	}
+}
+
+func (r *Registry) Register(s Schema) error {
+	if s.Type == "" || s.New == nil {
+		return errors.New("schema requires a type and a payload factory")
+	}
+	if s.Version < 1 || s.MinVersion < 0 || s.MinVersion > s.Version {
+		return fmt.Errorf("schema %s: invalid version range [%d, %d]", s.Type, s.MinVersion, s.Version)
+	}
+	if s.Codec == nil {
+		s.Codec = JSONCodec{}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.schemas[s.Type]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateEventType, s.Type)
+	}
+	r.schemas[s.Type] = s
+	return nil
+}
+
+func (r *Registry) MustRegister(s Schema) {
+	if err := r.Register(s); err != nil {
+		panic(err)
+	}
+}
+
+func (r *Registry) Schema(eventType string) (Schema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.schemas[eventType]
+	return s, ok
+}
+
+// CheckCompatibility reports whether this build can decode the envelope.
+func (r *Registry) CheckCompatibility(env *Envelope) error {
+	s, ok := r.Schema(env.Type)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownEventType, env.Type)
+	}
+	if env.SchemaVersion < s.MinVersion {
+		return fmt.Errorf("%w: %s v%d, oldest supported is v%d", ErrIncompatibleVersion, env.Type, env.SchemaVersion, s.MinVersion)
+	}
+	return nil
+}
+
+type EncodeOption func(*Envelope)
+
+func WithProducer(producer string) EncodeOption {
+	return func(e *Envelope) {
+		e.Producer = producer
+	}
+}
+
+func WithTrace(trace TraceContext) EncodeOption {
+	return func(e *Envelope) {
+		e.Trace = trace
+	}
+}
+
+// Encode wraps the event in an envelope and returns the wire representation.
+func (r *Registry) Encode(event Event, opts ...EncodeOption) ([]byte, error) {
+	env, err := r.Wrap(event, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(env)
+}
+
+// Wrap builds the envelope for an event without serializing it.
+func (r *Registry) Wrap(event Event, opts ...EncodeOption) (*Envelope, error) {
+	s, ok := r.Schema(event.EventType())
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, event.EventType())
+	}
+
+	payload, err := s.Codec.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s payload: %w", s.Type, err)
+	}
+
+	env := NewEnvelope(s.Type, s.Version, "")
+	env.ContentType = s.Codec.ContentType()
+	if env.ContentType == "" {
+		env.ContentType = ContentTypeJSON
+	}
+	env.Payload = payload
+	for _, opt := range opts {
+		opt(env)
+	}
+	return env, nil
+}
+
+// Decode parses an envelope and its payload. ErrNotEnvelope is returned for
+// messages that predate the envelope format.
+func (r *Registry) Decode(data []byte) (*Envelope, any, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, nil, err
+	}
+
+	payload, err := r.DecodePayload(&env)
+	if err != nil {
+		return &env, nil, err
+	}
+	return &env, payload, nil
+}
+
+func (r *Registry) DecodePayload(env *Envelope) (any, error) {
+	if err := r.CheckCompatibility(env); err != nil {
+		return nil, err
+	}
+	s, _ := r.Schema(env.Type)
+
+	codec, err := codecFor(env.ContentType)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := s.New()
+	if err := codec.Unmarshal(env.Payload, payload); err != nil {
+		return nil, fmt.Errorf("decode %s payload: %w", env.Type, err)
+	}
+	return payload, nil
+}
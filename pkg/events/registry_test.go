@@ -0,0 +1,159 @@
+package events_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/CP-Payne/taskflow/pkg/events"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type protoEvent struct {
+	*wrapperspb.StringValue
+}
+
+func (protoEvent) EventType() string { return "test.proto" }
+
+func newTestRegistry(t *testing.T) *events.Registry {
+	t.Helper()
+	r := events.NewRegistry()
+	r.MustRegister(events.Schema{
+		Type:       events.TypeTaskAssigned,
+		Version:    2,
+		MinVersion: 2,
+		New:        func() any { return &events.TaskAssignedEvent{} },
+	})
+	r.MustRegister(events.Schema{
+		Type:       "test.proto",
+		Version:    1,
+		MinVersion: 1,
+		Codec:      events.ProtoCodec{},
+		New:        func() any { return &wrapperspb.StringValue{} },
+	})
+	return r
+}
+
+func TestRegistry_RoundTrip(t *testing.T) {
+	r := newTestRegistry(t)
+
+	t.Run("JSON payload", func(t *testing.T) {
+		in := &events.TaskAssignedEvent{TaskID: "task-1", UserID: "user-1"}
+		data, err := r.Encode(in, events.WithProducer("test"), events.WithTrace(events.TraceContext{TraceParent: "00-abc-def-01"}))
+		if err != nil {
+			t.Fatalf("Encode() failed: %v", err)
+		}
+
+		env, payload, err := r.Decode(data)
+		if err != nil {
+			t.Fatalf("Decode() failed: %v", err)
+		}
+		if env.Type != events.TypeTaskAssigned || env.SchemaVersion != 2 || env.Producer != "test" || env.ID == "" {
+			t.Errorf("unexpected envelope: %+v", env)
+		}
+		if env.Trace.TraceParent != "00-abc-def-01" {
+			t.Errorf("trace context lost: %+v", env.Trace)
+		}
+		out, ok := payload.(*events.TaskAssignedEvent)
+		if !ok || *out != *in {
+			t.Errorf("payload mismatch: got %+v, want %+v", payload, in)
+		}
+	})
+
+	t.Run("JSON payload without content type", func(t *testing.T) {
+		env := events.NewEnvelope(events.TypeTaskAssigned, 2, "test")
+		env.Payload = []byte(`{"taskId":"task-1","userId":"user-1"}`)
+		data, err := json.Marshal(env)
+		if err != nil {
+			t.Fatalf("marshal envelope: %v", err)
+		}
+
+		var wire struct {
+			ContentType string          `json:"contentType"`
+			Payload     json.RawMessage `json:"payload"`
+		}
+		if err := json.Unmarshal(data, &wire); err != nil {
+			t.Fatalf("unmarshal wire format: %v", err)
+		}
+		if wire.ContentType != events.ContentTypeJSON || string(wire.Payload) != string(env.Payload) {
+			t.Errorf("expected the payload embedded as JSON, got %s", data)
+		}
+
+		_, payload, err := r.Decode(data)
+		if err != nil {
+			t.Fatalf("Decode() failed: %v", err)
+		}
+		if out := payload.(*events.TaskAssignedEvent); out.TaskID != "task-1" {
+			t.Errorf("unexpected payload %+v", out)
+		}
+	})
+
+	t.Run("protobuf payload", func(t *testing.T) {
+		in := protoEvent{wrapperspb.String("hello")}
+		data, err := r.Encode(in)
+		if err != nil {
+			t.Fatalf("Encode() failed: %v", err)
+		}
+
+		env, payload, err := r.Decode(data)
+		if err != nil {
+			t.Fatalf("Decode() failed: %v", err)
+		}
+		if env.ContentType != events.ContentTypeProtobuf {
+			t.Errorf("expected protobuf content type, got %q", env.ContentType)
+		}
+		if !proto.Equal(payload.(*wrapperspb.StringValue), in.StringValue) {
+			t.Errorf("payload mismatch: got %v, want %v", payload, in.StringValue)
+		}
+	})
+}
+
+func TestRegistry_Decode(t *testing.T) {
+	envelope := func(eventType string, version int) []byte {
+		env := events.NewEnvelope(eventType, version, "test")
+		env.ContentType = events.ContentTypeJSON
+		env.Payload = []byte(`{"taskId":"task-1","userId":"user-1","addedLater":true}`)
+		data, err := json.Marshal(env)
+		if err != nil {
+			t.Fatalf("marshal envelope: %v", err)
+		}
+		return data
+	}
+
+	tests := []struct {
+		name      string
+		data      []byte
+		expectErr error
+	}{
+		{
+			name: "Newer schema version is accepted",
+			data: envelope(events.TypeTaskAssigned, 3),
+		},
+		{
+			name:      "Older schema version is rejected",
+			data:      envelope(events.TypeTaskAssigned, 1),
+			expectErr: events.ErrIncompatibleVersion,
+		},
+		{
+			name:      "Unknown event type is rejected",
+			data:      envelope("task.archived", 1),
+			expectErr: events.ErrUnknownEventType,
+		},
+		{
+			name:      "Bare legacy event is reported as not enveloped",
+			data:      []byte(`{"taskId":"task-1","userId":"user-1"}`),
+			expectErr: events.ErrNotEnvelope,
+		},
+	}
+
+	r := newTestRegistry(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := r.Decode(tt.data)
+			if !errors.Is(err, tt.expectErr) {
+				t.Errorf("expected error %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
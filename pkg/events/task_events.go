@@ -1,14 +1,85 @@
 package events
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// ChannelTaskAssigned carries enveloped assignment events. It replaces
+	// ChannelTaskAssignedLegacy, whose subscribers expect a bare
+	// TaskAssignedEvent.
+	ChannelTaskAssigned = "events:task:assigned:v2"
+	ChannelTaskCreated  = "events:task:created"
+	ChannelTaskUpdated  = "events:task:updated"
+	ChannelTaskDeleted  = "events:task:deleted"
+)
+
+// ChannelTaskAssignedLegacy carries assignment events as bare JSON, without
+// an envelope. The task service publishes them there as well until no
+// subscriber reads the channel any more.
+const ChannelTaskAssignedLegacy = "events:task:assigned"
 
 const (
-	ChannelTaskAssigned = "events:task:assigned"
+	TypeTaskAssigned = "task.assigned"
+	TypeTaskCreated  = "task.created"
+	TypeTaskUpdated  = "task.updated"
+	TypeTaskDeleted  = "task.deleted"
 )
 
+func init() {
+	// v2 added the task snapshot; v1 events still decode with Task left nil.
+	DefaultRegistry.MustRegister(Schema{
+		Type:       TypeTaskAssigned,
+		Version:    2,
+		MinVersion: 1,
+		New:        func() any { return &TaskAssignedEvent{} },
+	})
+	DefaultRegistry.MustRegister(Schema{
+		Type:       TypeTaskCreated,
+		Version:    1,
+		MinVersion: 1,
+		New:        func() any { return &TaskCreatedEvent{} },
+	})
+	DefaultRegistry.MustRegister(Schema{
+		Type:       TypeTaskUpdated,
+		Version:    1,
+		MinVersion: 1,
+		New:        func() any { return &TaskUpdatedEvent{} },
+	})
+	DefaultRegistry.MustRegister(Schema{
+		Type:       TypeTaskDeleted,
+		Version:    1,
+		MinVersion: 1,
+		New:        func() any { return &TaskDeletedEvent{} },
+	})
+}
+
+// TaskSnapshot is the state of a task at the time an event was published,
+// so that consumers don't need to call back into the task service.
+type TaskSnapshot struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	CreatedBy   string     `json:"createdBy"`
+	AssignedTo  string     `json:"assignedTo,omitempty"`
+	WorkspaceID string     `json:"workspaceId,omitempty"`
+	Status      string     `json:"status"`
+	Priority    string     `json:"priority"`
+	DueDate     *time.Time `json:"dueDate,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
 type TaskAssignedEvent struct {
 	TaskID string `json:"taskId"`
 	UserID string `json:"userId"`
+	// Task is nil for events published before schema v2.
+	Task *TaskSnapshot `json:"task,omitempty"`
+}
+
+func (e *TaskAssignedEvent) EventType() string {
+	return TypeTaskAssigned
 }
 
 // Marshal encodes the event into JSON bytes.
@@ -25,3 +96,28 @@ func UnmarshalTaskAssignedEvent(data []byte) (*TaskAssignedEvent, error) {
 	}
 	return &event, nil
 }
+
+type TaskCreatedEvent struct {
+	Task TaskSnapshot `json:"task"`
+}
+
+func (e *TaskCreatedEvent) EventType() string {
+	return TypeTaskCreated
+}
+
+type TaskUpdatedEvent struct {
+	Task TaskSnapshot `json:"task"`
+}
+
+func (e *TaskUpdatedEvent) EventType() string {
+	return TypeTaskUpdated
+}
+
+// TaskDeletedEvent carries the task as it was before deletion.
+type TaskDeletedEvent struct {
+	Task TaskSnapshot `json:"task"`
+}
+
+func (e *TaskDeletedEvent) EventType() string {
+	return TypeTaskDeleted
+}
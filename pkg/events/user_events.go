@@ -0,0 +1,54 @@
+package events
+
+import "time"
+
+const (
+	// ChannelUserUpdated carries changes to user accounts.
+	ChannelUserUpdated = "events:user:updated"
+	// ChannelUserSecurity carries security events about user accounts.
+	ChannelUserSecurity = "events:user:security"
+)
+
+const (
+	TypeUserUpdated   = "user.updated"
+	TypeUserLockedOut = "user.locked_out"
+)
+
+func init() {
+	DefaultRegistry.MustRegister(Schema{
+		Type:       TypeUserUpdated,
+		Version:    1,
+		MinVersion: 1,
+		New:        func() any { return &UserUpdatedEvent{} },
+	})
+	DefaultRegistry.MustRegister(Schema{
+		Type:       TypeUserLockedOut,
+		Version:    1,
+		MinVersion: 1,
+		New:        func() any { return &UserLockedOutEvent{} },
+	})
+}
+
+// UserUpdatedEvent signals that a user's profile changed, so consumers
+// caching user details should drop them.
+type UserUpdatedEvent struct {
+	UserID string `json:"userId"`
+}
+
+func (e *UserUpdatedEvent) EventType() string {
+	return TypeUserUpdated
+}
+
+// UserLockedOutEvent signals that logins to an account are refused until
+// LockedUntil after too many failed attempts.
+type UserLockedOutEvent struct {
+	UserID      string    `json:"userId"`
+	LockedUntil time.Time `json:"lockedUntil"`
+	// IP is the client address of the last failed attempt, if known.
+	IP       string `json:"ip,omitempty"`
+	Failures int    `json:"failures"`
+}
+
+func (e *UserLockedOutEvent) EventType() string {
+	return TypeUserLockedOut
+}
@@ -0,0 +1,167 @@
+// Package grpcauth provides the server interceptor that authenticates
+// callers by their bearer token, either a session JWT or a personal access
+// token, and enforces the scopes of the methods they call.
+package grpcauth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Scopes a personal access token can be limited to.
+const (
+	ScopeTasksRead  = "tasks:read"
+	ScopeTasksWrite = "tasks:write"
+)
+
+// Scopes lists every scope, in the order they are documented.
+var Scopes = []string{ScopeTasksRead, ScopeTasksWrite}
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Principal is an authenticated caller.
+type Principal struct {
+	UserID uuid.UUID
+	// TokenID identifies the personal access token used, and is empty for
+	// session JWTs.
+	TokenID string
+	// Scopes limit a personal access token. Session JWTs are not limited.
+	Scopes []string
+}
+
+// Session reports whether the caller used a session JWT rather than a
+// personal access token.
+func (p *Principal) Session() bool {
+	return p.TokenID == ""
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return p.Session() || slices.Contains(p.Scopes, scope)
+}
+
+// Verifier authenticates bearer tokens. It returns an error wrapping
+// ErrInvalidToken for tokens it refuses.
+type Verifier interface {
+	Verify(ctx context.Context, token string) (*Principal, error)
+}
+
+// Rule protects one method.
+type Rule struct {
+	// Scope is required of personal access tokens. Empty admits any.
+	Scope string
+	// SessionOnly refuses personal access tokens, for methods such as
+	// creating tokens that only the user should call.
+	SessionOnly bool
+}
+
+type principalKey struct{}
+
+// FromContext returns the caller authenticated by the interceptor.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// NewContext returns a copy of ctx carrying p.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// Interceptor authenticates calls to the methods it has rules for, keyed by
+// full method name, e.g. "/task.v1.TaskService/Create". Other methods, such
+// as health checks, are called as they are.
+type Interceptor struct {
+	verifier Verifier
+	rules    map[string]Rule
+	logger   *zap.SugaredLogger
+}
+
+func New(verifier Verifier, rules map[string]Rule, logger *zap.SugaredLogger) *Interceptor {
+	return &Interceptor{verifier: verifier, rules: rules, logger: logger}
+}
+
+// ServerOptions installs the unary and stream interceptors.
+func (i *Interceptor) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(i.Unary()),
+		grpc.ChainStreamInterceptor(i.Stream()),
+	}
+}
+
+func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := i.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (i *Interceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := i.authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func (i *Interceptor) authenticate(ctx context.Context, method string) (context.Context, error) {
+	rule, ok := i.rules[method]
+	if !ok {
+		return ctx, nil
+	}
+
+	token, ok := bearerToken(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	principal, err := i.verifier.Verify(ctx, token)
+	if errors.Is(err, ErrInvalidToken) {
+		i.logger.Warnw("Rejected invalid token", "method", method, "error", err)
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	} else if err != nil {
+		i.logger.Errorw("Failed to verify token", "method", method, "error", err)
+		return nil, status.Error(codes.Unavailable, "cannot verify token")
+	}
+
+	switch {
+	case rule.SessionOnly && !principal.Session():
+		return nil, status.Error(codes.PermissionDenied, "personal access tokens cannot call this method")
+	case rule.Scope != "" && !principal.HasScope(rule.Scope):
+		return nil, status.Errorf(codes.PermissionDenied, "token lacks the %s scope", rule.Scope)
+	}
+	return NewContext(ctx, principal), nil
+}
+
+// serverStream carries the authenticated context to stream handlers.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func bearerToken(ctx context.Context) (string, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		scheme, token, ok := strings.Cut(value, " ")
+		if ok && strings.EqualFold(scheme, "bearer") && strings.TrimSpace(token) != "" {
+			return strings.TrimSpace(token), true
+		}
+	}
+	return "", false
+}
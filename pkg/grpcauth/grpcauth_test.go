@@ -0,0 +1,146 @@
+package grpcauth_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/CP-Payne/taskflow/pkg/grpcauth"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeVerifier knows a fixed set of tokens.
+type fakeVerifier struct {
+	principals map[string]*grpcauth.Principal
+	err        error
+}
+
+func (v *fakeVerifier) Verify(ctx context.Context, token string) (*grpcauth.Principal, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+	p, ok := v.principals[token]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown", grpcauth.ErrInvalidToken)
+	}
+	return p, nil
+}
+
+const (
+	readMethod    = "/task.v1.TaskService/List"
+	writeMethod   = "/task.v1.TaskService/Create"
+	sessionMethod = "/user.v1.User/CreateAccessToken"
+	openMethod    = "/grpc.health.v1.Health/Check"
+)
+
+func TestInterceptor_Unary(t *testing.T) {
+	userID := uuid.New()
+	verifier := &fakeVerifier{principals: map[string]*grpcauth.Principal{
+		"session": {UserID: userID},
+		"reader":  {UserID: userID, TokenID: "r", Scopes: []string{grpcauth.ScopeTasksRead}},
+	}}
+	interceptor := grpcauth.New(verifier, map[string]grpcauth.Rule{
+		readMethod:    {Scope: grpcauth.ScopeTasksRead},
+		writeMethod:   {Scope: grpcauth.ScopeTasksWrite},
+		sessionMethod: {SessionOnly: true},
+	}, zap.NewNop().Sugar())
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		wantCode      codes.Code
+		wantPrincipal bool
+	}{
+		{name: "unprotected method", method: openMethod, wantCode: codes.OK},
+		{name: "missing token", method: readMethod, wantCode: codes.Unauthenticated},
+		{name: "wrong scheme", method: readMethod, authorization: "Basic session", wantCode: codes.Unauthenticated},
+		{name: "invalid token", method: readMethod, authorization: "Bearer nope", wantCode: codes.Unauthenticated},
+		{name: "session reads", method: readMethod, authorization: "Bearer session", wantCode: codes.OK, wantPrincipal: true},
+		{name: "session writes", method: writeMethod, authorization: "bearer session", wantCode: codes.OK, wantPrincipal: true},
+		{name: "token with scope", method: readMethod, authorization: "Bearer reader", wantCode: codes.OK, wantPrincipal: true},
+		{name: "token without scope", method: writeMethod, authorization: "Bearer reader", wantCode: codes.PermissionDenied},
+		{name: "token on session-only method", method: sessionMethod, authorization: "Bearer reader", wantCode: codes.PermissionDenied},
+		{name: "session on session-only method", method: sessionMethod, authorization: "Bearer session", wantCode: codes.OK, wantPrincipal: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.authorization != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.authorization))
+			}
+
+			var gotPrincipal bool
+			handler := func(ctx context.Context, req any) (any, error) {
+				p, ok := grpcauth.FromContext(ctx)
+				gotPrincipal = ok && p.UserID == userID
+				return "ok", nil
+			}
+			_, err := interceptor.Unary()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("expected code %v, got %v (%v)", tt.wantCode, got, err)
+			}
+			if gotPrincipal != tt.wantPrincipal {
+				t.Errorf("expected principal in context %v, got %v", tt.wantPrincipal, gotPrincipal)
+			}
+		})
+	}
+}
+
+func TestInterceptor_VerifierUnavailable(t *testing.T) {
+	verifier := &fakeVerifier{err: errors.New("connection refused")}
+	interceptor := grpcauth.New(verifier, map[string]grpcauth.Rule{readMethod: {}}, zap.NewNop().Sugar())
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer session"))
+	_, err := interceptor.Unary()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: readMethod}, func(ctx context.Context, req any) (any, error) {
+		t.Fatal("handler called without a verified token")
+		return nil, nil
+	})
+	if got := status.Code(err); got != codes.Unavailable {
+		t.Errorf("expected Unavailable, got %v", got)
+	}
+}
+
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestInterceptor_Stream(t *testing.T) {
+	verifier := &fakeVerifier{principals: map[string]*grpcauth.Principal{
+		"writer": {UserID: uuid.New(), TokenID: "w", Scopes: []string{grpcauth.ScopeTasksWrite}},
+		"reader": {UserID: uuid.New(), TokenID: "r", Scopes: []string{grpcauth.ScopeTasksRead}},
+	}}
+	interceptor := grpcauth.New(verifier, map[string]grpcauth.Rule{
+		readMethod: {Scope: grpcauth.ScopeTasksRead},
+	}, zap.NewNop().Sugar())
+
+	call := func(token string) (bool, error) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+		var authenticated bool
+		err := interceptor.Stream()(nil, &fakeStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: readMethod}, func(srv any, ss grpc.ServerStream) error {
+			_, authenticated = grpcauth.FromContext(ss.Context())
+			return nil
+		})
+		return authenticated, err
+	}
+
+	if authenticated, err := call("reader"); err != nil || !authenticated {
+		t.Errorf("expected the reader to be authenticated, got %v, %v", authenticated, err)
+	}
+	if _, err := call("writer"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied for the writer, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package grpcclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/serviceconfig"
+	"google.golang.org/grpc/status"
+)
+
+// BalancerName is the load balancing policy that spreads calls round robin
+// over the ready backends, skipping those whose breaker is open.
+const BalancerName = "taskflow_breaker_round_robin"
+
+func init() {
+	balancer.Register(breakerBalancerBuilder{})
+}
+
+// serviceConfig selects the breaker balancer with the given settings.
+func serviceConfig(cfg BreakerConfig) string {
+	return fmt.Sprintf(`{"loadBalancingConfig":[{%q:{"failureThreshold":%d,"openTimeout":%q}}]}`,
+		BalancerName, cfg.FailureThreshold, cfg.OpenTimeout.String())
+}
+
+type lbConfig struct {
+	serviceconfig.LoadBalancingConfig
+	breaker BreakerConfig
+}
+
+type breakerBalancerBuilder struct{}
+
+func (breakerBalancerBuilder) Name() string {
+	return BalancerName
+}
+
+func (breakerBalancerBuilder) ParseConfig(raw json.RawMessage) (serviceconfig.LoadBalancingConfig, error) {
+	var parsed struct {
+		FailureThreshold int    `json:"failureThreshold"`
+		OpenTimeout      string `json:"openTimeout"`
+	}
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %w", BalancerName, err)
+	}
+	cfg := &lbConfig{breaker: BreakerConfig{FailureThreshold: parsed.FailureThreshold}}
+	if parsed.OpenTimeout != "" {
+		timeout, err := time.ParseDuration(parsed.OpenTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s openTimeout: %w", BalancerName, err)
+		}
+		cfg.breaker.OpenTimeout = timeout
+	}
+	return cfg, nil
+}
+
+// Build returns the base round robin balancer with a picker builder of its
+// own, so breakers are kept per client connection and backend address and
+// survive the picker being rebuilt when backends come and go.
+func (breakerBalancerBuilder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	pb := &breakerPickerBuilder{breakers: make(map[string]*Breaker)}
+	return &breakerBalancer{
+		Balancer: base.NewBalancerBuilder(BalancerName, pb, base.Config{HealthCheck: true}).Build(cc, opts),
+		pb:       pb,
+	}
+}
+
+type breakerBalancer struct {
+	balancer.Balancer
+	pb *breakerPickerBuilder
+}
+
+func (b *breakerBalancer) UpdateClientConnState(state balancer.ClientConnState) error {
+	if cfg, ok := state.BalancerConfig.(*lbConfig); ok {
+		b.pb.setConfig(cfg.breaker)
+	}
+	return b.Balancer.UpdateClientConnState(state)
+}
+
+func (b *breakerBalancer) ExitIdle() {
+	if ei, ok := b.Balancer.(balancer.ExitIdler); ok {
+		ei.ExitIdle()
+	}
+}
+
+type breakerPickerBuilder struct {
+	mu       sync.Mutex
+	cfg      BreakerConfig
+	breakers map[string]*Breaker
+}
+
+func (pb *breakerPickerBuilder) setConfig(cfg BreakerConfig) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	if cfg != pb.cfg {
+		pb.cfg = cfg
+		// Breakers made with the old settings are replaced
+		pb.breakers = make(map[string]*Breaker)
+	}
+}
+
+func (pb *breakerPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	backends := make([]backend, 0, len(info.ReadySCs))
+	for sc, sci := range info.ReadySCs {
+		addr := sci.Address.Addr
+		b, ok := pb.breakers[addr]
+		if !ok {
+			b = NewBreaker(pb.cfg)
+			pb.breakers[addr] = b
+		}
+		backends = append(backends, backend{subConn: sc, breaker: b})
+	}
+	p := &breakerPicker{backends: backends}
+	if len(backends) > 0 {
+		p.next.Store(uint32(rand.N(len(backends))))
+	}
+	return p
+}
+
+type backend struct {
+	subConn balancer.SubConn
+	breaker *Breaker
+}
+
+// breakerPicker picks the next backend whose breaker allows the call, and
+// fails the call with ErrCircuitOpen when none does.
+type breakerPicker struct {
+	backends []backend
+	next     atomic.Uint32
+}
+
+func (p *breakerPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	n := uint32(len(p.backends))
+	if n == 0 {
+		return balancer.PickResult{}, balancer.ErrNoSubConnAvailable
+	}
+	start := p.next.Add(1)
+	for i := uint32(0); i < n; i++ {
+		b := p.backends[(start+i)%n]
+		if !b.breaker.Allow() {
+			continue
+		}
+		return balancer.PickResult{
+			SubConn: b.subConn,
+			Done: func(info balancer.DoneInfo) {
+				b.breaker.Record(!isFailure(status.Code(info.Err)))
+			},
+		}, nil
+	}
+	return balancer.PickResult{}, ErrCircuitOpen
+}
@@ -0,0 +1,90 @@
+package grpcclient
+
+import (
+	"sync"
+	"time"
+)
+
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// breaker. Zero disables circuit breaking.
+	FailureThreshold int
+	// OpenTimeout is how long an open breaker rejects calls before letting a
+	// single trial call through.
+	OpenTimeout time.Duration
+}
+
+type breakerState int
+
+const (
+	stateClosed breakerState = iota
+	stateOpen
+	stateHalfOpen
+)
+
+// Breaker is a consecutive-failure circuit breaker.
+type Breaker struct {
+	cfg      BreakerConfig
+	now      func() time.Time
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	// trial is set while the single half-open call is in flight.
+	trial bool
+}
+
+func NewBreaker(cfg BreakerConfig) *Breaker {
+	return &Breaker{cfg: cfg, now: time.Now}
+}
+
+// Allow reports whether a call may proceed. Every allowed call must be
+// followed by Record.
+func (b *Breaker) Allow() bool {
+	if b.cfg.FailureThreshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if b.now().Sub(b.openedAt) < b.cfg.OpenTimeout {
+			return false
+		}
+		b.state = stateHalfOpen
+		b.trial = true
+		return true
+	case stateHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Record reports the outcome of an allowed call.
+func (b *Breaker) Record(success bool) {
+	if b.cfg.FailureThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		b.state = stateClosed
+		b.failures = 0
+		b.trial = false
+		return
+	}
+
+	b.failures++
+	if b.state == stateHalfOpen || b.failures >= b.cfg.FailureThreshold {
+		b.state = stateOpen
+		b.openedAt = b.now()
+		b.trial = false
+	}
+}
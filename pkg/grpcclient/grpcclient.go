@@ -0,0 +1,164 @@
+// Package grpcclient provides the interceptor and load balancer every
+// internal gRPC client uses: per-method deadlines, retries of idempotent
+// calls with jittered backoff, and a circuit breaker per backend address.
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrCircuitOpen is the status returned for calls rejected by an open breaker.
+var ErrCircuitOpen = status.Error(codes.Unavailable, "circuit breaker open")
+
+// MethodPolicy configures calls to one full method name, e.g.
+// "/user.v1.User/GetByID".
+type MethodPolicy struct {
+	// Timeout bounds the whole call, retries included. A shorter deadline on
+	// the caller's context wins. Zero uses Config.DefaultTimeout.
+	Timeout time.Duration
+	// Idempotent calls are retried.
+	Idempotent bool
+}
+
+type RetryPolicy struct {
+	// MaxAttempts includes the first attempt.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// RetryableCodes are the status codes worth another attempt.
+	RetryableCodes []codes.Code
+}
+
+// Backoff returns a random delay between zero and the exponential backoff
+// for the given retry, starting at 1.
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	backoff := p.InitialBackoff << (retry - 1)
+	if backoff <= 0 || backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return rand.N(backoff)
+}
+
+func (p RetryPolicy) retryable(code codes.Code) bool {
+	for _, c := range p.RetryableCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+type Config struct {
+	// DefaultTimeout applies to methods without a policy timeout. Zero means
+	// no deadline beyond the caller's.
+	DefaultTimeout time.Duration
+	Methods        map[string]MethodPolicy
+	Retry          RetryPolicy
+	Breaker        BreakerConfig
+}
+
+// DefaultConfig returns the settings used by internal clients unless a
+// service overrides them.
+func DefaultConfig() Config {
+	return Config{
+		DefaultTimeout: 5 * time.Second,
+		Methods:        map[string]MethodPolicy{},
+		Retry: RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: 50 * time.Millisecond,
+			MaxBackoff:     time.Second,
+			RetryableCodes: []codes.Code{codes.Unavailable, codes.ResourceExhausted, codes.Aborted},
+		},
+		Breaker: BreakerConfig{
+			FailureThreshold: 5,
+			OpenTimeout:      10 * time.Second,
+		},
+	}
+}
+
+// DialOptions returns the dial options installing the interceptor and the
+// breaker balancer. They replace the load balancing policy of options given
+// before them, such as grpcresolver.DialOptions.
+func DialOptions(cfg Config) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(UnaryClientInterceptor(cfg)),
+		grpc.WithDefaultServiceConfig(serviceConfig(cfg.Breaker)),
+	}
+}
+
+// UnaryClientInterceptor applies the deadlines and retries of cfg to unary
+// calls. Breakers are kept by the balancer, which skips backends whose
+// breaker is open and fails calls with ErrCircuitOpen when all are.
+func UnaryClientInterceptor(cfg Config) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		policy := cfg.Methods[method]
+		timeout := policy.Timeout
+		if timeout == 0 {
+			timeout = cfg.DefaultTimeout
+		}
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		attempts := 1
+		if policy.Idempotent && cfg.Retry.MaxAttempts > 1 {
+			attempts = cfg.Retry.MaxAttempts
+		}
+
+		var err error
+		for attempt := 1; attempt <= attempts; attempt++ {
+			if attempt > 1 {
+				timer := time.NewTimer(cfg.Retry.Backoff(attempt - 1))
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return err
+				case <-timer.C:
+				}
+			}
+
+			prev := err
+			err = invoker(ctx, method, req, reply, cc, opts...)
+			if IsCircuitOpen(err) {
+				// Every backend is failing; the error of the previous
+				// attempt says more than the open breakers
+				if prev != nil {
+					return prev
+				}
+				return err
+			}
+			if err == nil || !cfg.Retry.retryable(status.Code(err)) || ctx.Err() != nil {
+				return err
+			}
+		}
+		return err
+	}
+}
+
+// isFailure reports whether code indicates an unhealthy backend rather than
+// a rejected request.
+func isFailure(code codes.Code) bool {
+	switch code {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsCircuitOpen reports whether err came from an open breaker.
+func IsCircuitOpen(err error) bool {
+	return errors.Is(err, ErrCircuitOpen)
+}
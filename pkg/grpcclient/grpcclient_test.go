@@ -0,0 +1,244 @@
+package grpcclient_test
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CP-Payne/taskflow/pkg/grpcclient"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+	"google.golang.org/grpc/status"
+)
+
+const method = "/test.v1.Test/Get"
+
+func testConfig() grpcclient.Config {
+	cfg := grpcclient.DefaultConfig()
+	cfg.Retry.InitialBackoff = time.Millisecond
+	cfg.Retry.MaxBackoff = 2 * time.Millisecond
+	cfg.Breaker = grpcclient.BreakerConfig{FailureThreshold: 3, OpenTimeout: 50 * time.Millisecond}
+	return cfg
+}
+
+func newConn(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+	return newConnTo(t, "passthrough:///test")
+}
+
+// scripted returns an invoker failing with the given codes in turn, then
+// succeeding, and a pointer to the number of calls.
+func scripted(failures ...codes.Code) (grpc.UnaryInvoker, *int) {
+	calls := 0
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		if calls <= len(failures) {
+			return status.Error(failures[calls-1], "scripted failure")
+		}
+		return nil
+	}, &calls
+}
+
+func TestUnaryClientInterceptor_Retry(t *testing.T) {
+	tests := []struct {
+		name       string
+		idempotent bool
+		failures   []codes.Code
+		wantCalls  int
+		wantCode   codes.Code
+	}{
+		{
+			name:       "Retry idempotent call until it succeeds",
+			idempotent: true,
+			failures:   []codes.Code{codes.Unavailable, codes.Unavailable},
+			wantCalls:  3,
+			wantCode:   codes.OK,
+		},
+		{
+			name:       "Give up after max attempts",
+			idempotent: true,
+			failures:   []codes.Code{codes.Unavailable, codes.Unavailable, codes.Unavailable},
+			wantCalls:  3,
+			wantCode:   codes.Unavailable,
+		},
+		{
+			name:      "Do not retry non-idempotent call",
+			failures:  []codes.Code{codes.Unavailable},
+			wantCalls: 1,
+			wantCode:  codes.Unavailable,
+		},
+		{
+			name:       "Do not retry non-retryable code",
+			idempotent: true,
+			failures:   []codes.Code{codes.NotFound},
+			wantCalls:  1,
+			wantCode:   codes.NotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Methods[method] = grpcclient.MethodPolicy{Idempotent: tt.idempotent}
+			interceptor := grpcclient.UnaryClientInterceptor(cfg)
+
+			invoker, calls := scripted(tt.failures...)
+			err := interceptor(context.Background(), method, nil, nil, newConn(t), invoker)
+			if status.Code(err) != tt.wantCode {
+				t.Errorf("expected code %v, got %v", tt.wantCode, err)
+			}
+			if *calls != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, *calls)
+			}
+		})
+	}
+}
+
+func TestUnaryClientInterceptor_Deadline(t *testing.T) {
+	cfg := testConfig()
+	cfg.Methods[method] = grpcclient.MethodPolicy{Timeout: 100 * time.Millisecond}
+	interceptor := grpcclient.UnaryClientInterceptor(cfg)
+
+	var remaining time.Duration
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			t.Fatal("expected a deadline on the call")
+		}
+		remaining = time.Until(deadline)
+		return nil
+	}
+
+	if err := interceptor(context.Background(), method, nil, nil, newConn(t), invoker); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if remaining <= 0 || remaining > 100*time.Millisecond {
+		t.Errorf("expected the method timeout, got %v remaining", remaining)
+	}
+
+	// A shorter deadline set by the caller wins.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := interceptor(ctx, method, nil, nil, newConn(t), invoker); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if remaining > 10*time.Millisecond {
+		t.Errorf("expected the caller's deadline, got %v remaining", remaining)
+	}
+}
+
+// healthServer answers health checks with err, counting the calls.
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+	err   error
+	calls atomic.Int32
+}
+
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	s.calls.Add(1)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func startBackend(t *testing.T, err error) (*healthServer, string) {
+	t.Helper()
+	lis, lerr := net.Listen("tcp", "127.0.0.1:0")
+	if lerr != nil {
+		t.Fatalf("failed to listen: %v", lerr)
+	}
+	srv := &healthServer{err: err}
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return srv, lis.Addr().String()
+}
+
+// dialBackends connects to the addresses through the breaker balancer.
+func dialBackends(t *testing.T, cfg grpcclient.Config, addrs ...string) healthpb.HealthClient {
+	t.Helper()
+	r := manual.NewBuilderWithScheme("test")
+	state := resolver.State{}
+	for _, addr := range addrs {
+		state.Addresses = append(state.Addresses, resolver.Address{Addr: addr})
+	}
+	r.InitialState(state)
+
+	opts := append(grpcclient.DialOptions(cfg), grpc.WithResolvers(r), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	cc, err := grpc.NewClient(r.Scheme()+":///backends", opts...)
+	if err != nil {
+		t.Fatalf("grpc.NewClient() failed: %v", err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return healthpb.NewHealthClient(cc)
+}
+
+func TestCircuitBreaker_PerBackend(t *testing.T) {
+	cfg := testConfig()
+	cfg.Breaker.OpenTimeout = time.Minute
+	bad, badAddr := startBackend(t, status.Error(codes.Unavailable, "backend down"))
+	good, goodAddr := startBackend(t, nil)
+	client := dialBackends(t, cfg, badAddr, goodAddr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Health checks are not idempotent in cfg, so every failure is one call
+	var failed int
+	for i := 0; i < 30; i++ {
+		if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.WaitForReady(true)); err != nil {
+			failed++
+		}
+	}
+
+	if got := bad.calls.Load(); got != int32(cfg.Breaker.FailureThreshold) {
+		t.Errorf("expected the failing backend to get %d calls before its breaker opened, got %d", cfg.Breaker.FailureThreshold, got)
+	}
+	if failed != cfg.Breaker.FailureThreshold {
+		t.Errorf("expected only the calls to the failing backend to fail, got %d failures", failed)
+	}
+	if got := good.calls.Load(); got != int32(30-failed) {
+		t.Errorf("expected the healthy backend to serve the other calls, got %d", got)
+	}
+}
+
+func TestCircuitBreaker_AllBackendsOpen(t *testing.T) {
+	cfg := testConfig()
+	_, addr := startBackend(t, status.Error(codes.Unavailable, "backend down"))
+	client := dialBackends(t, cfg, addr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for i := 0; i < cfg.Breaker.FailureThreshold; i++ {
+		client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.WaitForReady(true))
+	}
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	if !grpcclient.IsCircuitOpen(err) {
+		t.Fatalf("expected the breaker to be open, got %v", err)
+	}
+
+	// After the open timeout a trial call goes through again
+	time.Sleep(cfg.Breaker.OpenTimeout)
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); status.Code(err) != codes.Unavailable || grpcclient.IsCircuitOpen(err) {
+		t.Errorf("expected the trial call to reach the backend, got %v", err)
+	}
+}
+
+func newConnTo(t *testing.T, target string) *grpc.ClientConn {
+	t.Helper()
+	cc, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient() failed: %v", err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return cc
+}
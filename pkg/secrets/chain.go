@@ -0,0 +1,135 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Chain reads each secret from the first provider that has it.
+type Chain struct {
+	providers []Secrets
+}
+
+func NewChain(providers ...Secrets) *Chain {
+	return &Chain{providers: providers}
+}
+
+// Get returns the secret from the first provider that has the path. Other
+// errors are returned right away rather than falling back to a later
+// provider.
+func (c *Chain) Get(ctx context.Context, path string) (*Secret, error) {
+	return c.first(path, func(p Secrets) (*Secret, error) {
+		return p.Get(ctx, path)
+	})
+}
+
+func (c *Chain) GetVersion(ctx context.Context, path string, version int) (*Secret, error) {
+	return c.first(path, func(p Secrets) (*Secret, error) {
+		return p.GetVersion(ctx, path, version)
+	})
+}
+
+func (c *Chain) first(path string, get func(Secrets) (*Secret, error)) (*Secret, error) {
+	if len(c.providers) == 0 {
+		return nil, fmt.Errorf("%w: %q, no secrets provider is configured", ErrNotFound, path)
+	}
+	var notFound []error
+	for _, p := range c.providers {
+		secret, err := get(p)
+		if errors.Is(err, ErrNotFound) {
+			notFound = append(notFound, err)
+			continue
+		}
+		return secret, err
+	}
+	return nil, errors.Join(notFound...)
+}
+
+// Vault returns the Vault provider of the chain, or nil.
+func (c *Chain) Vault() *VaultSecretManager {
+	for _, p := range c.providers {
+		if v, ok := p.(*VaultSecretManager); ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// RenewTokens keeps the tokens of the providers logged in to Vault valid until
+// ctx is done.
+func (c *Chain) RenewTokens(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, p := range c.providers {
+		if v, ok := p.(*VaultSecretManager); ok {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				v.RenewToken(ctx)
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+// Config selects the secrets providers of a service. Every configured
+// provider is chained, in the order of the fields, so local secrets override
+// Vault during development.
+type Config struct {
+	// EnvPrefix enables the EnvProvider, e.g. "SECRET_".
+	EnvPrefix string `yaml:"env_prefix" env:"SECRETS_ENV_PREFIX"`
+	// Dir enables the FileProvider.
+	Dir string `yaml:"dir" env:"SECRETS_DIR"`
+	// File enables the EncryptedFileProvider, opened with the base64 FileKey.
+	File    string      `yaml:"file" env:"SECRETS_FILE"`
+	FileKey string      `yaml:"file_key" env:"SECRETS_FILE_KEY" secret:"true"`
+	Vault   VaultConfig `yaml:"vault"`
+}
+
+func (c Config) Validate() error {
+	if c.File != "" {
+		if _, err := ParseKey(c.FileKey); err != nil {
+			return fmt.Errorf("invalid SECRETS_FILE_KEY: %w", err)
+		}
+	}
+	return nil
+}
+
+// Enabled reports whether any provider is configured.
+func (c Config) Enabled() bool {
+	return c.EnvPrefix != "" || c.Dir != "" || c.File != "" || c.Vault.Enabled()
+}
+
+// New chains the providers configured in cfg. It logs in to Vault when
+// configured; run RenewTokens to keep the login valid.
+func New(ctx context.Context, cfg Config, logger *zap.SugaredLogger) (*Chain, error) {
+	var providers []Secrets
+	if cfg.EnvPrefix != "" {
+		providers = append(providers, NewEnvProvider(cfg.EnvPrefix))
+	}
+	if cfg.Dir != "" {
+		providers = append(providers, NewFileProvider(cfg.Dir))
+	}
+	if cfg.File != "" {
+		key, err := ParseKey(cfg.FileKey)
+		if err != nil {
+			return nil, err
+		}
+		p, err := NewEncryptedFileProvider(cfg.File, key)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+	if cfg.Vault.Enabled() {
+		v, err := NewVaultSecretManager(ctx, cfg.Vault, logger)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, v)
+	}
+	return NewChain(providers...), nil
+}
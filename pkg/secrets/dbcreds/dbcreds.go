@@ -0,0 +1,275 @@
+// Package dbcreds connects database/sql pools with short-lived credentials,
+// such as those issued by Vault's database secrets engine. The lease is
+// renewed while possible, and fresh credentials are issued before it expires.
+// Open connections are left alone, so in-flight requests are not dropped;
+// only new connections use the fresh credentials.
+package dbcreds
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultRotateBefore = time.Minute
+	DefaultMinInterval  = time.Second
+
+	retryMinBackoff = time.Second
+	retryMaxBackoff = 30 * time.Second
+)
+
+var ErrNoCredentials = errors.New("no database credentials issued")
+
+// Credentials are a database login under a lease.
+type Credentials struct {
+	Username string
+	Password string
+	LeaseID  string
+	// LeaseDuration is how long the credentials are valid from issue or the
+	// last renewal. Zero means they do not expire.
+	LeaseDuration time.Duration
+	Renewable     bool
+}
+
+// Issuer issues database credentials, e.g. from Vault.
+type Issuer interface {
+	Issue(ctx context.Context) (*Credentials, error)
+	// Renew extends the lease and returns its new duration, which may be
+	// shorter than requested once the lease reaches its max TTL.
+	Renew(ctx context.Context, leaseID string, increment time.Duration) (time.Duration, error)
+	Revoke(ctx context.Context, leaseID string) error
+}
+
+// DSNFunc builds the data source name of the driver for creds.
+type DSNFunc func(creds Credentials) string
+
+type Options struct {
+	// RotateBefore is how long before expiry fresh credentials are issued
+	// when the lease cannot be renewed any further. It is capped at a third
+	// of the lease duration, so short leases are still used for most of their
+	// time. Connections must not outlive their credentials, so set the pool's
+	// SetConnMaxLifetime to at most RotateBefore, or a third of the lease if
+	// that is shorter.
+	RotateBefore time.Duration
+	// MinInterval is the least time between two renewals or rotations, so a
+	// lease that is about to expire does not get credentials issued back to
+	// back.
+	MinInterval time.Duration
+	// RenewIncrement is requested on renewal. Zero asks for the lease's
+	// original duration.
+	RenewIncrement time.Duration
+}
+
+// lease is the current credentials and when they expire.
+type lease struct {
+	creds   Credentials
+	expires time.Time
+}
+
+// Connector is a driver.Connector whose connections use the current
+// credentials. Open the pool with sql.OpenDB and keep Run going.
+type Connector struct {
+	issuer Issuer
+	driver driver.Driver
+	dsn    DSNFunc
+	opts   Options
+	logger *zap.SugaredLogger
+
+	mu      sync.RWMutex
+	current *lease
+}
+
+func NewConnector(issuer Issuer, d driver.Driver, dsn DSNFunc, opts Options, logger *zap.SugaredLogger) *Connector {
+	if opts.RotateBefore <= 0 {
+		opts.RotateBefore = DefaultRotateBefore
+	}
+	if opts.MinInterval <= 0 {
+		opts.MinInterval = DefaultMinInterval
+	}
+	return &Connector{
+		issuer: issuer,
+		driver: d,
+		dsn:    dsn,
+		opts:   opts,
+		logger: logger,
+	}
+}
+
+// Connect opens a connection with the current credentials. The first call
+// issues credentials if Run has not yet.
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	l, err := c.lease(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name := c.dsn(l.creds)
+	if dc, ok := c.driver.(driver.DriverContext); ok {
+		connector, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return connector.Connect(ctx)
+	}
+	return c.driver.Open(name)
+}
+
+func (c *Connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Credentials returns the current credentials.
+func (c *Connector) Credentials() (Credentials, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.current == nil {
+		return Credentials{}, false
+	}
+	return c.current.creds, true
+}
+
+func (c *Connector) lease(ctx context.Context) (*lease, error) {
+	c.mu.RLock()
+	l := c.current
+	c.mu.RUnlock()
+	if l != nil {
+		return l, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current != nil {
+		return c.current, nil
+	}
+	l, err := c.issue(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.current = l
+	return l, nil
+}
+
+func (c *Connector) issue(ctx context.Context) (*lease, error) {
+	creds, err := c.issuer.Issue(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue database credentials: %w", err)
+	}
+	if creds == nil {
+		return nil, ErrNoCredentials
+	}
+	l := &lease{creds: *creds}
+	if creds.LeaseDuration > 0 {
+		l.expires = time.Now().Add(creds.LeaseDuration)
+	}
+	c.logger.Infow("Issued database credentials", "username", creds.Username, "leaseDuration", creds.LeaseDuration)
+	return l, nil
+}
+
+// Run renews the lease until it reaches its max TTL, then rotates to fresh
+// credentials, until ctx is done. The last lease is revoked on return, so
+// cancel ctx only once the pool is no longer used.
+func (c *Connector) Run(ctx context.Context) {
+	backoff := retryMinBackoff
+	for {
+		l, err := c.lease(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger.Errorw("Failed to issue database credentials", "error", err, "retryIn", backoff)
+			if !sleep(ctx, backoff) {
+				return
+			}
+			backoff = min(2*backoff, retryMaxBackoff)
+			continue
+		}
+		backoff = retryMinBackoff
+		if l.expires.IsZero() {
+			<-ctx.Done()
+			c.revoke(l)
+			return
+		}
+
+		if !sleep(ctx, c.nextAction(l)) {
+			c.revoke(l)
+			return
+		}
+		if err := c.refresh(ctx, l); err != nil && ctx.Err() == nil {
+			c.logger.Errorw("Failed to refresh database credentials", "error", err, "expires", l.expires)
+			sleep(ctx, backoff)
+			backoff = min(2*backoff, retryMaxBackoff)
+		}
+	}
+}
+
+// nextAction returns when the lease is renewed: after two thirds of its
+// remaining time, and no later than rotateBefore its expiry, but not sooner
+// than MinInterval.
+func (c *Connector) nextAction(l *lease) time.Duration {
+	left := time.Until(l.expires)
+	return max(c.opts.MinInterval, min(left*2/3, left-c.rotateBefore(l.creds)))
+}
+
+// rotateBefore returns how long before expiry creds are replaced: RotateBefore,
+// but at most a third of their lease duration.
+func (c *Connector) rotateBefore(creds Credentials) time.Duration {
+	return min(c.opts.RotateBefore, creds.LeaseDuration/3)
+}
+
+// refresh renews the lease, or replaces it with fresh credentials when it can
+// no longer be renewed beyond rotateBefore.
+func (c *Connector) refresh(ctx context.Context, l *lease) error {
+	if l.creds.Renewable {
+		d, err := c.issuer.Renew(ctx, l.creds.LeaseID, c.opts.RenewIncrement)
+		if err == nil && d > c.rotateBefore(l.creds) {
+			c.mu.Lock()
+			c.current = &lease{creds: l.creds, expires: time.Now().Add(d)}
+			c.mu.Unlock()
+			c.logger.Debugw("Renewed database credentials", "username", l.creds.Username, "leaseDuration", d)
+			return nil
+		}
+		if err != nil {
+			c.logger.Warnw("Failed to renew database credentials, rotating", "error", err)
+		}
+	}
+
+	next, err := c.issue(ctx)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.current = next
+	c.mu.Unlock()
+	// The old credentials expire on their own, after the connections using
+	// them reached their max lifetime.
+	c.logger.Infow("Rotated database credentials", "previous", l.creds.Username, "username", next.creds.Username)
+	return nil
+}
+
+func (c *Connector) revoke(l *lease) {
+	if l.creds.LeaseID == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.issuer.Revoke(ctx, l.creds.LeaseID); err != nil {
+		c.logger.Warnw("Failed to revoke database credentials", "error", err, "username", l.creds.Username)
+	}
+}
+
+// sleep waits for d and reports whether ctx is still active.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,217 @@
+package dbcreds_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CP-Payne/taskflow/pkg/secrets/dbcreds"
+	"go.uber.org/zap"
+)
+
+// fakeIssuer issues credentials user1, user2, ... and renews leases with the
+// durations in renewals, then fails.
+type fakeIssuer struct {
+	lease     time.Duration
+	renewable bool
+
+	mu       sync.Mutex
+	issued   int
+	renewals []time.Duration
+	renewed  int
+	revoked  []string
+}
+
+func (f *fakeIssuer) Issue(context.Context) (*dbcreds.Credentials, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.issued++
+	return &dbcreds.Credentials{
+		Username:      fmt.Sprintf("user%d", f.issued),
+		Password:      "pw",
+		LeaseID:       fmt.Sprintf("lease%d", f.issued),
+		LeaseDuration: f.lease,
+		Renewable:     f.renewable,
+	}, nil
+}
+
+func (f *fakeIssuer) Renew(_ context.Context, leaseID string, _ time.Duration) (time.Duration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.renewed >= len(f.renewals) {
+		return 0, errors.New("lease not found")
+	}
+	f.renewed++
+	return f.renewals[f.renewed-1], nil
+}
+
+func (f *fakeIssuer) Revoke(_ context.Context, leaseID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.revoked = append(f.revoked, leaseID)
+	return nil
+}
+
+func (f *fakeIssuer) stats() (issued, renewed int, revoked []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.issued, f.renewed, slices.Clone(f.revoked)
+}
+
+// fakeDriver records the data source name of every connection.
+type fakeDriver struct {
+	mu    sync.Mutex
+	conns []*fakeConn
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c := &fakeConn{dsn: name}
+	d.conns = append(d.conns, c)
+	return c, nil
+}
+
+type fakeConn struct {
+	dsn    string
+	mu     sync.Mutex
+	closed bool
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not implemented") }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not implemented") }
+func (c *fakeConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *fakeConn) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func dsn(creds dbcreds.Credentials) string {
+	return creds.Username + ":" + creds.Password
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestConnector_RotatesWithoutDroppingConnections(t *testing.T) {
+	issuer := &fakeIssuer{lease: 300 * time.Millisecond}
+	d := &fakeDriver{}
+	connector := dbcreds.NewConnector(issuer, d, dsn, dbcreds.Options{RotateBefore: 100 * time.Millisecond, MinInterval: 10 * time.Millisecond}, zap.NewNop().Sugar())
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// An in-flight request holds a connection opened with the first
+	// credentials.
+	inFlight, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn() failed: %v", err)
+	}
+	if d.conns[0].dsn != "user1:pw" {
+		t.Fatalf("expected the first credentials, got %q", d.conns[0].dsn)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		connector.Run(ctx)
+		close(done)
+	}()
+
+	waitFor(t, "rotation", func() bool {
+		creds, _ := connector.Credentials()
+		return creds.Username == "user2"
+	})
+	next, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn() failed: %v", err)
+	}
+	defer next.Close()
+	if got := d.conns[len(d.conns)-1].dsn; got != "user2:pw" {
+		t.Errorf("expected new connections to use the fresh credentials, got %q", got)
+	}
+	if d.conns[0].isClosed() {
+		t.Error("expected the in-flight connection to stay open")
+	}
+	if err := inFlight.Close(); err != nil {
+		t.Errorf("Close() failed: %v", err)
+	}
+
+	cancel()
+	<-done
+	_, _, revoked := issuer.stats()
+	creds, _ := connector.Credentials()
+	if len(revoked) != 1 || revoked[0] != creds.LeaseID {
+		t.Errorf("expected the current lease %s to be revoked on shutdown, got %v", creds.LeaseID, revoked)
+	}
+}
+
+func TestConnector_RenewsUntilMaxTTL(t *testing.T) {
+	issuer := &fakeIssuer{
+		lease:     150 * time.Millisecond,
+		renewable: true,
+		// The third renewal is capped by the max TTL and triggers rotation.
+		renewals: []time.Duration{150 * time.Millisecond, 150 * time.Millisecond, 20 * time.Millisecond},
+	}
+	connector := dbcreds.NewConnector(issuer, &fakeDriver{}, dsn, dbcreds.Options{RotateBefore: 50 * time.Millisecond, MinInterval: 10 * time.Millisecond}, zap.NewNop().Sugar())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		connector.Run(ctx)
+		close(done)
+	}()
+
+	waitFor(t, "rotation", func() bool {
+		issued, _, _ := issuer.stats()
+		return issued == 2
+	})
+	cancel()
+	<-done
+
+	if _, renewed, _ := issuer.stats(); renewed != 3 {
+		t.Errorf("expected 3 renewals before rotating, got %d", renewed)
+	}
+	if creds, _ := connector.Credentials(); creds.Username != "user2" {
+		t.Errorf("expected the fresh credentials, got %s", creds.Username)
+	}
+}
+
+func TestConnector_LeaseShorterThanRotateBefore(t *testing.T) {
+	// The 30s lease is shorter than the default RotateBefore of a minute.
+	issuer := &fakeIssuer{lease: 30 * time.Second}
+	connector := dbcreds.NewConnector(issuer, &fakeDriver{}, dsn, dbcreds.Options{}, zap.NewNop().Sugar())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	connector.Run(ctx)
+
+	if issued, _, revoked := issuer.stats(); issued != 1 || len(revoked) != 1 {
+		t.Errorf("expected the credentials to be issued and revoked once, got %d issued and %v revoked", issued, revoked)
+	}
+	if creds, _ := connector.Credentials(); creds.Username != "user1" {
+		t.Errorf("expected the first credentials to be kept, got %s", creds.Username)
+	}
+}
@@ -0,0 +1,69 @@
+package dbcreds
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// DefaultMount is where the database secrets engine is enabled.
+const DefaultMount = "database"
+
+// VaultIssuer issues credentials for a role of Vault's database secrets
+// engine.
+type VaultIssuer struct {
+	client *vault.Client
+	path   string
+}
+
+// NewVaultIssuer issues credentials from <mount>/creds/<role>. The client
+// must be logged in, e.g. secrets.VaultSecretManager.Client.
+func NewVaultIssuer(client *vault.Client, mount, role string) *VaultIssuer {
+	if mount == "" {
+		mount = DefaultMount
+	}
+	return &VaultIssuer{
+		client: client,
+		path:   fmt.Sprintf("%s/creds/%s", mount, role),
+	}
+}
+
+func (v *VaultIssuer) Issue(ctx context.Context) (*Credentials, error) {
+	secret, err := v.client.Logical().ReadWithContext(ctx, v.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", v.path, err)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("%w: %s returned no data", ErrNoCredentials, v.path)
+	}
+
+	username, _ := secret.Data["username"].(string)
+	password, _ := secret.Data["password"].(string)
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("%w: %s returned no username or password", ErrNoCredentials, v.path)
+	}
+	return &Credentials{
+		Username:      username,
+		Password:      password,
+		LeaseID:       secret.LeaseID,
+		LeaseDuration: time.Duration(secret.LeaseDuration) * time.Second,
+		Renewable:     secret.Renewable,
+	}, nil
+}
+
+func (v *VaultIssuer) Renew(ctx context.Context, leaseID string, increment time.Duration) (time.Duration, error) {
+	secret, err := v.client.Sys().RenewWithContext(ctx, leaseID, int(increment.Seconds()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to renew lease: %w", err)
+	}
+	return time.Duration(secret.LeaseDuration) * time.Second, nil
+}
+
+func (v *VaultIssuer) Revoke(ctx context.Context, leaseID string) error {
+	if err := v.client.Sys().RevokeWithContext(ctx, leaseID); err != nil {
+		return fmt.Errorf("failed to revoke lease: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KeySize is the size of the AES-256 keys sealing secrets files.
+const KeySize = 32
+
+// sealedHeader starts every sealed file and is authenticated with it.
+const sealedHeader = "taskflow-secrets-v1\n"
+
+var ErrInvalidSealedFile = errors.New("invalid sealed secrets file")
+
+// EncryptedFileProvider reads secrets from a file written by Seal. The file is
+// read on every Get, so replacing it rotates the secrets. Local secrets are
+// not versioned.
+type EncryptedFileProvider struct {
+	path string
+	key  []byte
+}
+
+// NewEncryptedFileProvider checks that the file at path opens with key.
+func NewEncryptedFileProvider(path string, key []byte) (*EncryptedFileProvider, error) {
+	p := &EncryptedFileProvider{path: path, key: key}
+	if _, err := p.read(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *EncryptedFileProvider) Get(_ context.Context, path string) (*Secret, error) {
+	all, err := p.read()
+	if err != nil {
+		return nil, err
+	}
+	data, ok := all[path]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q in %s", ErrNotFound, path, p.path)
+	}
+	return &Secret{Path: path, Data: data}, nil
+}
+
+func (p *EncryptedFileProvider) GetVersion(ctx context.Context, path string, version int) (*Secret, error) {
+	return getUnversioned(ctx, p, path, version)
+}
+
+func (p *EncryptedFileProvider) read() (map[string]map[string]string, error) {
+	sealed, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file: %w", err)
+	}
+	all, err := Unseal(p.key, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open secrets file %s: %w", p.path, err)
+	}
+	return all, nil
+}
+
+// Seal encrypts secrets, keyed by path, with AES-256-GCM.
+func Seal(key []byte, secrets map[string]map[string]string) ([]byte, error) {
+	plaintext, err := yaml.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := append([]byte(sealedHeader), nonce...)
+	return gcm.Seal(sealed, nonce, plaintext, []byte(sealedHeader)), nil
+}
+
+// Unseal decrypts secrets sealed with Seal.
+func Unseal(key, sealed []byte) (map[string]map[string]string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	body, ok := strings.CutPrefix(string(sealed), sealedHeader)
+	if !ok || len(body) < gcm.NonceSize() {
+		return nil, ErrInvalidSealedFile
+	}
+	nonce, ciphertext := []byte(body[:gcm.NonceSize()]), []byte(body[gcm.NonceSize():])
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(sealedHeader))
+	if err != nil {
+		return nil, fmt.Errorf("%w: wrong key or corrupted content", ErrInvalidSealedFile)
+	}
+
+	var secrets map[string]map[string]string
+	if err := yaml.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSealedFile, err)
+	}
+	return secrets, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("secrets file key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// GenerateKey returns a random key for Seal.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ParseKey decodes a base64 encoded key, the form used in SECRETS_FILE_KEY.
+func ParseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("secrets file key is not valid base64: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("secrets file key must be %d bytes, got %d", KeySize, len(key))
+	}
+	return key, nil
+}
@@ -0,0 +1,123 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// FileProvider reads secrets from a directory holding one directory per
+// secret and one file per key, the layout of mounted Kubernetes secrets. The
+// key "private_key" of the secret "jwt/auth" is the file jwt/auth/private_key.
+// Local secrets are not versioned.
+type FileProvider struct {
+	dir string
+}
+
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{dir: dir}
+}
+
+// Get reads every file of the secret's directory. An empty path or "." is
+// the directory itself.
+func (p *FileProvider) Get(_ context.Context, path string) (*Secret, error) {
+	local := filepath.FromSlash(path)
+	if path == "" {
+		local = "."
+	}
+	if !filepath.IsLocal(local) {
+		return nil, fmt.Errorf("invalid secret path %q", path)
+	}
+
+	dir := filepath.Join(p.dir, local)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+		return nil, fmt.Errorf("%w: %q in %s", ErrNotFound, path, p.dir)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read secret %q: %w", path, err)
+	}
+
+	secret := &Secret{Path: path, Data: make(map[string]string)}
+	for _, e := range entries {
+		// Skip hidden files, such as the ..data link of mounted secrets.
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		file := filepath.Join(dir, e.Name())
+		// Stat follows symlinks, which mounted secrets consist of.
+		info, err := os.Stat(file)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		value, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret %q: %w", path, err)
+		}
+		secret.Data[e.Name()] = string(value)
+	}
+	return secret, nil
+}
+
+func (p *FileProvider) GetVersion(ctx context.Context, path string, version int) (*Secret, error) {
+	return getUnversioned(ctx, p, path, version)
+}
+
+// EnvProvider reads secrets from environment variables. The key "password"
+// of the secret "notifier/smtp" is the variable <prefix>NOTIFIER_SMTP_PASSWORD:
+// the path and key are upper-cased, and characters other than letters and
+// digits become underscores. Keys are returned lower-cased. Local secrets are
+// not versioned.
+type EnvProvider struct {
+	prefix string
+	// Environ lists the variables, os.Environ by default.
+	Environ func() []string
+}
+
+func NewEnvProvider(prefix string) *EnvProvider {
+	return &EnvProvider{prefix: prefix, Environ: os.Environ}
+}
+
+func (p *EnvProvider) Get(_ context.Context, path string) (*Secret, error) {
+	prefix := p.prefix + envName(path) + "_"
+	secret := &Secret{Path: path, Data: make(map[string]string)}
+	for _, kv := range p.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if key, ok := strings.CutPrefix(name, prefix); ok && key != "" {
+			secret.Data[strings.ToLower(key)] = value
+		}
+	}
+	if len(secret.Data) == 0 {
+		return nil, fmt.Errorf("%w: no %s* variables", ErrNotFound, prefix)
+	}
+	return secret, nil
+}
+
+func (p *EnvProvider) GetVersion(ctx context.Context, path string, version int) (*Secret, error) {
+	return getUnversioned(ctx, p, path, version)
+}
+
+func envName(path string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, path)
+}
+
+// getUnversioned serves GetVersion for providers without versions, where the
+// current secret is version 0.
+func getUnversioned(ctx context.Context, s Secrets, path string, version int) (*Secret, error) {
+	if version != 0 {
+		return nil, fmt.Errorf("%w: %q has no version %d, local secrets are not versioned", ErrNotFound, path, version)
+	}
+	return s.Get(ctx, path)
+}
@@ -1,5 +1,83 @@
 package secrets
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound    = errors.New("secret not found")
+	ErrKeyNotFound = errors.New("key not found in secret")
+)
+
+// Secret is one version of a set of key/value pairs stored at a path.
+type Secret struct {
+	Path string
+	// Version increases with every write. Zero means the provider does not
+	// version secrets.
+	Version int
+	// CreatedAt is when the version was written, zero when unknown.
+	CreatedAt time.Time
+	Data      map[string]string
+}
+
+func (s *Secret) Value(key string) (string, error) {
+	v, ok := s.Data[key]
+	if !ok {
+		return "", fmt.Errorf("%w: %q in %q", ErrKeyNotFound, key, s.Path)
+	}
+	return v, nil
+}
+
 type Secrets interface {
-	GetCryptoKey(path string, keyName string) ([]byte, error)
+	// Get returns the latest version of the secret at path.
+	Get(ctx context.Context, path string) (*Secret, error)
+	// GetVersion returns the given version of the secret at path.
+	GetVersion(ctx context.Context, path string, version int) (*Secret, error)
+}
+
+// GetValue returns key from the latest version of the secret at path.
+func GetValue(ctx context.Context, s Secrets, path, key string) (string, error) {
+	secret, err := s.Get(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	return secret.Value(key)
+}
+
+// RefPrefix starts a reference to a secret in a configuration value, e.g.
+// "secret:notifier/smtp#password".
+const RefPrefix = "secret:"
+
+// ParseRef splits a reference into the path and key of the secret. ok is false
+// when value is not a reference.
+func ParseRef(value string) (path, key string, ok bool, err error) {
+	ref, isRef := strings.CutPrefix(value, RefPrefix)
+	if !isRef {
+		return "", "", false, nil
+	}
+	path, key, found := strings.Cut(ref, "#")
+	if !found || path == "" || key == "" {
+		return "", "", true, fmt.Errorf("invalid secret reference %q, expected %s<path>#<key>", value, RefPrefix)
+	}
+	return path, key, true, nil
+}
+
+// Resolver returns a function replacing secret references with their values
+// read from s. Other values are returned unchanged. s may be nil when no
+// provider is configured, in which case references are an error.
+func Resolver(ctx context.Context, s Secrets) func(value string) (string, error) {
+	return func(value string) (string, error) {
+		path, key, ok, err := ParseRef(value)
+		if !ok || err != nil {
+			return value, err
+		}
+		if s == nil {
+			return "", fmt.Errorf("secret reference %q needs a secrets provider", value)
+		}
+		return GetValue(ctx, s, path, key)
+	}
 }
@@ -0,0 +1,173 @@
+package secrets_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CP-Payne/taskflow/pkg/secrets"
+	"go.uber.org/zap"
+)
+
+// fakeSecrets keeps every version of each secret in memory.
+type fakeSecrets struct {
+	mu       sync.Mutex
+	versions map[string][]map[string]string
+	err      error
+}
+
+func newFakeSecrets() *fakeSecrets {
+	return &fakeSecrets{versions: make(map[string][]map[string]string)}
+}
+
+func (f *fakeSecrets) put(path string, data map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.versions[path] = append(f.versions[path], data)
+}
+
+func (f *fakeSecrets) fail(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+func (f *fakeSecrets) Get(ctx context.Context, path string) (*secrets.Secret, error) {
+	f.mu.Lock()
+	n := len(f.versions[path])
+	f.mu.Unlock()
+	return f.GetVersion(ctx, path, n)
+}
+
+func (f *fakeSecrets) GetVersion(_ context.Context, path string, version int) (*secrets.Secret, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	versions := f.versions[path]
+	if version < 1 || version > len(versions) {
+		return nil, fmt.Errorf("%w: %s version %d", secrets.ErrNotFound, path, version)
+	}
+	return &secrets.Secret{Path: path, Version: version, Data: versions[version-1]}, nil
+}
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		value   string
+		path    string
+		key     string
+		ok      bool
+		wantErr bool
+	}{
+		{value: "hunter2"},
+		{value: "", ok: false},
+		{value: "secret:notifier/smtp#password", path: "notifier/smtp", key: "password", ok: true},
+		{value: "secret:notifier/smtp", ok: true, wantErr: true},
+		{value: "secret:#password", ok: true, wantErr: true},
+		{value: "secret:notifier/smtp#", ok: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			path, key, ok, err := secrets.ParseRef(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if ok != tt.ok || path != tt.path || key != tt.key {
+				t.Errorf("expected (%q, %q, %v), got (%q, %q, %v)", tt.path, tt.key, tt.ok, path, key, ok)
+			}
+		})
+	}
+}
+
+func TestResolver(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeSecrets()
+	store.put("task/redis", map[string]string{"password": "v1"})
+	store.put("task/redis", map[string]string{"password": "v2"})
+
+	resolve := secrets.Resolver(ctx, store)
+	if got, err := resolve("plain"); err != nil || got != "plain" {
+		t.Errorf("expected plain values unchanged, got %q, %v", got, err)
+	}
+	if got, err := resolve("secret:task/redis#password"); err != nil || got != "v2" {
+		t.Errorf("expected the latest version, got %q, %v", got, err)
+	}
+	if _, err := resolve("secret:task/redis#username"); !errors.Is(err, secrets.ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+	if _, err := resolve("secret:task/missing#password"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	noProvider := secrets.Resolver(ctx, nil)
+	if got, err := noProvider("plain"); err != nil || got != "plain" {
+		t.Errorf("expected plain values unchanged without a provider, got %q, %v", got, err)
+	}
+	if _, err := noProvider("secret:task/redis#password"); err == nil {
+		t.Error("expected references to fail without a provider")
+	}
+}
+
+func TestWatcher_Refresh(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeSecrets()
+	store.put("notifier/smtp", map[string]string{"password": "v1"})
+
+	watcher := secrets.NewWatcher(store, time.Hour, zap.NewNop().Sugar())
+	var seen []string
+	watcher.Watch("notifier/smtp", func(s *secrets.Secret) {
+		seen = append(seen, s.Data["password"])
+	})
+
+	watcher.Refresh(ctx)
+	watcher.Refresh(ctx)
+	store.put("notifier/smtp", map[string]string{"password": "v2"})
+	store.fail(errors.New("vault sealed"))
+	watcher.Refresh(ctx)
+	store.fail(nil)
+	watcher.Refresh(ctx)
+	watcher.Refresh(ctx)
+
+	if fmt.Sprint(seen) != "[v1 v2]" {
+		t.Errorf("expected one call per version, got %v", seen)
+	}
+}
+
+func TestWatcher_Run(t *testing.T) {
+	store := newFakeSecrets()
+	store.put("task/redis", map[string]string{"password": "v1"})
+
+	watcher := secrets.NewWatcher(store, time.Millisecond, zap.NewNop().Sugar())
+	versions := make(chan int, 10)
+	watcher.Watch("task/redis", func(s *secrets.Secret) { versions <- s.Version })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		watcher.Run(ctx)
+		close(done)
+	}()
+
+	expectVersion := func(want int) {
+		t.Helper()
+		select {
+		case got := <-versions:
+			if got != want {
+				t.Errorf("expected version %d, got %d", want, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for version %d", want)
+		}
+	}
+	expectVersion(1)
+	store.put("task/redis", map[string]string{"password": "v2"})
+	expectVersion(2)
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,234 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+	auth "github.com/hashicorp/vault/api/auth/approle"
+	"go.uber.org/zap"
+)
+
+// DefaultKVMount is the KV v2 mount secrets are read from.
+const DefaultKVMount = "secret"
+
+const (
+	// loginMinBackoff and loginMaxBackoff bound the delay between failed
+	// logins after the token expired.
+	loginMinBackoff = time.Second
+	loginMaxBackoff = time.Minute
+	// minLoginInterval is the least time between two successful logins, so
+	// a token with a short or no lease does not cause a login loop.
+	minLoginInterval = 10 * time.Second
+)
+
+// VaultConfig locates Vault and the AppRole credentials of the service. Vault
+// is optional unless Addr is set.
+type VaultConfig struct {
+	Addr     string `yaml:"addr" env:"VAULT_ADDR"`
+	RoleID   string `yaml:"role_id" env:"APPROLE_ROLE_ID"`
+	SecretID string `yaml:"secret_id" env:"APPROLE_SECRET_ID" secret:"true"`
+	Mount    string `yaml:"mount" env:"VAULT_KV_MOUNT"`
+}
+
+func (c VaultConfig) Enabled() bool {
+	return c.Addr != ""
+}
+
+func (c VaultConfig) Validate() error {
+	if c.Enabled() && (c.RoleID == "" || c.SecretID == "") {
+		return errors.New("vault needs APPROLE_ROLE_ID and APPROLE_SECRET_ID")
+	}
+	return nil
+}
+
+// VaultSecretManager reads secrets from a KV v2 mount, logged in with AppRole.
+type VaultSecretManager struct {
+	client *vault.Client
+	mount  string
+	login  vault.AuthMethod
+	logger *zap.SugaredLogger
+
+	mu sync.Mutex
+	// token is the current login, kept alive by RenewToken.
+	token    *vault.Secret
+	loggedIn time.Time
+}
+
+func NewVaultSecretManager(ctx context.Context, cfg VaultConfig, logger *zap.SugaredLogger) (*VaultSecretManager, error) {
+	config := vault.DefaultConfig()
+	config.Address = cfg.Addr
+
+	client, err := vault.NewClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vault client: %w", err)
+	}
+
+	// Assigning Roles
+	appRoleAuth, err := auth.NewAppRoleAuth(
+		cfg.RoleID,
+		&auth.SecretID{FromString: cfg.SecretID},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AppRole auth: %w", err)
+	}
+
+	mount := cfg.Mount
+	if mount == "" {
+		mount = DefaultKVMount
+	}
+	v := &VaultSecretManager{
+		client: client,
+		mount:  mount,
+		login:  appRoleAuth,
+		logger: logger,
+	}
+	if err := v.logIn(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Client returns the Vault client, logged in as the service.
+func (v *VaultSecretManager) Client() *vault.Client {
+	return v.client
+}
+
+// logIn logs in with AppRole. The client uses the new token for subsequent
+// requests.
+func (v *VaultSecretManager) logIn(ctx context.Context) error {
+	authInfo, err := v.client.Auth().Login(ctx, v.login)
+	if err != nil {
+		return fmt.Errorf("failed to login with AppRole credentials: %w", err)
+	}
+	if authInfo == nil || authInfo.Auth == nil || authInfo.Auth.ClientToken == "" {
+		return fmt.Errorf("authentication failed: No token received")
+	}
+
+	v.mu.Lock()
+	v.token = authInfo
+	v.loggedIn = time.Now()
+	v.mu.Unlock()
+	return nil
+}
+
+// RenewToken keeps the login token valid until ctx is done. A lifetime
+// watcher renews the token, and once it can no longer be renewed, e.g. at its
+// max TTL, the service logs in again, at most once every minLoginInterval.
+func (v *VaultSecretManager) RenewToken(ctx context.Context) {
+	for ctx.Err() == nil {
+		if err := v.watchToken(ctx); err != nil && ctx.Err() == nil {
+			v.logger.Warnw("Vault token renewal stopped", "error", err)
+		}
+
+		v.mu.Lock()
+		next := v.loggedIn.Add(minLoginInterval)
+		v.mu.Unlock()
+		if !sleepUntil(ctx, next) {
+			return
+		}
+
+		backoff := loginMinBackoff
+		for ctx.Err() == nil {
+			err := v.logIn(ctx)
+			if err == nil {
+				v.logger.Infow("Logged in to Vault again")
+				break
+			}
+			v.logger.Errorw("Failed to log in to Vault", "error", err, "retryIn", backoff)
+			sleepUntil(ctx, time.Now().Add(backoff))
+			backoff = min(2*backoff, loginMaxBackoff)
+		}
+	}
+}
+
+// watchToken renews the current token until it expires or renewal fails.
+func (v *VaultSecretManager) watchToken(ctx context.Context) error {
+	v.mu.Lock()
+	token := v.token
+	v.mu.Unlock()
+
+	if !token.Auth.Renewable {
+		lease := time.Duration(token.Auth.LeaseDuration) * time.Second
+		if lease == 0 {
+			// The token does not expire.
+			<-ctx.Done()
+			return nil
+		}
+		// Log in again when two thirds of the lease are gone.
+		sleepUntil(ctx, time.Now().Add(lease*2/3))
+		return nil
+	}
+
+	watcher, err := v.client.NewLifetimeWatcher(&vault.LifetimeWatcherInput{Secret: token})
+	if err != nil {
+		return err
+	}
+	go watcher.Start()
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-watcher.DoneCh():
+			return err
+		case renewal := <-watcher.RenewCh():
+			v.logger.Debugw("Renewed Vault token", "leaseDuration", renewal.Secret.Auth.LeaseDuration)
+		}
+	}
+}
+
+// sleepUntil waits until t and reports whether ctx is still active.
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (v *VaultSecretManager) Get(ctx context.Context, path string) (*Secret, error) {
+	kv, err := v.client.KVv2(v.mount).Get(ctx, path)
+	if err != nil {
+		return nil, v.readError(path, err)
+	}
+	return fromKV(path, kv), nil
+}
+
+func (v *VaultSecretManager) GetVersion(ctx context.Context, path string, version int) (*Secret, error) {
+	kv, err := v.client.KVv2(v.mount).GetVersion(ctx, path, version)
+	if err != nil {
+		return nil, v.readError(path, err)
+	}
+	return fromKV(path, kv), nil
+}
+
+func (v *VaultSecretManager) readError(path string, err error) error {
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		return fmt.Errorf("%w: %q in mount %q", ErrNotFound, path, v.mount)
+	}
+	return fmt.Errorf("failed to read secret %q from Vault: %w", path, err)
+}
+
+func fromKV(path string, kv *vault.KVSecret) *Secret {
+	secret := &Secret{Path: path, Data: make(map[string]string, len(kv.Data))}
+	if kv.VersionMetadata != nil {
+		secret.Version = kv.VersionMetadata.Version
+		secret.CreatedAt = kv.VersionMetadata.CreatedTime
+	}
+	for k, v := range kv.Data {
+		if s, ok := v.(string); ok {
+			secret.Data[k] = s
+		} else {
+			secret.Data[k] = fmt.Sprint(v)
+		}
+	}
+	return secret
+}
@@ -0,0 +1,103 @@
+package secrets
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultRefreshInterval is how often a Watcher reads watched secrets.
+const DefaultRefreshInterval = time.Minute
+
+type watch struct {
+	path    string
+	fn      func(*Secret)
+	current *Secret
+}
+
+// Watcher polls secrets and calls back when they change, so rotated
+// passwords and keys are picked up without a restart.
+type Watcher struct {
+	secrets  Secrets
+	interval time.Duration
+	logger   *zap.SugaredLogger
+
+	mu      sync.Mutex
+	watches []*watch
+	// refreshing serializes refreshes, which update the current versions.
+	refreshing sync.Mutex
+}
+
+func NewWatcher(secrets Secrets, interval time.Duration, logger *zap.SugaredLogger) *Watcher {
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+	return &Watcher{
+		secrets:  secrets,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Watch calls fn with the secret at path whenever a new version is read. The
+// first call happens on the first refresh, when Run starts.
+func (w *Watcher) Watch(path string, fn func(*Secret)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.watches = append(w.watches, &watch{path: path, fn: fn})
+}
+
+// Run refreshes the watched secrets until ctx is done.
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		w.Refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh reads every watched secret once and calls back for those that
+// changed. A failed read keeps the current version.
+func (w *Watcher) Refresh(ctx context.Context) {
+	w.refreshing.Lock()
+	defer w.refreshing.Unlock()
+
+	w.mu.Lock()
+	watches := w.watches
+	w.mu.Unlock()
+
+	for _, wt := range watches {
+		secret, err := w.secrets.Get(ctx, wt.path)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.logger.Warnw("Failed to refresh secret", "path", wt.path, "error", err)
+			}
+			continue
+		}
+		if !changed(wt.current, secret) {
+			continue
+		}
+		wt.current = secret
+		wt.fn(secret)
+	}
+}
+
+// changed compares versions, or the data when the provider does not version
+// secrets.
+func changed(current, next *Secret) bool {
+	if current == nil {
+		return true
+	}
+	if current.Version != 0 || next.Version != 0 {
+		return current.Version != next.Version
+	}
+	return !maps.Equal(current.Data, next.Data)
+}
@@ -0,0 +1,348 @@
+// Package server runs a gRPC service the way every taskflow service does:
+// it registers the instance with discovery, serves grpc.health.v1 backed by
+// dependency checks, runs background tasks, and shuts down gracefully on
+// SIGINT or SIGTERM.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/CP-Payne/taskflow/pkg/discovery"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	DefaultHealthInterval  = 5 * time.Second
+	DefaultCheckTimeout    = 2 * time.Second
+	DefaultRegisterTimeout = 10 * time.Second
+	DefaultShutdownTimeout = 15 * time.Second
+)
+
+// State is the lifecycle stage of a Server.
+type State int32
+
+const (
+	// StateStarting lasts until the listener is open and every check passed
+	// once.
+	StateStarting State = iota
+	// StateReady means the instance is registered and serving.
+	StateReady
+	// StateDraining means the instance is deregistered, reports NOT_SERVING
+	// and waits for in-flight calls.
+	StateDraining
+	StateStopped
+)
+
+func (s State) String() string {
+	switch s {
+	case StateStarting:
+		return "starting"
+	case StateReady:
+		return "ready"
+	case StateDraining:
+		return "draining"
+	case StateStopped:
+		return "stopped"
+	default:
+		return fmt.Sprintf("State(%d)", int32(s))
+	}
+}
+
+// Check reports whether a dependency, such as Redis or the repository, is
+// usable.
+type Check func(ctx context.Context) error
+
+type Config struct {
+	// Name is the service name registered with discovery.
+	Name string
+	// Port to listen on. Zero picks a free port.
+	Port     int
+	Registry discovery.Registry
+	// RegisterOptions describe the instance to the registry.
+	RegisterOptions []discovery.RegisterOption
+
+	// HealthInterval is how often checks run and health is reported to the
+	// registry.
+	HealthInterval  time.Duration
+	CheckTimeout    time.Duration
+	RegisterTimeout time.Duration
+	// ShutdownTimeout bounds draining. Streams still open when it expires are
+	// closed.
+	ShutdownTimeout time.Duration
+}
+
+func (c Config) withDefaults() Config {
+	if c.HealthInterval <= 0 {
+		c.HealthInterval = DefaultHealthInterval
+	}
+	if c.CheckTimeout <= 0 {
+		c.CheckTimeout = DefaultCheckTimeout
+	}
+	if c.RegisterTimeout <= 0 {
+		c.RegisterTimeout = DefaultRegisterTimeout
+	}
+	if c.ShutdownTimeout <= 0 {
+		c.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	return c
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+type task struct {
+	name string
+	run  func(ctx context.Context)
+}
+
+type Server struct {
+	cfg        Config
+	logger     *zap.SugaredLogger
+	grpcServer *grpc.Server
+	health     *health.Server
+	instanceID string
+	state      atomic.Int32
+
+	mu     sync.Mutex
+	checks []namedCheck
+	tasks  []task
+	addr   net.Addr
+}
+
+// New creates a server whose gRPC server already serves grpc.health.v1.
+// Register the service APIs on GRPC before calling Run.
+func New(cfg Config, logger *zap.SugaredLogger, opts ...grpc.ServerOption) *Server {
+	s := &Server{
+		cfg:        cfg.withDefaults(),
+		logger:     logger,
+		grpcServer: grpc.NewServer(opts...),
+		health:     health.NewServer(),
+		instanceID: discovery.GenerateInstanceID(cfg.Name),
+	}
+	healthpb.RegisterHealthServer(s.grpcServer, s.health)
+	return s
+}
+
+func (s *Server) GRPC() *grpc.Server {
+	return s.grpcServer
+}
+
+func (s *Server) State() State {
+	return State(s.state.Load())
+}
+
+// Addr returns the listening address once the server is ready.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addr
+}
+
+// AddCheck adds a dependency check. The server reports NOT_SERVING, and stops
+// reporting health to the registry, while any check fails.
+func (s *Server) AddCheck(name string, check Check) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checks = append(s.checks, namedCheck{name: name, check: check})
+}
+
+// Go runs fn in the background once the server starts. Its context is
+// canceled after the gRPC server stopped, and Run waits for it to return.
+func (s *Server) Go(name string, fn func(ctx context.Context)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks = append(s.tasks, task{name: name, run: fn})
+}
+
+// Run serves until ctx is done, a termination signal arrives or the gRPC
+// server fails, then drains and stops. It returns the serve error, if any.
+func (s *Server) Run(ctx context.Context) error {
+	ctx, stopSignals := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	s.setServing(false)
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.cfg.Port))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", s.cfg.Port, err)
+	}
+	s.mu.Lock()
+	s.addr = lis.Addr()
+	tasks := s.tasks
+	s.mu.Unlock()
+	s.logger.Infof("gRPC server listening on %s", lis.Addr().String())
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if err := s.grpcServer.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	bgCtx, bgCancel := context.WithCancel(context.Background())
+	defer bgCancel()
+	var wg sync.WaitGroup
+	for _, t := range tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.logger.Infow("Starting background task", "task", t.name)
+			t.run(bgCtx)
+			s.logger.Infow("Background task stopped", "task", t.name)
+		}()
+	}
+
+	registered, runErr := s.start(ctx, serveErr)
+	if registered {
+		runErr = s.monitor(ctx, serveErr)
+	}
+
+	s.shutdown(registered)
+	bgCancel()
+	wg.Wait()
+	s.state.Store(int32(StateStopped))
+	s.logger.Info("Shutdown complete")
+	return runErr
+}
+
+// start waits for the checks to pass, then registers the instance. It reports
+// whether the instance was registered.
+func (s *Server) start(ctx context.Context, serveErr <-chan error) (bool, error) {
+	ticker := time.NewTicker(s.cfg.HealthInterval)
+	defer ticker.Stop()
+	for !s.runChecks(ctx) {
+		select {
+		case <-ctx.Done():
+			return false, nil
+		case err := <-serveErr:
+			return false, err
+		case <-ticker.C:
+		}
+	}
+
+	port := s.Addr().(*net.TCPAddr).Port
+	serviceAddr := fmt.Sprintf("localhost:%d", port)
+	registerCtx, cancel := context.WithTimeout(ctx, s.cfg.RegisterTimeout)
+	defer cancel()
+	if err := s.cfg.Registry.Register(registerCtx, s.instanceID, s.cfg.Name, serviceAddr, s.cfg.RegisterOptions...); err != nil {
+		return false, fmt.Errorf("failed to register service: %w", err)
+	}
+	s.reportHealth(ctx)
+	s.state.Store(int32(StateReady))
+	s.logger.Infow("Service registered successfully", "id", s.instanceID, "name", s.cfg.Name, "address", serviceAddr)
+	return true, nil
+}
+
+// monitor keeps the health status current until the server must stop.
+func (s *Server) monitor(ctx context.Context, serveErr <-chan error) error {
+	ticker := time.NewTicker(s.cfg.HealthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Received shutdown signal")
+			return nil
+		case err := <-serveErr:
+			if err != nil {
+				s.logger.Errorw("gRPC server failed", "error", err)
+			}
+			return err
+		case <-ticker.C:
+			if s.runChecks(ctx) {
+				s.reportHealth(ctx)
+			}
+		}
+	}
+}
+
+// runChecks runs every check, updates the served health status and reports
+// whether all passed.
+func (s *Server) runChecks(ctx context.Context) bool {
+	s.mu.Lock()
+	checks := s.checks
+	s.mu.Unlock()
+
+	healthy := true
+	for _, c := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, s.cfg.CheckTimeout)
+		err := c.check(checkCtx)
+		cancel()
+		if err != nil {
+			healthy = false
+			s.logger.Warnw("Dependency check failed", "check", c.name, "error", err)
+		}
+	}
+	if ctx.Err() == nil {
+		s.setServing(healthy)
+	}
+	return healthy
+}
+
+func (s *Server) reportHealth(ctx context.Context) {
+	reportCtx, cancel := context.WithTimeout(ctx, s.cfg.CheckTimeout)
+	defer cancel()
+	if err := s.cfg.Registry.ReportHealthState(reportCtx, s.instanceID, s.cfg.Name); err != nil {
+		s.logger.Warnw("Failed to report healthy state", "error", err, "instanceID", s.instanceID)
+	}
+}
+
+// setServing sets the status of the server and of every registered service.
+func (s *Server) setServing(serving bool) {
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	s.health.SetServingStatus("", status)
+	for name := range s.grpcServer.GetServiceInfo() {
+		if name != healthpb.Health_ServiceDesc.ServiceName {
+			s.health.SetServingStatus(name, status)
+		}
+	}
+}
+
+// shutdown drains the server: it reports NOT_SERVING, leaves the registry,
+// and lets in-flight calls finish within the shutdown timeout.
+func (s *Server) shutdown(registered bool) {
+	s.state.Store(int32(StateDraining))
+	s.logger.Info("Initiating graceful shutdown...")
+	s.health.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+
+	if registered {
+		if err := s.cfg.Registry.Deregister(ctx, s.instanceID, s.cfg.Name); err != nil {
+			s.logger.Errorw("Failed to deregister service during shutdown", "error", err, "instanceID", s.instanceID)
+		} else {
+			s.logger.Info("Service deregistered successfully")
+		}
+	}
+
+	// Streams only end when clients disconnect, so force the stop on timeout.
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.logger.Warn("Graceful stop timed out, closing open streams")
+		s.grpcServer.Stop()
+	}
+	s.logger.Info("gRPC server has been stopped")
+}
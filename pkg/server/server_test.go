@@ -0,0 +1,157 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CP-Payne/taskflow/pkg/discovery"
+	"github.com/CP-Payne/taskflow/pkg/discovery/memory"
+	"github.com/CP-Payne/taskflow/pkg/server"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const interval = 10 * time.Millisecond
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(interval)
+	}
+}
+
+func TestServer_Lifecycle(t *testing.T) {
+	registry := memory.NewRegistry()
+	srv := server.New(server.Config{
+		Name:           "test",
+		Registry:       registry,
+		HealthInterval: interval,
+	}, zap.NewNop().Sugar())
+
+	var depErr atomic.Pointer[error]
+	srv.AddCheck("dependency", func(ctx context.Context) error {
+		if err := depErr.Load(); err != nil {
+			return *err
+		}
+		return nil
+	})
+
+	var taskStopped atomic.Bool
+	srv.Go("task", func(ctx context.Context) {
+		<-ctx.Done()
+		taskStopped.Store(true)
+	})
+
+	if srv.State() != server.StateStarting {
+		t.Errorf("expected state starting, got %v", srv.State())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runErr := make(chan error, 1)
+	go func() { runErr <- srv.Run(ctx) }()
+
+	waitFor(t, "ready state", func() bool { return srv.State() == server.StateReady })
+
+	addrs, err := registry.ServiceAddresses(ctx, "test")
+	if err != nil {
+		t.Fatalf("expected the instance to be registered: %v", err)
+	}
+	want := fmt.Sprintf("localhost:%d", srv.Addr().(*net.TCPAddr).Port)
+	if len(addrs) != 1 || addrs[0] != want {
+		t.Fatalf("expected address %s, got %v", want, addrs)
+	}
+
+	conn, err := grpc.NewClient(addrs[0], grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient() failed: %v", err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+	status := func() healthpb.HealthCheckResponse_ServingStatus {
+		res, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			return healthpb.HealthCheckResponse_UNKNOWN
+		}
+		return res.GetStatus()
+	}
+
+	waitFor(t, "SERVING", func() bool { return status() == healthpb.HealthCheckResponse_SERVING })
+
+	// A failing dependency takes the instance out of service until it recovers.
+	failure := errors.New("connection refused")
+	depErr.Store(&failure)
+	waitFor(t, "NOT_SERVING", func() bool { return status() == healthpb.HealthCheckResponse_NOT_SERVING })
+	depErr.Store(nil)
+	waitFor(t, "SERVING", func() bool { return status() == healthpb.HealthCheckResponse_SERVING })
+
+	cancel()
+	select {
+	case err := <-runErr:
+		if err != nil {
+			t.Errorf("Run() failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after cancellation")
+	}
+
+	if srv.State() != server.StateStopped {
+		t.Errorf("expected state stopped, got %v", srv.State())
+	}
+	if !taskStopped.Load() {
+		t.Error("expected the background task to be stopped")
+	}
+	if _, err := registry.ServiceAddresses(context.Background(), "test"); !errors.Is(err, discovery.ErrNotFound) {
+		t.Errorf("expected the instance to be deregistered, got %v", err)
+	}
+}
+
+func TestServer_NotRegisteredUntilChecksPass(t *testing.T) {
+	registry := memory.NewRegistry()
+	srv := server.New(server.Config{
+		Name:           "test",
+		Registry:       registry,
+		HealthInterval: interval,
+	}, zap.NewNop().Sugar())
+
+	var ready atomic.Bool
+	srv.AddCheck("dependency", func(ctx context.Context) error {
+		if !ready.Load() {
+			return errors.New("not ready")
+		}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		srv.Run(ctx)
+		close(done)
+	}()
+
+	time.Sleep(5 * interval)
+	if srv.State() != server.StateStarting {
+		t.Errorf("expected state starting, got %v", srv.State())
+	}
+	if _, err := registry.ServiceAddresses(ctx, "test"); !errors.Is(err, discovery.ErrNotFound) {
+		t.Errorf("expected no registered instance, got %v", err)
+	}
+
+	ready.Store(true)
+	waitFor(t, "ready state", func() bool { return srv.State() == server.StateReady })
+
+	cancel()
+	<-done
+}
@@ -1,3 +1,12 @@
 path "secret/data/jwt/user-service" {
   capabilities = ["read"]
 }
+
+# JWT_SIGNER=transit signs with a Transit key instead of reading the KV key.
+path "transit/keys/jwt-user-service" {
+  capabilities = ["read"]
+}
+
+path "transit/sign/jwt-user-service/sha2-256" {
+  capabilities = ["update"]
+}
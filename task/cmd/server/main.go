@@ -2,25 +2,25 @@ package main
 
 import (
 	"context"
-	"flag"
-	"fmt"
-	"net"
+	"errors"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
-	"github.com/CP-Payne/taskflow/pkg/discovery"
-	"github.com/CP-Payne/taskflow/pkg/discovery/consul"
+	pkgconfig "github.com/CP-Payne/taskflow/pkg/config"
+	"github.com/CP-Payne/taskflow/pkg/discovery/backend"
 	grpcApi "github.com/CP-Payne/taskflow/pkg/gen/task/v1"
+	"github.com/CP-Payne/taskflow/pkg/grpcauth"
+	"github.com/CP-Payne/taskflow/pkg/secrets"
+	"github.com/CP-Payne/taskflow/pkg/server"
+	"github.com/CP-Payne/taskflow/task/config"
+	usergateway "github.com/CP-Payne/taskflow/task/internal/gateway/user"
 	grpchandler "github.com/CP-Payne/taskflow/task/internal/handler/grpc"
 	"github.com/CP-Payne/taskflow/task/internal/publisher"
 	"github.com/CP-Payne/taskflow/task/internal/repository/memory"
 	"github.com/CP-Payne/taskflow/task/internal/service"
-	"github.com/joho/godotenv"
+	"github.com/CP-Payne/taskflow/task/internal/watch"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
-	"google.golang.org/grpc"
 )
 
 const (
@@ -32,32 +32,36 @@ func main() {
 	logger := zap.Must(zap.NewProduction()).Sugar()
 	defer logger.Sync()
 
-	var port int
-	flag.IntVar(&port, "port", 9002, "API handler port")
-	flag.Parse()
-	logger.Infof("Starting the task service on port %d", port)
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, pkgconfig.ErrPrinted) {
+		return
+	} else if err != nil {
+		logger.Fatalw("invalid configuration", "error", err)
+	}
+	logger.Infof("Starting the task service on port %d", cfg.Port)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Load global config
-	if err := godotenv.Load("./config/.env"); err != nil {
-		logger.Fatalw("failed to load global config", "error", err)
-	}
-
-	// TODO: Add registry addr to global config
-	registry, err := consul.NewRegistry("localhost:8500")
+	registry, err := backend.New(cfg.Discovery)
 	if err != nil {
 		logger.Fatalw("failed to create service discovery registry", "error", err)
 	}
+	logger.Infow("Using service discovery backend", "backend", cfg.Discovery.Backend)
 
-	instanceID := discovery.GenerateInstanceID(serviceName)
-	serviceAddr := fmt.Sprintf("localhost:%d", port)
-
-	redisAddr := os.Getenv("REDIS_NOTIFIER_ADDR")
+	// Secret references in the configuration are read from the secrets
+	// providers
+	secretsProvider, err := secrets.New(ctx, cfg.Secrets, logger)
+	if err != nil {
+		logger.Fatalw("failed to create secrets provider", "error", err)
+	}
+	if err := pkgconfig.ResolveSecrets(&cfg, secrets.Resolver(ctx, secretsProvider)); err != nil {
+		logger.Fatalw("failed to resolve secrets", "error", err)
+	}
 
 	rdb := redis.NewClient(&redis.Options{
-		Addr: redisAddr,
+		Addr:     cfg.RedisAddr,
+		Password: cfg.RedisPassword,
 	})
 	_, err = rdb.Ping(ctx).Result()
 	if err != nil {
@@ -68,94 +72,58 @@ func main() {
 
 	redisPublisher := publisher.NewRedisPublisher(rdb, logger)
 
+	// WatchTasks streams are fed from the events this instance publishes
+	hub := watch.NewHub(watch.DefaultHistorySize, watch.DefaultBufferSize)
+	taskPublisher := publisher.NewFanoutPublisher(redisPublisher, hub, logger)
+
 	// TODO: Create config to pass to layers
 	// TODO: Define Handler in main, instead of StartGRPCServer
 	repo := memory.NewInMemory()
-	srv := service.New(repo, logger, redisPublisher)
-
-	grpcServer := grpc.NewServer()
-	taskHandler := grpchandler.NewTaskHandler(srv, logger)
-	grpcApi.RegisterTaskServiceServer(grpcServer, taskHandler)
+	srv := service.New(repo, logger, taskPublisher, hub)
 
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	// Calls are authenticated with the user service, which issued the
+	// tokens. Access tokens need the scope of the method they call.
+	userGateway, err := usergateway.NewGateway(registry, cfg.AuthCacheTTL, usergateway.ClientConfig(), logger)
 	if err != nil {
-		logger.Fatalw("failed to listen", "port", port, "error", err)
+		logger.Fatalw("failed to create user gateway", "error", err)
 	}
-	logger.Infof("gRPC server listening on %s", lis.Addr().String())
-
-	// Register service to consul
-	registerCtx, registerCancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer registerCancel()
-	if err := registry.Register(registerCtx, instanceID, serviceName, serviceAddr); err != nil {
-		logger.Fatalw("failed registering service to consul", "error", err)
-	}
-	logger.Infow("Service registered successfully", "id", instanceID, "name", serviceName, "address", serviceAddr)
-
-	// HealthCheck
-
-	go func(ctx context.Context) {
-		ticker := time.NewTicker(5 * time.Second)
-		defer ticker.Stop()
-		logger.Info("Starting health reporter")
-		for {
-			select {
-			case <-ticker.C:
-				reportCtx, reportCancel := context.WithTimeout(context.Background(), 2*time.Second)
-				if err := registry.ReportHealthState(reportCtx, instanceID, serviceName); err != nil {
-					logger.Warnw("failed to report healthy state", "error", err, "instanceID", instanceID)
-				}
-				reportCancel()
-			case <-ctx.Done():
-				logger.Info("Stopping health reported due to context cancellation")
-				return
-			}
-		}
-	}(ctx)
-
-	shutdownChan := make(chan os.Signal, 1)
-	signal.Notify(shutdownChan, syscall.SIGINT, syscall.SIGTERM)
-
-	errChan := make(chan error, 1)
-
-	go func() {
-		logger.Info("Starting gRPC server...")
-		if err := grpcServer.Serve(lis); err != nil && err != grpc.ErrServerStopped {
-			logger.Errorf("gRPC server failed: %v", err)
-			errChan <- err
-		} else {
-			logger.Info("gRPC server stopped gracefully")
-			close(errChan)
-		}
-	}()
-
-	select {
-	case sig := <-shutdownChan:
-		logger.Infow("Received shutdown signal", "signal", sig)
-	case err := <-errChan:
-		logger.Errorw("gRPC server failed to start or run", "error", err)
-	}
-
-	logger.Info("Initiating graceful shutdown...")
+	defer userGateway.Close()
+
+	read := grpcauth.Rule{Scope: grpcauth.ScopeTasksRead}
+	write := grpcauth.Rule{Scope: grpcauth.ScopeTasksWrite}
+	authInterceptor := grpcauth.New(userGateway, map[string]grpcauth.Rule{
+		grpcApi.TaskService_Create_FullMethodName:               write,
+		grpcApi.TaskService_Update_FullMethodName:               write,
+		grpcApi.TaskService_Assign_FullMethodName:               write,
+		grpcApi.TaskService_Delete_FullMethodName:               write,
+		grpcApi.TaskService_List_FullMethodName:                 read,
+		grpcApi.TaskService_GetByID_FullMethodName:              read,
+		grpcApi.TaskService_ListUnassigned_FullMethodName:       read,
+		grpcApi.TaskService_ListByAssignedUserID_FullMethodName: read,
+		grpcApi.TaskService_ListByUserID_FullMethodName:         read,
+		grpcApi.TaskService_WatchTasks_FullMethodName:           read,
+	}, logger)
+
+	app := server.New(server.Config{
+		Name:            serviceName,
+		Port:            cfg.Port,
+		Registry:        registry,
+		RegisterOptions: cfg.Discovery.RegisterOptions(),
+		ShutdownTimeout: shutdownTimeout,
+	}, logger, authInterceptor.ServerOptions()...)
+	taskHandler := grpchandler.NewTaskHandler(srv, logger)
+	grpcApi.RegisterTaskServiceServer(app.GRPC(), taskHandler)
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
-	defer shutdownCancel()
+	app.AddCheck("redis", func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
+	})
+	app.AddCheck("repository", repo.Ping)
+	app.Go("vault token renewal", secretsProvider.RenewTokens)
 
-	logger.Info("Deregistering service from Consul...")
-	if err := registry.Deregister(shutdownCtx, instanceID, serviceName); err != nil {
-		logger.Errorw("failed to deregister service during shutdown", "error", err, "instanceID", instanceID)
-	} else {
-		logger.Info("Service deregistered successfully")
+	if err := app.Run(ctx); err != nil {
+		logger.Errorw("Task service stopped with error", "error", err)
 	}
 
-	logger.Info("Stopping background tasks (like health check)...")
-	cancel()
-
-	logger.Info("Stopping gRPC server gracefully...")
-	grpcServer.GracefulStop()
-	logger.Info("gRPC server has been stopped.")
-
 	logger.Info("Flushing logs...")
 	_ = logger.Sync()
-
-	logger.Info("Shutdown complete.")
 }
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"time"
+
+	pkgconfig "github.com/CP-Payne/taskflow/pkg/config"
+	"github.com/CP-Payne/taskflow/pkg/discovery/backend"
+	"github.com/CP-Payne/taskflow/pkg/secrets"
+	usergateway "github.com/CP-Payne/taskflow/task/internal/gateway/user"
+)
+
+// envFiles are read for variables missing from the environment.
+var envFiles = []string{"config/.env"}
+
+type Config struct {
+	Port      int            `yaml:"port" flag:"port" usage:"API handler port"`
+	Discovery backend.Config `yaml:"discovery"`
+	// RedisAddr is where task events are published for the notifier.
+	RedisAddr     string `yaml:"redis_addr" env:"REDIS_NOTIFIER_ADDR" required:"true"`
+	RedisPassword string `yaml:"redis_password" env:"REDIS_PASSWORD" secret:"true"`
+	// Secrets resolves secret references such as
+	// "secret:task/redis#password".
+	Secrets secrets.Config `yaml:"secrets"`
+	// AuthCacheTTL is how long bearer tokens verified by the user service
+	// are trusted, and so how long a revoked access token keeps working.
+	AuthCacheTTL time.Duration `yaml:"auth_cache_ttl" env:"AUTH_CACHE_TTL"`
+}
+
+func Default() Config {
+	return Config{
+		Port:         9002,
+		Discovery:    backend.DefaultConfig(),
+		AuthCacheTTL: usergateway.DefaultCacheTTL,
+	}
+}
+
+// Load returns the configuration of the task service. It returns
+// pkgconfig.ErrPrinted when run with --print-config.
+func Load(args []string) (Config, error) {
+	cfg := Default()
+	err := pkgconfig.New("task", envFiles...).Load(&cfg, args)
+	return cfg, err
+}
@@ -0,0 +1,88 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/CP-Payne/taskflow/pkg/discovery"
+	"github.com/CP-Payne/taskflow/pkg/discovery/grpcresolver"
+	gen "github.com/CP-Payne/taskflow/pkg/gen/user/v1"
+	"github.com/CP-Payne/taskflow/pkg/grpcauth"
+	"github.com/CP-Payne/taskflow/pkg/grpcclient"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// DefaultCacheTTL is how long a verified token is trusted without asking the
+// user service again, and so how long a revoked token keeps working.
+const DefaultCacheTTL = 30 * time.Second
+
+// ClientConfig bounds token introspection, which every authenticated call
+// may wait on.
+func ClientConfig() grpcclient.Config {
+	cfg := grpcclient.DefaultConfig()
+	cfg.Methods[gen.User_IntrospectToken_FullMethodName] = grpcclient.MethodPolicy{
+		Timeout:    2 * time.Second,
+		Idempotent: true,
+	}
+	return cfg
+}
+
+// Gateway verifies bearer tokens with the user service, which issued them.
+// It implements grpcauth.Verifier.
+type Gateway struct {
+	logger *zap.SugaredLogger
+	conn   *grpc.ClientConn
+	client gen.UserClient
+	cache  *cache
+}
+
+func NewGateway(registry discovery.Registry, cacheTTL time.Duration, clientConfig grpcclient.Config, logger *zap.SugaredLogger) (*Gateway, error) {
+	opts := append(grpcresolver.DialOptions(registry), grpcclient.DialOptions(clientConfig)...)
+	opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient(grpcresolver.Target("user"), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Gateway{
+		logger: logger,
+		conn:   conn,
+		client: gen.NewUserClient(conn),
+		cache:  newCache(cacheTTL),
+	}, nil
+}
+
+func (g *Gateway) Verify(ctx context.Context, token string) (*grpcauth.Principal, error) {
+	if principal, ok := g.cache.get(token); ok {
+		return principal, nil
+	}
+
+	res, err := g.client.IntrospectToken(ctx, &gen.IntrospectTokenRequest{Token: token})
+	if err != nil {
+		g.logger.Warnw("Failed to introspect token", "error", err)
+		return nil, err
+	}
+	if !res.GetActive() {
+		return nil, grpcauth.ErrInvalidToken
+	}
+	userID, err := uuid.Parse(res.GetUserId())
+	if err != nil {
+		return nil, fmt.Errorf("user service returned an invalid user ID: %w", err)
+	}
+
+	principal := &grpcauth.Principal{
+		UserID:  userID,
+		TokenID: res.GetTokenId(),
+		Scopes:  res.GetScopes(),
+	}
+	g.cache.set(token, principal)
+	return principal, nil
+}
+
+func (g *Gateway) Close() error {
+	return g.conn.Close()
+}
@@ -0,0 +1,89 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CP-Payne/taskflow/pkg/discovery/static"
+	gen "github.com/CP-Payne/taskflow/pkg/gen/user/v1"
+	"github.com/CP-Payne/taskflow/pkg/grpcauth"
+	"github.com/CP-Payne/taskflow/task/internal/gateway/user"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+var userID = uuid.New()
+
+type userServer struct {
+	gen.UnimplementedUserServer
+	calls atomic.Int32
+}
+
+func (s *userServer) IntrospectToken(ctx context.Context, req *gen.IntrospectTokenRequest) (*gen.IntrospectTokenResponse, error) {
+	s.calls.Add(1)
+	if req.GetToken() != "tfp_valid" {
+		return &gen.IntrospectTokenResponse{Active: false}, nil
+	}
+	return &gen.IntrospectTokenResponse{
+		Active:  true,
+		UserId:  userID.String(),
+		TokenId: "valid",
+		Scopes:  []string{grpcauth.ScopeTasksRead},
+	}, nil
+}
+
+func startUserServer(t *testing.T) (*userServer, string) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	srv := &userServer{}
+	s := grpc.NewServer()
+	gen.RegisterUserServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return srv, lis.Addr().String()
+}
+
+func TestGateway_Verify(t *testing.T) {
+	srv, addr := startUserServer(t)
+
+	gtw, err := user.NewGateway(static.NewRegistry(map[string][]string{"user": {addr}}), time.Minute, user.ClientConfig(), zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatalf("NewGateway() failed: %v", err)
+	}
+	defer gtw.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Verified tokens are served from the cache.
+	for i := 0; i < 3; i++ {
+		p, err := gtw.Verify(ctx, "tfp_valid")
+		if err != nil {
+			t.Fatalf("Verify() failed: %v", err)
+		}
+		if p.UserID != userID || p.TokenID != "valid" || !p.HasScope(grpcauth.ScopeTasksRead) || p.HasScope(grpcauth.ScopeTasksWrite) {
+			t.Errorf("unexpected principal %+v", p)
+		}
+	}
+	if got := srv.calls.Load(); got != 1 {
+		t.Errorf("expected 1 call for a cached token, got %d", got)
+	}
+
+	// Refused tokens are asked about every time, so new ones work at once.
+	for i := 0; i < 2; i++ {
+		if _, err := gtw.Verify(ctx, "tfp_revoked"); !errors.Is(err, grpcauth.ErrInvalidToken) {
+			t.Errorf("expected ErrInvalidToken, got %v", err)
+		}
+	}
+	if got := srv.calls.Load(); got != 3 {
+		t.Errorf("expected refused tokens not to be cached, got %d calls", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	api "github.com/CP-Payne/taskflow/pkg/gen/task/v1"
+	"github.com/CP-Payne/taskflow/pkg/grpcauth"
 	"github.com/CP-Payne/taskflow/task/internal/model"
 	"github.com/CP-Payne/taskflow/task/internal/service"
 	"github.com/google/uuid"
@@ -27,42 +28,87 @@ func NewTaskHandler(taskService *service.TaskService, logger *zap.SugaredLogger)
 	}
 }
 
+// callerID returns the authenticated user. A user ID in the request must be
+// the caller's own, since tokens only act for the user they belong to; an
+// empty one defaults to the caller.
+func (h *TaskHandler) callerID(ctx context.Context, method string, requested *api.UUID) (uuid.UUID, error) {
+	caller, ok := grpcauth.FromContext(ctx)
+	if !ok {
+		return uuid.Nil, status.Errorf(codes.Unauthenticated, "authentication required")
+	}
+	if requested.GetValue() == "" {
+		return caller.UserID, nil
+	}
+	userID, err := uuid.Parse(requested.GetValue())
+	if err != nil {
+		h.logger.Warnw(method+" invalid userID", "userID", requested.GetValue(), "error", err)
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "invalid userID")
+	}
+	if userID != caller.UserID {
+		h.logger.Warnw(method+" userID does not match the caller",
+			"userID", userID,
+			"callerID", caller.UserID,
+			"tokenID", caller.TokenID,
+		)
+		return uuid.Nil, status.Errorf(codes.PermissionDenied, "user_id must be the authenticated user")
+	}
+	return userID, nil
+}
+
 func (h *TaskHandler) Create(ctx context.Context, req *api.CreateRequest) (*api.CreateResponse, error) {
-	if req == nil || req.Title == "" || req.UserId == nil {
+	if req == nil || req.Title == "" {
 		h.logger.Warnw("Create validation failed: invalid arguments",
-			"userID", req.UserId.GetValue(),
 			"title", req.GetTitle(),
 		)
 		return nil, status.Errorf(codes.InvalidArgument, "nil request or invalid arguments")
 	}
+	userID, err := h.callerID(ctx, "Create", req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := api.Priority_name[int32(req.GetPriority())]; !ok {
+		h.logger.Warnw("Create validation failed: invalid priority",
+			"priority", req.GetPriority(),
+		)
+		return nil, status.Errorf(codes.InvalidArgument, "invalid priority")
+	}
 
 	task := &model.Task{
 		ID:          uuid.New(),
 		Title:       req.GetTitle(),
 		Description: req.GetDescription(),
 		Status:      model.Pending,
+		Priority:    model.Priority(req.GetPriority()),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	userID, err := uuid.Parse(req.GetUserId().GetValue())
-	if err != nil {
-		h.logger.Errorw("Create: invalid arguements",
-			"userID", req.GetUserId().GetValue(),
-			"error", zap.Error(err),
-		)
+	task.UserID = userID
 
-		return nil, status.Errorf(codes.InvalidArgument, "failed to create task: invalid userID")
+	if req.GetDueDate() != nil {
+		dueDate := req.GetDueDate().AsTime()
+		task.DueDate = &dueDate
 	}
 
-	task.UserID = userID
+	if workspaceStr := req.GetWorkspaceId().GetValue(); workspaceStr != "" {
+		workspaceID, err := uuid.Parse(workspaceStr)
+		if err != nil {
+			h.logger.Warnw("Create: invalid workspaceID",
+				"workspaceID", workspaceStr,
+				"error", err,
+			)
+			return nil, status.Errorf(codes.InvalidArgument, "failed to create task: invalid workspaceID")
+		}
+		task.WorkspaceID = &workspaceID
+	}
 
 	assignedToStr := req.GetAssignedTo().GetValue()
 	if assignedToStr != "" {
 		assignedToID, err := uuid.Parse(assignedToStr)
 		if err != nil {
 			h.logger.Warn("Create: Task assignment failed",
-				"userID", req.GetUserId().GetValue(),
+				"userID", userID,
 				"assignedToID", assignedToStr,
 				"error", err,
 			)
@@ -74,7 +120,7 @@ func (h *TaskHandler) Create(ctx context.Context, req *api.CreateRequest) (*api.
 	task, err = h.taskService.CreateTask(ctx, task)
 	if err != nil {
 		h.logger.Errorw("Internal error during task creation",
-			"userID", req.GetUserId(),
+			"userID", userID,
 			"TaskID", task.ID,
 			zap.Error(err),
 		)
@@ -95,7 +141,11 @@ func (h *TaskHandler) List(ctx context.Context, req *api.ListRequest) (*api.List
 		h.logger.Warnw("List request is nil")
 		return nil, status.Errorf(codes.InvalidArgument, "request cannot be nil")
 	}
-	tasks, err := h.taskService.ListAll(ctx)
+	userID, err := h.callerID(ctx, "List", nil)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := h.taskService.ListVisible(ctx, userID)
 	if err != nil {
 		h.logger.Errorw("Internal error during tasks retrieval",
 			zap.Error(err),
@@ -145,7 +195,11 @@ func (h *TaskHandler) GetByID(ctx context.Context, req *api.GetByIDRequest) (*ap
 
 	}
 
-	task, err := h.taskService.GetByID(ctx, taskID)
+	callerID, err := h.callerID(ctx, "GetByID", nil)
+	if err != nil {
+		return nil, err
+	}
+	task, err := h.taskService.GetVisible(ctx, callerID, taskID)
 	if err != nil {
 		if errors.Is(err, service.ErrNotFound) {
 			h.logger.Warnw("GetByID task not found",
@@ -164,20 +218,14 @@ func (h *TaskHandler) GetByID(ctx context.Context, req *api.GetByIDRequest) (*ap
 }
 
 func (h *TaskHandler) ListByAssignedUserID(ctx context.Context, req *api.ListByAssignedUserIDRequest) (*api.ListByAssignedUserIDResponse, error) {
-	if req == nil || req.GetUserId() == nil {
-		h.logger.Warnw("ListByAssignedUserID validation failed: invalid arguments",
-			"userID", req.GetUserId(),
-		)
-		return nil, status.Errorf(codes.InvalidArgument, "nil request or invalid arguments")
+	if req == nil {
+		h.logger.Warnw("ListByAssignedUserID request is nil")
+		return nil, status.Errorf(codes.InvalidArgument, "request cannot be nil")
 	}
 
-	userID, err := uuid.Parse(req.GetUserId().GetValue())
+	userID, err := h.callerID(ctx, "ListByAssignedUserID", req.GetUserId())
 	if err != nil {
-		h.logger.Warnw("ListByAssignedUserID invalid userID",
-			"userID", req.GetUserId().GetValue(),
-			"error", err,
-		)
-		return nil, status.Errorf(codes.InvalidArgument, "invalid userID")
+		return nil, err
 	}
 
 	tasks, err := h.taskService.ListByAssignedUserID(ctx, userID)
@@ -201,20 +249,14 @@ func (h *TaskHandler) ListByAssignedUserID(ctx context.Context, req *api.ListByA
 }
 
 func (h *TaskHandler) ListByUserID(ctx context.Context, req *api.ListByUserIDRequest) (*api.ListByUserIDResponse, error) {
-	if req == nil || req.GetUserId() == nil {
-		h.logger.Warnw("ListByUserID validation failed: invalid arguments",
-			"userID", req.GetUserId(),
-		)
-		return nil, status.Errorf(codes.InvalidArgument, "nil request or invalid arguments")
+	if req == nil {
+		h.logger.Warnw("ListByUserID request is nil")
+		return nil, status.Errorf(codes.InvalidArgument, "request cannot be nil")
 	}
 
-	userID, err := uuid.Parse(req.GetUserId().GetValue())
+	userID, err := h.callerID(ctx, "ListByUserID", req.GetUserId())
 	if err != nil {
-		h.logger.Warnw("ListByUserID invalid userID",
-			"userID", req.GetUserId().GetValue(),
-			"error", err,
-		)
-		return nil, status.Errorf(codes.InvalidArgument, "invalid userID")
+		return nil, err
 	}
 
 	tasks, err := h.taskService.ListByUserID(ctx, userID)
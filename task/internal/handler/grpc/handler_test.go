@@ -0,0 +1,166 @@
+package grpc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CP-Payne/taskflow/pkg/events"
+	api "github.com/CP-Payne/taskflow/pkg/gen/task/v1"
+	"github.com/CP-Payne/taskflow/pkg/grpcauth"
+	grpchandler "github.com/CP-Payne/taskflow/task/internal/handler/grpc"
+	"github.com/CP-Payne/taskflow/task/internal/model"
+	"github.com/CP-Payne/taskflow/task/internal/repository/memory"
+	"github.com/CP-Payne/taskflow/task/internal/service"
+	"github.com/CP-Payne/taskflow/task/internal/watch"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type nopPublisher struct{}
+
+func (nopPublisher) PublishTaskAssigned(context.Context, *events.TaskAssignedEvent) error { return nil }
+func (nopPublisher) PublishTaskCreated(context.Context, *events.TaskCreatedEvent) error   { return nil }
+func (nopPublisher) PublishTaskUpdated(context.Context, *events.TaskUpdatedEvent) error   { return nil }
+func (nopPublisher) PublishTaskDeleted(context.Context, *events.TaskDeletedEvent) error   { return nil }
+
+func newHandler() *grpchandler.TaskHandler {
+	logger := zap.NewNop().Sugar()
+	srv := service.New(memory.NewInMemory(), logger, nopPublisher{}, watch.NewHub(0, 0))
+	return grpchandler.NewTaskHandler(srv, logger)
+}
+
+func as(userID uuid.UUID, scopes ...string) context.Context {
+	return grpcauth.NewContext(context.Background(), &grpcauth.Principal{UserID: userID, TokenID: "t", Scopes: scopes})
+}
+
+func TestTaskHandler_CallerMustMatchUserID(t *testing.T) {
+	h := newHandler()
+	alice, bob := uuid.New(), uuid.New()
+
+	// Bob's task, unassigned, and one he assigned to himself
+	created, err := h.Create(as(bob), &api.CreateRequest{Title: "bob's", UserId: model.UuidToProtoUUID(bob)})
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	bobsTask := created.GetTask().GetId()
+	created, err = h.Create(as(bob), &api.CreateRequest{Title: "private", AssignedTo: model.UuidToProtoUUID(bob)})
+	if err != nil {
+		t.Fatalf("Create() without user_id failed: %v", err)
+	}
+	if got := created.GetTask().GetUserId().GetValue(); got != bob.String() {
+		t.Fatalf("expected the task to default to the caller, got %s", got)
+	}
+	privateTask := created.GetTask().GetId()
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context) error
+		want codes.Code
+	}{
+		{
+			name: "create as another user",
+			call: func(ctx context.Context) error {
+				_, err := h.Create(ctx, &api.CreateRequest{Title: "forged", UserId: model.UuidToProtoUUID(bob)})
+				return err
+			},
+			want: codes.PermissionDenied,
+		},
+		{
+			name: "list another user's tasks",
+			call: func(ctx context.Context) error {
+				_, err := h.ListByUserID(ctx, &api.ListByUserIDRequest{UserId: model.UuidToProtoUUID(bob)})
+				return err
+			},
+			want: codes.PermissionDenied,
+		},
+		{
+			name: "list another user's assignments",
+			call: func(ctx context.Context) error {
+				_, err := h.ListByAssignedUserID(ctx, &api.ListByAssignedUserIDRequest{UserId: model.UuidToProtoUUID(bob)})
+				return err
+			},
+			want: codes.PermissionDenied,
+		},
+		{
+			name: "update another user's task",
+			call: func(ctx context.Context) error {
+				title := "taken"
+				_, err := h.Update(ctx, &api.UpdateRequest{TaskId: bobsTask, Title: &title})
+				return err
+			},
+			want: codes.PermissionDenied,
+		},
+		{
+			name: "delete another user's task",
+			call: func(ctx context.Context) error {
+				_, err := h.Delete(ctx, &api.DeleteRequest{TaskId: bobsTask})
+				return err
+			},
+			want: codes.PermissionDenied,
+		},
+		{
+			name: "assign another user's task to them",
+			call: func(ctx context.Context) error {
+				_, err := h.Assign(ctx, &api.AssignRequest{TaskId: bobsTask, UserId: model.UuidToProtoUUID(bob)})
+				return err
+			},
+			want: codes.PermissionDenied,
+		},
+		{
+			name: "get a task assigned to someone else",
+			call: func(ctx context.Context) error {
+				_, err := h.GetByID(ctx, &api.GetByIDRequest{TaskId: privateTask})
+				return err
+			},
+			want: codes.NotFound,
+		},
+		{
+			name: "claim an unassigned task",
+			call: func(ctx context.Context) error {
+				_, err := h.Assign(ctx, &api.AssignRequest{TaskId: bobsTask, UserId: model.UuidToProtoUUID(alice)})
+				return err
+			},
+			want: codes.OK,
+		},
+		{
+			name: "create as self",
+			call: func(ctx context.Context) error {
+				_, err := h.Create(ctx, &api.CreateRequest{Title: "mine", UserId: model.UuidToProtoUUID(alice)})
+				return err
+			},
+			want: codes.OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(tt.call(as(alice, grpcauth.ScopeTasksWrite))); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	// Listing shows alice her own and claimed tasks, not bob's private one
+	list, err := h.List(as(alice), &api.ListRequest{})
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	for _, task := range list.GetTasks() {
+		if task.GetId().GetValue() == privateTask.GetValue() {
+			t.Errorf("List() returned a task assigned to another user")
+		}
+	}
+	if len(list.GetTasks()) != 2 {
+		t.Errorf("expected 2 visible tasks, got %d", len(list.GetTasks()))
+	}
+}
+
+func TestTaskHandler_RequiresPrincipal(t *testing.T) {
+	h := newHandler()
+	_, err := h.Create(context.Background(), &api.CreateRequest{Title: "anonymous"})
+	if got := status.Code(err); got != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated, got %v", got)
+	}
+}
@@ -0,0 +1,214 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	api "github.com/CP-Payne/taskflow/pkg/gen/task/v1"
+	"github.com/CP-Payne/taskflow/task/internal/model"
+	"github.com/CP-Payne/taskflow/task/internal/service"
+	"github.com/CP-Payne/taskflow/task/internal/watch"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (h *TaskHandler) Update(ctx context.Context, req *api.UpdateRequest) (*api.UpdateResponse, error) {
+	taskID, err := uuid.Parse(req.GetTaskId().GetValue())
+	if err != nil {
+		h.logger.Warnw("Update invalid taskID",
+			"taskID", req.GetTaskId().GetValue(),
+			"error", err,
+		)
+		return nil, status.Errorf(codes.InvalidArgument, "invalid taskID")
+	}
+
+	update := service.TaskUpdate{
+		Title:       req.Title,
+		Description: req.Description,
+	}
+	if req.Status != nil {
+		if _, ok := api.Status_name[int32(req.GetStatus())]; !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid status")
+		}
+		s := model.Status(req.GetStatus())
+		update.Status = &s
+	}
+	if req.Priority != nil {
+		if _, ok := api.Priority_name[int32(req.GetPriority())]; !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid priority")
+		}
+		p := model.Priority(req.GetPriority())
+		update.Priority = &p
+	}
+	if req.GetDueDate() != nil {
+		dueDate := req.GetDueDate().AsTime()
+		update.DueDate = &dueDate
+	}
+
+	callerID, err := h.callerID(ctx, "Update", nil)
+	if err != nil {
+		return nil, err
+	}
+	task, err := h.taskService.UpdateTask(ctx, callerID, taskID, update)
+	if err != nil {
+		return nil, h.taskError("Update", taskID, err)
+	}
+
+	h.logger.Infow("Task updated successfully", "taskID", task.ID)
+	return &api.UpdateResponse{Task: task.ToProto()}, nil
+}
+
+func (h *TaskHandler) Assign(ctx context.Context, req *api.AssignRequest) (*api.AssignResponse, error) {
+	taskID, err := uuid.Parse(req.GetTaskId().GetValue())
+	if err != nil {
+		h.logger.Warnw("Assign invalid taskID",
+			"taskID", req.GetTaskId().GetValue(),
+			"error", err,
+		)
+		return nil, status.Errorf(codes.InvalidArgument, "invalid taskID")
+	}
+	userID, err := uuid.Parse(req.GetUserId().GetValue())
+	if err != nil {
+		h.logger.Warnw("Assign invalid userID",
+			"userID", req.GetUserId().GetValue(),
+			"error", err,
+		)
+		return nil, status.Errorf(codes.InvalidArgument, "invalid userID")
+	}
+
+	callerID, err := h.callerID(ctx, "Assign", nil)
+	if err != nil {
+		return nil, err
+	}
+	task, err := h.taskService.AssignTask(ctx, callerID, taskID, userID)
+	if err != nil {
+		return nil, h.taskError("Assign", taskID, err)
+	}
+
+	h.logger.Infow("Task assigned successfully", "taskID", task.ID, "userID", userID)
+	return &api.AssignResponse{Task: task.ToProto()}, nil
+}
+
+func (h *TaskHandler) Delete(ctx context.Context, req *api.DeleteRequest) (*api.DeleteResponse, error) {
+	taskID, err := uuid.Parse(req.GetTaskId().GetValue())
+	if err != nil {
+		h.logger.Warnw("Delete invalid taskID",
+			"taskID", req.GetTaskId().GetValue(),
+			"error", err,
+		)
+		return nil, status.Errorf(codes.InvalidArgument, "invalid taskID")
+	}
+
+	callerID, err := h.callerID(ctx, "Delete", nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.taskService.DeleteTask(ctx, callerID, taskID); err != nil {
+		return nil, h.taskError("Delete", taskID, err)
+	}
+
+	h.logger.Infow("Task deleted successfully", "taskID", taskID)
+	return &api.DeleteResponse{}, nil
+}
+
+func (h *TaskHandler) WatchTasks(req *api.WatchTasksRequest, stream api.TaskService_WatchTasksServer) error {
+	filter, err := filterFromProto(req)
+	if err != nil {
+		h.logger.Warnw("WatchTasks validation failed", "error", err)
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	callerID, err := h.callerID(stream.Context(), "WatchTasks", nil)
+	if err != nil {
+		return err
+	}
+	filter.VisibleTo = &callerID
+
+	var resumeAfter *watch.Position
+	if token := req.GetResumeToken(); token != "" {
+		pos, err := watch.ParsePosition(token)
+		if err != nil {
+			h.logger.Warnw("WatchTasks validation failed", "error", err)
+			return status.Errorf(codes.InvalidArgument, "invalid resume_token")
+		}
+		resumeAfter = &pos
+	}
+
+	sub, err := h.taskService.WatchTasks(filter, resumeAfter)
+	if err != nil {
+		if errors.Is(err, watch.ErrResumeUnavailable) {
+			return status.Errorf(codes.OutOfRange, "cannot resume after %s, list tasks and watch again", req.GetResumeToken())
+		}
+		h.logger.Errorw("WatchTasks internal error", "error", err)
+		return status.Errorf(codes.Internal, "internal server error")
+	}
+	defer sub.Close()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-sub.Done():
+			if errors.Is(sub.Err(), watch.ErrSlowConsumer) {
+				h.logger.Warnw("WatchTasks consumer too slow, disconnecting")
+				return status.Errorf(codes.ResourceExhausted, "consumer too slow, resume from the last received sequence")
+			}
+			return nil
+		case ev := <-sub.Events():
+			if err := stream.Send(eventToProto(&ev)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// taskError maps service errors to gRPC status errors.
+func (h *TaskHandler) taskError(method string, taskID uuid.UUID, err error) error {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		h.logger.Warnw(method+" task not found", "taskID", taskID.String())
+		return status.Errorf(codes.NotFound, "resource not found")
+	case errors.Is(err, service.ErrInvalidArgument):
+		return status.Errorf(codes.InvalidArgument, "invalid arguments")
+	case errors.Is(err, service.ErrPermissionDenied):
+		h.logger.Warnw(method+" permission denied", "taskID", taskID.String())
+		return status.Errorf(codes.PermissionDenied, "permission denied")
+	default:
+		h.logger.Errorw(method+" internal error", "taskID", taskID.String(), "error", err)
+		return status.Errorf(codes.Internal, "internal server error")
+	}
+}
+
+func filterFromProto(req *api.WatchTasksRequest) (watch.Filter, error) {
+	var filter watch.Filter
+	for _, f := range []struct {
+		name  string
+		value string
+		dst   **uuid.UUID
+	}{
+		{"assignedTo", req.GetAssignedTo().GetValue(), &filter.AssignedTo},
+		{"createdBy", req.GetCreatedBy().GetValue(), &filter.CreatedBy},
+		{"workspaceID", req.GetWorkspaceId().GetValue(), &filter.WorkspaceID},
+	} {
+		if f.value == "" {
+			continue
+		}
+		id, err := uuid.Parse(f.value)
+		if err != nil {
+			return watch.Filter{}, errors.New("invalid " + f.name)
+		}
+		*f.dst = &id
+	}
+	return filter, nil
+}
+
+func eventToProto(ev *watch.Event) *api.TaskEvent {
+	return &api.TaskEvent{
+		Sequence:    ev.Seq,
+		ResumeToken: ev.Position().String(),
+		Type:        api.TaskEventType(ev.Type),
+		Task:        ev.Task.ToProto(),
+		OccurredAt:  timestamppb.New(ev.OccurredAt),
+	}
+}
@@ -1,11 +1,13 @@
 package model
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/CP-Payne/taskflow/pkg/events"
 	api "github.com/CP-Payne/taskflow/pkg/gen/task/v1"
 )
 
@@ -17,6 +19,61 @@ const (
 	Completed
 )
 
+func (s Status) String() string {
+	switch s {
+	case InProgress:
+		return "in_progress"
+	case Pending:
+		return "pending"
+	case Completed:
+		return "completed"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseStatus is the inverse of Status.String.
+func ParseStatus(s string) (Status, error) {
+	for _, status := range []Status{InProgress, Pending, Completed} {
+		if status.String() == s {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown status %q", s)
+}
+
+type Priority int
+
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityMedium:
+		return "medium"
+	case PriorityHigh:
+		return "high"
+	default:
+		return "none"
+	}
+}
+
+// ParsePriority is the inverse of Priority.String.
+func ParsePriority(s string) (Priority, error) {
+	for _, priority := range []Priority{PriorityNone, PriorityLow, PriorityMedium, PriorityHigh} {
+		if priority.String() == s {
+			return priority, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown priority %q", s)
+}
+
 type Task struct {
 	ID          uuid.UUID
 	UserID      uuid.UUID
@@ -24,15 +81,38 @@ type Task struct {
 	Description string
 	Status      Status
 	AssignedTo  *uuid.UUID
+	WorkspaceID *uuid.UUID
+	Priority    Priority
+	DueDate     *time.Time
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
 
+// VisibleTo reports whether the user may see the task: they created it, it
+// is assigned to them, or it is unassigned and open to be claimed.
+func (t *Task) VisibleTo(userID uuid.UUID) bool {
+	return t.UserID == userID || t.AssignedTo == nil || *t.AssignedTo == userID
+}
+
+// EditableBy reports whether the user may update the task, which its
+// creator and assignee can.
+func (t *Task) EditableBy(userID uuid.UUID) bool {
+	return t.UserID == userID || (t.AssignedTo != nil && *t.AssignedTo == userID)
+}
+
 func (t *Task) ToProto() *api.Task {
 	var assignedTo *api.UUID
 	if t.AssignedTo != nil {
 		assignedTo = UuidToProtoUUID(*t.AssignedTo)
 	}
+	var workspaceID *api.UUID
+	if t.WorkspaceID != nil {
+		workspaceID = UuidToProtoUUID(*t.WorkspaceID)
+	}
+	var dueDate *timestamppb.Timestamp
+	if t.DueDate != nil {
+		dueDate = timestamppb.New(*t.DueDate)
+	}
 	return &api.Task{
 		Id:          UuidToProtoUUID(t.ID),
 		UserId:      UuidToProtoUUID(t.UserID),
@@ -42,7 +122,79 @@ func (t *Task) ToProto() *api.Task {
 		AssignedTo:  assignedTo,
 		CreatedAt:   timestamppb.New(t.CreatedAt),
 		UpdatedAt:   timestamppb.New(t.UpdatedAt),
+		Priority:    api.Priority(t.Priority),
+		DueDate:     dueDate,
+		WorkspaceId: workspaceID,
+	}
+}
+
+// Snapshot returns the task as carried in published events.
+func (t *Task) Snapshot() *events.TaskSnapshot {
+	snapshot := &events.TaskSnapshot{
+		ID:          t.ID.String(),
+		Title:       t.Title,
+		Description: t.Description,
+		CreatedBy:   t.UserID.String(),
+		Status:      t.Status.String(),
+		Priority:    t.Priority.String(),
+		DueDate:     t.DueDate,
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+	}
+	if t.AssignedTo != nil {
+		snapshot.AssignedTo = t.AssignedTo.String()
+	}
+	if t.WorkspaceID != nil {
+		snapshot.WorkspaceID = t.WorkspaceID.String()
+	}
+	return snapshot
+}
+
+// TaskFromSnapshot is the inverse of Snapshot.
+func TaskFromSnapshot(snapshot *events.TaskSnapshot) (*Task, error) {
+	id, err := uuid.Parse(snapshot.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid task id: %w", err)
+	}
+	createdBy, err := uuid.Parse(snapshot.CreatedBy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid creator id: %w", err)
+	}
+	status, err := ParseStatus(snapshot.Status)
+	if err != nil {
+		return nil, err
+	}
+	priority, err := ParsePriority(snapshot.Priority)
+	if err != nil {
+		return nil, err
+	}
+
+	task := &Task{
+		ID:          id,
+		UserID:      createdBy,
+		Title:       snapshot.Title,
+		Description: snapshot.Description,
+		Status:      status,
+		Priority:    priority,
+		DueDate:     snapshot.DueDate,
+		CreatedAt:   snapshot.CreatedAt,
+		UpdatedAt:   snapshot.UpdatedAt,
+	}
+	if snapshot.AssignedTo != "" {
+		assignedTo, err := uuid.Parse(snapshot.AssignedTo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid assignee id: %w", err)
+		}
+		task.AssignedTo = &assignedTo
+	}
+	if snapshot.WorkspaceID != "" {
+		workspaceID, err := uuid.Parse(snapshot.WorkspaceID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace id: %w", err)
+		}
+		task.WorkspaceID = &workspaceID
 	}
+	return task, nil
 }
 
 func UuidToProtoUUID(id uuid.UUID) *api.UUID {
@@ -0,0 +1,53 @@
+package publisher
+
+import (
+	"context"
+
+	"github.com/CP-Payne/taskflow/pkg/events"
+	"github.com/CP-Payne/taskflow/task/internal/model"
+	"github.com/CP-Payne/taskflow/task/internal/watch"
+	"go.uber.org/zap"
+)
+
+// FanoutPublisher hands every event to the watch hub of this instance before
+// passing it on, so WatchTasks streams see exactly what is published.
+type FanoutPublisher struct {
+	next   Publisher
+	hub    *watch.Hub
+	logger *zap.SugaredLogger
+}
+
+func NewFanoutPublisher(next Publisher, hub *watch.Hub, logger *zap.SugaredLogger) *FanoutPublisher {
+	return &FanoutPublisher{next: next, hub: hub, logger: logger}
+}
+
+func (p *FanoutPublisher) PublishTaskCreated(ctx context.Context, event *events.TaskCreatedEvent) error {
+	p.broadcast(watch.Created, &event.Task)
+	return p.next.PublishTaskCreated(ctx, event)
+}
+
+func (p *FanoutPublisher) PublishTaskUpdated(ctx context.Context, event *events.TaskUpdatedEvent) error {
+	p.broadcast(watch.Updated, &event.Task)
+	return p.next.PublishTaskUpdated(ctx, event)
+}
+
+func (p *FanoutPublisher) PublishTaskAssigned(ctx context.Context, event *events.TaskAssignedEvent) error {
+	if event.Task != nil {
+		p.broadcast(watch.Assigned, event.Task)
+	}
+	return p.next.PublishTaskAssigned(ctx, event)
+}
+
+func (p *FanoutPublisher) PublishTaskDeleted(ctx context.Context, event *events.TaskDeletedEvent) error {
+	p.broadcast(watch.Deleted, &event.Task)
+	return p.next.PublishTaskDeleted(ctx, event)
+}
+
+func (p *FanoutPublisher) broadcast(eventType watch.EventType, snapshot *events.TaskSnapshot) {
+	task, err := model.TaskFromSnapshot(snapshot)
+	if err != nil {
+		p.logger.Errorw("Failed to convert task snapshot for watchers", "taskID", snapshot.ID, "error", err)
+		return
+	}
+	p.hub.Publish(eventType, *task)
+}
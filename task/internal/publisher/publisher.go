@@ -8,4 +8,7 @@ import (
 
 type Publisher interface {
 	PublishTaskAssigned(ctx context.Context, event *events.TaskAssignedEvent) error
+	PublishTaskCreated(ctx context.Context, event *events.TaskCreatedEvent) error
+	PublishTaskUpdated(ctx context.Context, event *events.TaskUpdatedEvent) error
+	PublishTaskDeleted(ctx context.Context, event *events.TaskDeletedEvent) error
 }
@@ -8,6 +8,8 @@ import (
 	"go.uber.org/zap"
 )
 
+const producerName = "task-service"
+
 type RedisPublisher struct {
 	rdb    *redis.Client
 	logger *zap.SugaredLogger
@@ -17,22 +19,55 @@ func NewRedisPublisher(rdb *redis.Client, logger *zap.SugaredLogger) *RedisPubli
 	return &RedisPublisher{rdb: rdb, logger: logger}
 }
 
+// PublishTaskAssigned publishes the enveloped event, and the bare event on
+// the legacy channel for subscribers that predate the envelope format.
 func (p *RedisPublisher) PublishTaskAssigned(ctx context.Context, event *events.TaskAssignedEvent) error {
+	if err := p.publish(ctx, events.ChannelTaskAssigned, event); err != nil {
+		return err
+	}
+	return p.publishLegacy(ctx, event)
+}
+
+func (p *RedisPublisher) PublishTaskCreated(ctx context.Context, event *events.TaskCreatedEvent) error {
+	return p.publish(ctx, events.ChannelTaskCreated, event)
+}
+
+func (p *RedisPublisher) PublishTaskUpdated(ctx context.Context, event *events.TaskUpdatedEvent) error {
+	return p.publish(ctx, events.ChannelTaskUpdated, event)
+}
+
+func (p *RedisPublisher) PublishTaskDeleted(ctx context.Context, event *events.TaskDeletedEvent) error {
+	return p.publish(ctx, events.ChannelTaskDeleted, event)
+}
+
+func (p *RedisPublisher) publishLegacy(ctx context.Context, event *events.TaskAssignedEvent) error {
 	payload, err := event.Marshal()
 	if err != nil {
-		p.logger.Errorw("Failed to marshal TaskAssignedEvent", "error", zap.Error(err))
-		// log.Printf("ERROR: Failed to marshal TaskAssignedEvent: %v", err)
+		p.logger.Errorw("Failed to encode legacy event", "type", event.EventType(), "error", err)
+		return err
+	}
+
+	err = p.rdb.Publish(ctx, events.ChannelTaskAssignedLegacy, payload).Err()
+	if err != nil {
+		p.logger.Errorw("Failed to publish legacy event", "type", event.EventType(), "error", err, "channel", events.ChannelTaskAssignedLegacy)
+		return err
+	}
+	return nil
+}
+
+func (p *RedisPublisher) publish(ctx context.Context, channel string, event events.Event) error {
+	payload, err := events.DefaultRegistry.Encode(event, events.WithProducer(producerName))
+	if err != nil {
+		p.logger.Errorw("Failed to encode event", "type", event.EventType(), "error", err)
 		return err
 	}
 
-	err = p.rdb.Publish(ctx, events.ChannelTaskAssigned, payload).Err()
+	err = p.rdb.Publish(ctx, channel, payload).Err()
 	if err != nil {
-		p.logger.Errorw("Failed to publish TaskAssignedEvent", "error", err, "channel", events.ChannelTaskAssigned)
-		// log.Printf("ERROR: Failed to publish TaskAssignedEvent to %s: %v", events.ChannelTaskAssigned, err)
+		p.logger.Errorw("Failed to publish event", "type", event.EventType(), "error", err, "channel", channel)
 		return err
 	}
 
-	p.logger.Infow("Published TaskAssignedEvent", "channel", events.ChannelTaskAssigned, "event", event)
-	// log.Printf("Published TaskAssignedEvent to %s: %+v", events.ChannelTaskAssigned, event)
+	p.logger.Infow("Published event", "type", event.EventType(), "channel", channel, "event", event)
 	return nil
 }
@@ -88,3 +88,30 @@ func (r *MemoryRepository) ListUnassigned(ctx context.Context) ([]model.Task, er
 	}
 	return taskList, nil
 }
+
+func (r *MemoryRepository) Update(ctx context.Context, task *model.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.task[task.ID]; !ok {
+		return repository.ErrNotFound
+	}
+	updated := *task
+	r.task[task.ID] = &updated
+	return nil
+}
+
+func (r *MemoryRepository) Delete(ctx context.Context, taskID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.task[taskID]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.task, taskID)
+	return nil
+}
+
+func (r *MemoryRepository) Ping(ctx context.Context) error {
+	return nil
+}
@@ -18,6 +18,9 @@ type TaskRepository interface {
 	ListByAssignedUserID(ctx context.Context, userID uuid.UUID) ([]model.Task, error)
 	ListByUserID(ctx context.Context, userID uuid.UUID) ([]model.Task, error)
 	ListUnassigned(ctx context.Context) ([]model.Task, error)
-	// TODO: Update Task Status
-	// TODO: Assign Unassigned task to user
+	// Update replaces a stored task, returning ErrNotFound if it does not exist.
+	Update(ctx context.Context, task *model.Task) error
+	Delete(ctx context.Context, taskID uuid.UUID) error
+	// Ping reports whether the storage is reachable.
+	Ping(ctx context.Context) error
 }
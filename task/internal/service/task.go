@@ -3,40 +3,62 @@ package service
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/CP-Payne/taskflow/pkg/events"
 	"github.com/CP-Payne/taskflow/task/internal/model"
 	"github.com/CP-Payne/taskflow/task/internal/publisher"
 	"github.com/CP-Payne/taskflow/task/internal/repository"
+	"github.com/CP-Payne/taskflow/task/internal/watch"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
 var (
-	ErrNotFound = errors.New("resource not found")
-	ErrInternal = errors.New("internal server error")
+	ErrNotFound        = errors.New("resource not found")
+	ErrInvalidArgument = errors.New("invalid argument")
+	ErrInternal        = errors.New("internal server error")
+	// ErrPermissionDenied is returned when the caller may see a task but not
+	// make the change. Tasks they may not see are ErrNotFound.
+	ErrPermissionDenied = errors.New("permission denied")
 )
 
 type TaskService struct {
 	repo      repository.TaskRepository
 	logger    *zap.SugaredLogger
 	publisher publisher.Publisher
+	hub       *watch.Hub
 }
 
-func New(repo repository.TaskRepository, logger *zap.SugaredLogger, publisher publisher.Publisher) *TaskService {
+func New(repo repository.TaskRepository, logger *zap.SugaredLogger, publisher publisher.Publisher, hub *watch.Hub) *TaskService {
 	return &TaskService{
 		repo:      repo,
 		logger:    logger,
 		publisher: publisher,
+		hub:       hub,
 	}
 }
 
+// TaskUpdate holds the fields to change in UpdateTask. Nil fields are kept.
+type TaskUpdate struct {
+	Title       *string
+	Description *string
+	Status      *model.Status
+	Priority    *model.Priority
+	DueDate     *time.Time
+}
+
 func (s *TaskService) CreateTask(ctx context.Context, task *model.Task) (*model.Task, error) {
 	task, err := s.repo.Create(ctx, task)
 	if err != nil {
 		return &model.Task{}, ErrInternal
 	}
 
+	snapshot := task.Snapshot()
+	if err := s.publisher.PublishTaskCreated(ctx, &events.TaskCreatedEvent{Task: *snapshot}); err != nil {
+		s.logger.Warnw("Failed to publish task created event", "taskID", task.ID, "error", err)
+	}
+
 	if task.AssignedTo != nil {
 		if err := uuid.Validate(task.AssignedTo.String()); err != nil {
 			return &model.Task{}, err
@@ -44,18 +66,26 @@ func (s *TaskService) CreateTask(ctx context.Context, task *model.Task) (*model.
 		s.publisher.PublishTaskAssigned(ctx, &events.TaskAssignedEvent{
 			TaskID: task.ID.String(),
 			UserID: task.AssignedTo.String(),
+			Task:   snapshot,
 		})
 	}
 	return task, nil
 }
 
-func (s *TaskService) ListAll(ctx context.Context) ([]model.Task, error) {
+// ListVisible returns the tasks the user may see.
+func (s *TaskService) ListVisible(ctx context.Context, userID uuid.UUID) ([]model.Task, error) {
 	tasks, err := s.repo.List(ctx)
 	if err != nil {
 		return []model.Task{}, err
 	}
 
-	return tasks, nil
+	visible := make([]model.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if task.VisibleTo(userID) {
+			visible = append(visible, task)
+		}
+	}
+	return visible, nil
 }
 
 func (s *TaskService) ListAllUnassigned(ctx context.Context) ([]model.Task, error) {
@@ -66,6 +96,18 @@ func (s *TaskService) ListAllUnassigned(ctx context.Context) ([]model.Task, erro
 	return tasks, nil
 }
 
+// GetVisible returns the task if the caller may see it.
+func (s *TaskService) GetVisible(ctx context.Context, callerID, taskID uuid.UUID) (*model.Task, error) {
+	task, err := s.GetByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if !task.VisibleTo(callerID) {
+		return nil, ErrNotFound
+	}
+	return task, nil
+}
+
 func (s *TaskService) GetByID(ctx context.Context, taskID uuid.UUID) (*model.Task, error) {
 	task, err := s.repo.GetByID(ctx, taskID)
 	if err != nil {
@@ -100,3 +142,113 @@ func (s *TaskService) ListByUserID(ctx context.Context, userID uuid.UUID) ([]mod
 
 	return userTasks, nil
 }
+
+// UpdateTask changes the task for its creator or assignee.
+func (s *TaskService) UpdateTask(ctx context.Context, callerID, taskID uuid.UUID, update TaskUpdate) (*model.Task, error) {
+	task, err := s.GetVisible(ctx, callerID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if !task.EditableBy(callerID) {
+		return nil, ErrPermissionDenied
+	}
+
+	updated := *task
+	if update.Title != nil {
+		if *update.Title == "" {
+			return nil, ErrInvalidArgument
+		}
+		updated.Title = *update.Title
+	}
+	if update.Description != nil {
+		updated.Description = *update.Description
+	}
+	if update.Status != nil {
+		updated.Status = *update.Status
+	}
+	if update.Priority != nil {
+		updated.Priority = *update.Priority
+	}
+	if update.DueDate != nil {
+		updated.DueDate = update.DueDate
+	}
+	updated.UpdatedAt = time.Now()
+
+	if err := s.save(ctx, &updated); err != nil {
+		return nil, err
+	}
+
+	if err := s.publisher.PublishTaskUpdated(ctx, &events.TaskUpdatedEvent{Task: *updated.Snapshot()}); err != nil {
+		s.logger.Warnw("Failed to publish task updated event", "taskID", updated.ID, "error", err)
+	}
+	return &updated, nil
+}
+
+// AssignTask assigns the task to userID. Its creator may assign it to anyone,
+// other users may only claim an unassigned task for themselves.
+func (s *TaskService) AssignTask(ctx context.Context, callerID, taskID, userID uuid.UUID) (*model.Task, error) {
+	task, err := s.GetVisible(ctx, callerID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.UserID != callerID && (task.AssignedTo != nil || userID != callerID) {
+		return nil, ErrPermissionDenied
+	}
+
+	updated := *task
+	updated.AssignedTo = &userID
+	updated.UpdatedAt = time.Now()
+	if err := s.save(ctx, &updated); err != nil {
+		return nil, err
+	}
+
+	err = s.publisher.PublishTaskAssigned(ctx, &events.TaskAssignedEvent{
+		TaskID: updated.ID.String(),
+		UserID: userID.String(),
+		Task:   updated.Snapshot(),
+	})
+	if err != nil {
+		s.logger.Warnw("Failed to publish task assigned event", "taskID", updated.ID, "error", err)
+	}
+	return &updated, nil
+}
+
+// DeleteTask deletes the task for its creator.
+func (s *TaskService) DeleteTask(ctx context.Context, callerID, taskID uuid.UUID) error {
+	task, err := s.GetVisible(ctx, callerID, taskID)
+	if err != nil {
+		return err
+	}
+	if task.UserID != callerID {
+		return ErrPermissionDenied
+	}
+
+	if err := s.repo.Delete(ctx, taskID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNotFound
+		}
+		return ErrInternal
+	}
+
+	if err := s.publisher.PublishTaskDeleted(ctx, &events.TaskDeletedEvent{Task: *task.Snapshot()}); err != nil {
+		s.logger.Warnw("Failed to publish task deleted event", "taskID", task.ID, "error", err)
+	}
+	return nil
+}
+
+// WatchTasks subscribes to the task events matching filter, replaying those
+// after resumeAfter when it is not nil. The caller must close the
+// subscription.
+func (s *TaskService) WatchTasks(filter watch.Filter, resumeAfter *watch.Position) (*watch.Subscription, error) {
+	return s.hub.Subscribe(filter, resumeAfter)
+}
+
+func (s *TaskService) save(ctx context.Context, task *model.Task) error {
+	if err := s.repo.Update(ctx, task); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNotFound
+		}
+		return ErrInternal
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+// Package watch fans task events out to WatchTasks streams.
+package watch
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CP-Payne/taskflow/task/internal/model"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultHistorySize is how many recent events are kept for resuming.
+	DefaultHistorySize = 1024
+	// DefaultBufferSize is how many events a subscriber may fall behind
+	// before it is disconnected.
+	DefaultBufferSize = 64
+)
+
+var (
+	// ErrResumeUnavailable is returned when the events after the requested
+	// position are no longer kept, or were numbered by another hub, e.g.
+	// before a restart or on another instance.
+	ErrResumeUnavailable = errors.New("events to resume from are no longer available")
+	// ErrSlowConsumer ends a subscription whose buffer filled up.
+	ErrSlowConsumer = errors.New("subscriber fell too far behind")
+)
+
+type EventType int
+
+const (
+	Created EventType = iota
+	Updated
+	Assigned
+	Deleted
+)
+
+// Position locates an event for resuming. Sequence numbers only mean
+// something to the hub that assigned them, which is named by its epoch.
+type Position struct {
+	Epoch string
+	Seq   uint64
+}
+
+// String returns the position as an opaque resume token.
+func (p Position) String() string {
+	return p.Epoch + "." + strconv.FormatUint(p.Seq, 10)
+}
+
+// ParsePosition is the inverse of Position.String.
+func ParsePosition(token string) (Position, error) {
+	epoch, seq, ok := strings.Cut(token, ".")
+	if !ok || epoch == "" {
+		return Position{}, fmt.Errorf("malformed resume token %q", token)
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return Position{}, fmt.Errorf("malformed resume token %q", token)
+	}
+	return Position{Epoch: epoch, Seq: n}, nil
+}
+
+type Event struct {
+	// Epoch names the hub that published the event.
+	Epoch string
+	// Seq increases by one for every event published to the hub.
+	Seq        uint64
+	Type       EventType
+	Task       model.Task
+	OccurredAt time.Time
+}
+
+// Position returns where the event is, to resume after it.
+func (e Event) Position() Position {
+	return Position{Epoch: e.Epoch, Seq: e.Seq}
+}
+
+// Filter selects events by task. Nil fields match every task.
+type Filter struct {
+	AssignedTo  *uuid.UUID
+	CreatedBy   *uuid.UUID
+	WorkspaceID *uuid.UUID
+	// VisibleTo limits events to the tasks the user may see.
+	VisibleTo *uuid.UUID
+}
+
+func (f Filter) Matches(task *model.Task) bool {
+	if f.VisibleTo != nil && !task.VisibleTo(*f.VisibleTo) {
+		return false
+	}
+	if f.AssignedTo != nil && (task.AssignedTo == nil || *task.AssignedTo != *f.AssignedTo) {
+		return false
+	}
+	if f.CreatedBy != nil && task.UserID != *f.CreatedBy {
+		return false
+	}
+	if f.WorkspaceID != nil && (task.WorkspaceID == nil || *task.WorkspaceID != *f.WorkspaceID) {
+		return false
+	}
+	return true
+}
+
+// Hub numbers published events, keeps a ring of recent ones for resuming and
+// delivers them to matching subscribers without blocking the publisher.
+type Hub struct {
+	mu         sync.Mutex
+	epoch      string
+	seq        uint64
+	history    []Event
+	next       int
+	full       bool
+	bufferSize int
+	subs       map[*Subscription]struct{}
+}
+
+func NewHub(historySize, bufferSize int) *Hub {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	return &Hub{
+		epoch:      newEpoch(),
+		history:    make([]Event, historySize),
+		bufferSize: bufferSize,
+		subs:       make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish records the event and delivers it to matching subscribers.
+// Subscribers whose buffer is full are disconnected with ErrSlowConsumer.
+func (h *Hub) Publish(eventType EventType, task model.Task) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	ev := Event{Epoch: h.epoch, Seq: h.seq, Type: eventType, Task: task, OccurredAt: time.Now()}
+	h.history[h.next] = ev
+	h.next = (h.next + 1) % len(h.history)
+	if h.next == 0 {
+		h.full = true
+	}
+
+	for sub := range h.subs {
+		if !sub.filter.Matches(&ev.Task) {
+			continue
+		}
+		select {
+		case sub.events <- ev:
+		default:
+			h.drop(sub, ErrSlowConsumer)
+		}
+	}
+	return ev
+}
+
+// Subscribe starts a subscription. With a non-nil resumeAfter the matching
+// events published after that position are replayed first.
+func (h *Hub) Subscribe(filter Filter, resumeAfter *Position) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var replay []Event
+	if resumeAfter != nil {
+		if resumeAfter.Epoch != h.epoch {
+			return nil, ErrResumeUnavailable
+		}
+		missed, ok := h.since(resumeAfter.Seq)
+		if !ok {
+			return nil, ErrResumeUnavailable
+		}
+		for _, ev := range missed {
+			if filter.Matches(&ev.Task) {
+				replay = append(replay, ev)
+			}
+		}
+	}
+
+	sub := &Subscription{
+		hub:    h,
+		filter: filter,
+		events: make(chan Event, h.bufferSize+len(replay)),
+		done:   make(chan struct{}),
+	}
+	for _, ev := range replay {
+		sub.events <- ev
+	}
+	h.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Position returns the position of the latest event.
+func (h *Hub) Position() Position {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return Position{Epoch: h.epoch, Seq: h.seq}
+}
+
+// since returns the events after seq, oldest first, and false if some of them
+// are no longer in the history.
+func (h *Hub) since(seq uint64) ([]Event, bool) {
+	if seq > h.seq {
+		return nil, false
+	}
+	count := h.next
+	if h.full {
+		count = len(h.history)
+	}
+	missed := int(h.seq - seq)
+	if missed > count {
+		return nil, false
+	}
+
+	events := make([]Event, 0, missed)
+	for i := missed; i > 0; i-- {
+		idx := (h.next - i + len(h.history)) % len(h.history)
+		events = append(events, h.history[idx])
+	}
+	return events, true
+}
+
+// newEpoch returns a random name for a hub, so positions from another
+// process are told apart.
+func newEpoch() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		// A time-based epoch still changes with every restart
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// drop removes sub from the hub. Callers must hold h.mu.
+func (h *Hub) drop(sub *Subscription, err error) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	sub.err = err
+	close(sub.done)
+}
+
+type Subscription struct {
+	hub    *Hub
+	filter Filter
+	events chan Event
+	done   chan struct{}
+	err    error
+}
+
+// Events delivers the subscribed events in sequence order.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Done is closed when the subscription ends; Err then reports why.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.err
+}
+
+// Close ends the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.drop(s, nil)
+}
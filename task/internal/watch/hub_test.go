@@ -0,0 +1,173 @@
+package watch_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/CP-Payne/taskflow/task/internal/model"
+	"github.com/CP-Payne/taskflow/task/internal/watch"
+	"github.com/google/uuid"
+)
+
+func TestHub_Subscribe(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	taskFor := func(assignee uuid.UUID) model.Task {
+		return model.Task{ID: uuid.New(), UserID: bob, AssignedTo: &assignee}
+	}
+
+	tests := []struct {
+		name        string
+		history     int
+		filter      watch.Filter
+		resumeAfter uint64
+		// otherEpoch resumes from a position of another hub, as after a
+		// restart or when reconnecting to another instance.
+		otherEpoch bool
+		wantSeqs   []uint64
+		expectErr  error
+	}{
+		{
+			name:     "Live events only without resume",
+			history:  10,
+			wantSeqs: nil,
+		},
+		{
+			name:        "Replay every event after the sequence",
+			history:     10,
+			resumeAfter: 2,
+			wantSeqs:    []uint64{3, 4},
+		},
+		{
+			name:        "Replay only matching events",
+			history:     10,
+			filter:      watch.Filter{AssignedTo: &alice},
+			resumeAfter: 1,
+			wantSeqs:    []uint64{3},
+		},
+		{
+			name:        "Fail when the events were evicted",
+			history:     2,
+			resumeAfter: 1,
+			expectErr:   watch.ErrResumeUnavailable,
+		},
+		{
+			name:        "Fail for a sequence ahead of the hub",
+			history:     10,
+			resumeAfter: 99,
+			expectErr:   watch.ErrResumeUnavailable,
+		},
+		{
+			name:        "Fail for a later sequence from before a restart",
+			history:     10,
+			resumeAfter: 99,
+			otherEpoch:  true,
+			expectErr:   watch.ErrResumeUnavailable,
+		},
+		{
+			name:        "Fail for an earlier sequence from before a restart",
+			history:     10,
+			resumeAfter: 2,
+			otherEpoch:  true,
+			expectErr:   watch.ErrResumeUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := watch.NewHub(tt.history, 10)
+			hub.Publish(watch.Created, taskFor(alice))
+			hub.Publish(watch.Created, taskFor(bob))
+			hub.Publish(watch.Assigned, taskFor(alice))
+			hub.Publish(watch.Deleted, taskFor(bob))
+
+			var resumeAfter *watch.Position
+			if tt.resumeAfter > 0 {
+				epoch := hub.Position().Epoch
+				if tt.otherEpoch {
+					epoch = watch.NewHub(tt.history, 10).Position().Epoch
+				}
+				resumeAfter = &watch.Position{Epoch: epoch, Seq: tt.resumeAfter}
+			}
+			sub, err := hub.Subscribe(tt.filter, resumeAfter)
+			if !errors.Is(err, tt.expectErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			defer sub.Close()
+
+			var got []uint64
+			for len(sub.Events()) > 0 {
+				got = append(got, (<-sub.Events()).Seq)
+			}
+			if len(got) != len(tt.wantSeqs) {
+				t.Fatalf("replayed %v, want %v", got, tt.wantSeqs)
+			}
+			for i := range got {
+				if got[i] != tt.wantSeqs[i] {
+					t.Errorf("replayed %v, want %v", got, tt.wantSeqs)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestHub_SlowConsumer(t *testing.T) {
+	hub := watch.NewHub(10, 2)
+	workspace := uuid.New()
+
+	slow, err := hub.Subscribe(watch.Filter{}, nil)
+	if err != nil {
+		t.Fatalf("Subscribe() failed: %v", err)
+	}
+	other, err := hub.Subscribe(watch.Filter{WorkspaceID: &workspace}, nil)
+	if err != nil {
+		t.Fatalf("Subscribe() failed: %v", err)
+	}
+	defer other.Close()
+
+	for i := 0; i < 3; i++ {
+		hub.Publish(watch.Updated, model.Task{ID: uuid.New()})
+	}
+
+	select {
+	case <-slow.Done():
+	default:
+		t.Fatal("expected the slow subscriber to be disconnected")
+	}
+	if !errors.Is(slow.Err(), watch.ErrSlowConsumer) {
+		t.Errorf("expected ErrSlowConsumer, got %v", slow.Err())
+	}
+
+	// The subscriber filtering on a workspace received nothing and stays open.
+	select {
+	case <-other.Done():
+		t.Error("expected the idle subscriber to stay connected")
+	default:
+	}
+
+	// A disconnected client resumes from the last event it received.
+	resumed, err := hub.Subscribe(watch.Filter{}, &watch.Position{Epoch: hub.Position().Epoch, Seq: 2})
+	if err != nil {
+		t.Fatalf("Subscribe() failed: %v", err)
+	}
+	defer resumed.Close()
+	if ev := <-resumed.Events(); ev.Seq != 3 {
+		t.Errorf("resumed at %d, want 3", ev.Seq)
+	}
+}
+
+func TestParsePosition(t *testing.T) {
+	want := watch.Position{Epoch: "3f9a", Seq: 42}
+	got, err := watch.ParsePosition(want.String())
+	if err != nil || got != want {
+		t.Errorf("ParsePosition(%q) = %+v, %v, want %+v", want.String(), got, err, want)
+	}
+	for _, token := range []string{"", "42", ".42", "3f9a.", "3f9a.x"} {
+		if _, err := watch.ParsePosition(token); err == nil {
+			t.Errorf("ParsePosition(%q) succeeded, want an error", token)
+		}
+	}
+}
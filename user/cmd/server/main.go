@@ -2,26 +2,28 @@ package main
 
 import (
 	"context"
-	"flag"
+	"errors"
 	"fmt"
-	"net"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/CP-Payne/taskflow/pkg/authkeys"
-	"github.com/CP-Payne/taskflow/pkg/discovery"
-	"github.com/CP-Payne/taskflow/pkg/discovery/consul"
+	pkgconfig "github.com/CP-Payne/taskflow/pkg/config"
+	"github.com/CP-Payne/taskflow/pkg/discovery/backend"
 	grpcApi "github.com/CP-Payne/taskflow/pkg/gen/user/v1"
+	"github.com/CP-Payne/taskflow/pkg/grpcauth"
 	"github.com/CP-Payne/taskflow/pkg/secrets"
+	"github.com/CP-Payne/taskflow/pkg/server"
 	"github.com/CP-Payne/taskflow/user/config"
 	"github.com/CP-Payne/taskflow/user/internal/auth"
 	grpchandler "github.com/CP-Payne/taskflow/user/internal/handler/grpc"
+	"github.com/CP-Payne/taskflow/user/internal/password"
+	"github.com/CP-Payne/taskflow/user/internal/publisher"
 	"github.com/CP-Payne/taskflow/user/internal/repository/memory"
 	"github.com/CP-Payne/taskflow/user/internal/service"
+	"github.com/CP-Payne/taskflow/user/internal/throttle"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
-	"google.golang.org/grpc"
 )
 
 const (
@@ -30,135 +32,179 @@ const (
 )
 
 func main() {
-	_ = config.New("user/.env")
-
 	logger := zap.Must(zap.NewDevelopment()).Sugar()
 	defer logger.Sync()
 
-	var port int
-	flag.IntVar(&port, "port", 9001, "API handler port")
-	flag.Parse()
-	logger.Infof("Starting the user service on port %d", port)
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, pkgconfig.ErrPrinted) {
+		return
+	} else if err != nil {
+		logger.Fatalw("invalid configuration", "error", err)
+	}
+	logger.Infof("Starting the user service on port %d", cfg.Port)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Register service on Consul
-	// TODO: Add registry addr to global config
-	registry, err := consul.NewRegistry("localhost:8500")
+	registry, err := backend.New(cfg.Discovery)
 	if err != nil {
 		logger.Fatalw("failed to create service discovery registry", "error", err)
 	}
+	logger.Infow("Using service discovery backend", "backend", cfg.Discovery.Backend)
 
-	instanceID := discovery.GenerateInstanceID(serviceName)
-	serviceAddr := fmt.Sprintf("localhost:%d", port)
-
-	vaultAddr := os.Getenv("VAULT_ADDR")
-	roleID := os.Getenv("APPROLE_ROLE_ID")
-	secretID := os.Getenv("APPROLE_SECRET_ID")
-
-	secretsManager, err := secrets.NewVaultSecretManager(vaultAddr, roleID, secretID)
+	secretsProvider, err := secrets.New(ctx, cfg.Secrets, logger)
 	if err != nil {
-		logger.Fatalw("failed to create secrets manager", "error", err)
+		logger.Fatalw("failed to create secrets provider", "error", err)
 	}
-
-	keyPath := os.Getenv("VAULT_KEY_PATH")
-	keyName := os.Getenv("VAULT_KEY_NAME")
-
-	authKeys := authkeys.NewAuthKeys()
-	err = authKeys.LoadFromSecretsManager(secretsManager, keyPath, keyName, authkeys.Private)
-	if err != nil {
-		logger.Fatalw("failed to fetch auth key", "error", err)
+	if err := pkgconfig.ResolveSecrets(&cfg, secrets.Resolver(ctx, secretsProvider)); err != nil {
+		logger.Fatalw("failed to resolve secrets", "error", err)
 	}
 
-	authenticator := auth.NewJWTAuthenticator(authKeys)
-
-	repo := memory.NewInMemory()
-	srv := service.New(repo, authenticator, logger)
-
-	grpcServer := grpc.NewServer()
-	userHandler := grpchandler.NewUserHandler(srv, logger)
-	grpcApi.RegisterUserServer(grpcServer, userHandler)
-
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	// The local signer reloads its key when the watcher reads a new version
+	keyWatcher := secrets.NewWatcher(secretsProvider, cfg.SigningKey.RefreshInterval, logger)
+	signer, err := newSigner(ctx, cfg.SigningKey, secretsProvider, keyWatcher, logger)
 	if err != nil {
-		logger.Fatalw("failed to listen", "port", port, "error", err)
-	}
-	logger.Infof("gRPC server listening on %s", lis.Addr().String())
-
-	// Register service to consul
-	registerCtx, registerCancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer registerCancel()
-	if err := registry.Register(registerCtx, instanceID, serviceName, serviceAddr); err != nil {
-		logger.Fatalw("failed registering service to consul", "error", err)
+		logger.Fatalw("failed to create token signer", "error", err)
 	}
-	logger.Infow("Service registered successfully", "id", instanceID, "name", serviceName, "address", serviceAddr)
-
-	// HealthCheck
-
-	go func(ctx context.Context) {
-		ticker := time.NewTicker(5 * time.Second)
-		defer ticker.Stop()
-		logger.Info("Starting health reporter")
-		for {
-			select {
-			case <-ticker.C:
-				reportCtx, reportCancel := context.WithTimeout(context.Background(), 2*time.Second)
-				if err := registry.ReportHealthState(reportCtx, instanceID, serviceName); err != nil {
-					logger.Warnw("failed to report healthy state", "error", err, "instanceID", instanceID)
-				}
-				reportCancel()
-			case <-ctx.Done():
-				logger.Info("Stopping health reported due to context cancellation")
-				return
-			}
+	logger.Infow("Signing tokens", "signer", cfg.SigningKey.Signer, "method", signer.Method().Alg())
+
+	authenticator := auth.NewJWTAuthenticator(signer)
+
+	// Redis shares the login failure counters between instances and carries
+	// lockout events to the notifier
+	var rdb *redis.Client
+	if cfg.UsesRedis() {
+		rdb = redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword,
+		})
+		if err := rdb.Ping(ctx).Err(); err != nil {
+			logger.Fatalw("Failed to connect to Redis", "error", err)
 		}
-	}(ctx)
+		defer rdb.Close()
+		logger.Info("Connected to Redis")
+	}
 
-	shutdownChan := make(chan os.Signal, 1)
-	signal.Notify(shutdownChan, syscall.SIGINT, syscall.SIGTERM)
+	var loginStore throttle.Store = throttle.NewMemoryStore()
+	if cfg.LoginStore == config.LoginStoreRedis {
+		loginStore = throttle.NewRedisStore(rdb)
+	}
+	limiter := throttle.NewLimiter(loginStore, cfg.Login)
+	logger.Infow("Throttling failed logins", "store", cfg.LoginStore, "notifyLockout", cfg.NotifyLockout)
 
-	errChan := make(chan error, 1)
+	var userPublisher publisher.Publisher
+	if cfg.NotifyLockout {
+		userPublisher = publisher.NewRedisPublisher(rdb, logger)
+	}
 
-	go func() {
-		logger.Info("Starting gRPC server...")
-		if err := grpcServer.Serve(lis); err != nil && err != grpc.ErrServerStopped {
-			logger.Errorf("gRPC server failed: %v", err)
-			errChan <- err
-		} else {
-			logger.Info("gRPC server stopped gracefully")
-			close(errChan)
+	var breached *password.BreachedList
+	if cfg.Password.BreachedList != "" {
+		breached, err = password.LoadBreachedList(cfg.Password.BreachedList)
+		if err != nil {
+			logger.Fatalw("failed to load breached password list", "error", err)
 		}
-	}()
-
-	select {
-	case sig := <-shutdownChan:
-		logger.Infow("Received shutdown signal", "signal", sig)
-	case err := <-errChan:
-		logger.Errorw("gRPC server failed to start or run", "error", err)
+		logger.Infow("Loaded breached password list", "path", cfg.Password.BreachedList, "hashes", breached.Len())
 	}
+	passwords := password.NewValidator(cfg.Password, breached)
+	hashers := password.NewHashersFromConfig(cfg.PasswordHash)
 
-	logger.Info("Initiating graceful shutdown...")
-
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
-	defer shutdownCancel()
-
-	logger.Info("Deregistering service from Consul...")
-	if err := registry.Deregister(shutdownCtx, instanceID, serviceName); err != nil {
-		logger.Errorw("failed to deregister service during shutdown", "error", err, "instanceID", instanceID)
-	} else {
-		logger.Info("Service deregistered successfully")
+	repo := memory.NewInMemory()
+	srv := service.New(repo, authenticator, logger, limiter, userPublisher, passwords, hashers)
+
+	tokenService := service.NewAccessTokenService(memory.NewAccessTokenRepository(), srv, logger)
+
+	// The TOTP and access token RPCs act on the caller, and only take session
+	// JWTs so a leaked access token cannot mint more
+	sessionOnly := grpcauth.Rule{SessionOnly: true}
+	authInterceptor := grpcauth.New(tokenService, map[string]grpcauth.Rule{
+		grpcApi.User_EnrollTOTP_FullMethodName:        sessionOnly,
+		grpcApi.User_ConfirmTOTP_FullMethodName:       sessionOnly,
+		grpcApi.User_CreateAccessToken_FullMethodName: sessionOnly,
+		grpcApi.User_ListAccessTokens_FullMethodName:  sessionOnly,
+		grpcApi.User_RevokeAccessToken_FullMethodName: sessionOnly,
+	}, logger)
+
+	app := server.New(server.Config{
+		Name:            serviceName,
+		Port:            cfg.Port,
+		Registry:        registry,
+		RegisterOptions: cfg.Discovery.RegisterOptions(),
+		ShutdownTimeout: shutdownTimeout,
+	}, logger, authInterceptor.ServerOptions()...)
+	userHandler := grpchandler.NewUserHandler(srv, tokenService, logger)
+	grpcApi.RegisterUserServer(app.GRPC(), userHandler)
+
+	if rdb != nil {
+		app.AddCheck("redis", func(ctx context.Context) error {
+			return rdb.Ping(ctx).Err()
+		})
 	}
+	app.AddCheck("repository", repo.Ping)
+	app.Go("vault token renewal", secretsProvider.RenewTokens)
+	app.Go("signing key watcher", keyWatcher.Run)
 
-	logger.Info("Stopping background tasks (like health check)...")
-	cancel()
-
-	logger.Info("Stopping gRPC server gracefully...")
-	grpcServer.GracefulStop()
-	logger.Info("gRPC server has been stopped.")
+	if err := app.Run(ctx); err != nil {
+		logger.Errorw("User service stopped with error", "error", err)
+	}
 
 	logger.Info("Flushing logs...")
 	_ = logger.Sync()
+}
 
-	logger.Info("Shutdown complete.")
+// newSigner selects the token signer: local, with the private key read from
+// the secrets providers, or transit to sign in Vault.
+func newSigner(ctx context.Context, cfg config.SigningKey, secretsProvider *secrets.Chain, watcher *secrets.Watcher, logger *zap.SugaredLogger) (auth.Signer, error) {
+	switch cfg.Signer {
+	case config.SignerLocal:
+		authKeys := authkeys.NewAuthKeys()
+		if err := authKeys.LoadFromSecretsManager(secretsProvider, cfg.Path, cfg.Name, authkeys.Private); err != nil {
+			return nil, fmt.Errorf("failed to fetch auth key: %w", err)
+		}
+		signer, err := auth.NewLocalSignerFromPEM(authKeys.PrivateKey(), cfg.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		signer.WithRetention(service.TokenTTL)
+		retirePreviousKeys(ctx, secretsProvider, cfg, signer, service.TokenTTL, logger)
+		authKeys.Watch(watcher, cfg.Path, cfg.Name, authkeys.Private, logger)
+		authKeys.OnReload(func() {
+			if err := signer.Reload(authKeys.PrivateKey()); err != nil {
+				logger.Errorw("Failed to reload signing key, keeping the current one", "error", err)
+				return
+			}
+			logger.Infow("Reloaded signing key")
+		})
+		return signer, nil
+	case config.SignerTransit:
+		vault := secretsProvider.Vault()
+		if vault == nil {
+			return nil, errors.New("the transit signer needs VAULT_ADDR")
+		}
+		return auth.NewTransitSigner(ctx, vault.Client(), cfg.TransitMount, cfg.TransitKey, cfg.Algorithm)
+	default:
+		return nil, fmt.Errorf("unknown JWT_SIGNER %q", cfg.Signer)
+	}
+}
+
+// retirePreviousKeys lets signer verify the tokens of the key versions that
+// were replaced within retention, so they stay valid across a restart.
+func retirePreviousKeys(ctx context.Context, secretsProvider secrets.Secrets, cfg config.SigningKey, signer *auth.LocalSigner, retention time.Duration, logger *zap.SugaredLogger) {
+	current, err := secretsProvider.Get(ctx, cfg.Path)
+	if err != nil {
+		logger.Warnw("Failed to read signing key versions, tokens of previous keys will not verify", "error", err)
+		return
+	}
+	for current.Version > 1 && time.Since(current.CreatedAt) < retention {
+		previous, err := secretsProvider.GetVersion(ctx, cfg.Path, current.Version-1)
+		if err != nil {
+			logger.Warnw("Failed to read previous signing key", "version", current.Version-1, "error", err)
+			return
+		}
+		if key, err := previous.Value(cfg.Name); err == nil {
+			if err := signer.Retire([]byte(key), current.CreatedAt); err != nil {
+				logger.Warnw("Skipping previous signing key", "version", previous.Version, "error", err)
+			}
+		}
+		current = previous
+	}
 }
@@ -1,26 +1,140 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"time"
 
-	"github.com/joho/godotenv"
+	pkgconfig "github.com/CP-Payne/taskflow/pkg/config"
+	"github.com/CP-Payne/taskflow/pkg/discovery/backend"
+	"github.com/CP-Payne/taskflow/pkg/secrets"
+	"github.com/CP-Payne/taskflow/user/internal/auth"
+	"github.com/CP-Payne/taskflow/user/internal/password"
+	"github.com/CP-Payne/taskflow/user/internal/throttle"
 )
 
+// envFiles are read for variables missing from the environment.
+var envFiles = []string{"user/.env", "config/.env"}
+
 type Config struct {
-	KeyPath    string
-	JWTSignKey string
+	Port       int            `yaml:"port" flag:"port" usage:"API handler port"`
+	Discovery  backend.Config `yaml:"discovery"`
+	Secrets    secrets.Config `yaml:"secrets"`
+	SigningKey SigningKey     `yaml:"signing_key"`
+	// Password is the policy new passwords must meet.
+	Password password.Policy `yaml:"password"`
+	// PasswordHash selects how new passwords are hashed.
+	PasswordHash password.HashConfig `yaml:"password_hash"`
+	// Login throttles failed logins per account and client IP.
+	Login throttle.Config `yaml:"login"`
+	// LoginStore keeps the failure counters: memory for a single instance,
+	// or redis to share them between instances.
+	LoginStore string `yaml:"login_store" env:"LOGIN_THROTTLE_STORE" usage:"memory or redis"`
+	// NotifyLockout publishes lockouts for the notifier to email the user.
+	NotifyLockout bool `yaml:"notify_lockout" env:"LOGIN_LOCKOUT_NOTIFY"`
+	// RedisAddr is needed by the redis login store and lockout
+	// notifications.
+	RedisAddr     string `yaml:"redis_addr" env:"REDIS_NOTIFIER_ADDR"`
+	RedisPassword string `yaml:"redis_password" env:"REDIS_PASSWORD" secret:"true"`
 }
 
-func New(envPath string) *Config {
-	err := godotenv.Load(envPath)
-	if err != nil {
-		fmt.Printf("%v", err)
-		panic("failed to load service config")
+// Login stores selectable with LOGIN_THROTTLE_STORE.
+const (
+	LoginStoreMemory = "memory"
+	LoginStoreRedis  = "redis"
+)
 
+// UsesRedis reports whether the service needs a Redis connection.
+func (c Config) UsesRedis() bool {
+	return c.LoginStore == LoginStoreRedis || c.NotifyLockout
+}
+
+// Signers selectable with JWT_SIGNER.
+const (
+	// SignerLocal signs with the private key read from the secrets
+	// providers.
+	SignerLocal = "local"
+	// SignerTransit signs with a key of Vault's Transit engine, which never
+	// leaves Vault.
+	SignerTransit = "transit"
+)
+
+// SigningKey selects the key signing the issued tokens.
+type SigningKey struct {
+	Signer string `yaml:"signer" env:"JWT_SIGNER" usage:"local or transit"`
+	// Algorithm is RS256, ES256 or EdDSA, and must match the key.
+	Algorithm string `yaml:"algorithm" env:"JWT_ALGORITHM"`
+	// RefreshInterval is how often the local signer checks for a new
+	// version of the key.
+	RefreshInterval time.Duration `yaml:"refresh_interval" env:"JWT_KEY_REFRESH_INTERVAL"`
+	// Path and Name locate the private key for the local signer.
+	Path string `yaml:"path" env:"VAULT_KEY_PATH"`
+	Name string `yaml:"name" env:"VAULT_KEY_NAME"`
+	// TransitMount and TransitKey name the key of the transit signer.
+	TransitMount string `yaml:"transit_mount" env:"VAULT_TRANSIT_MOUNT"`
+	TransitKey   string `yaml:"transit_key" env:"VAULT_TRANSIT_KEY"`
+}
+
+func (c Config) Validate() error {
+	switch c.LoginStore {
+	case LoginStoreMemory, LoginStoreRedis:
+	default:
+		return fmt.Errorf("unknown LOGIN_THROTTLE_STORE %q, expected memory or redis", c.LoginStore)
+	}
+	if c.PasswordHash.Algorithm == password.AlgBcrypt && c.Password.MaxLength > password.MaxBcryptLength {
+		return fmt.Errorf("PASSWORD_MAX_LENGTH cannot exceed %d bytes with bcrypt, the most it hashes", password.MaxBcryptLength)
 	}
+	if c.UsesRedis() && c.RedisAddr == "" {
+		return errors.New("REDIS_NOTIFIER_ADDR is required by the redis login store and LOGIN_LOCKOUT_NOTIFY")
+	}
+
+	switch c.SigningKey.Algorithm {
+	case auth.AlgRS256, auth.AlgES256, auth.AlgEdDSA:
+	default:
+		return fmt.Errorf("unknown JWT_ALGORITHM %q, expected RS256, ES256 or EdDSA", c.SigningKey.Algorithm)
+	}
+
+	switch c.SigningKey.Signer {
+	case SignerLocal:
+		var errs []error
+		if c.SigningKey.Path == "" || c.SigningKey.Name == "" {
+			errs = append(errs, errors.New("the local signer needs VAULT_KEY_PATH and VAULT_KEY_NAME"))
+		}
+		if !c.Secrets.Enabled() {
+			errs = append(errs, errors.New("a secrets provider (SECRETS_DIR, SECRETS_FILE, SECRETS_ENV_PREFIX or VAULT_ADDR) is required to read the signing key"))
+		}
+		return errors.Join(errs...)
+	case SignerTransit:
+		if c.SigningKey.TransitKey == "" || !c.Secrets.Vault.Enabled() {
+			return errors.New("the transit signer needs VAULT_ADDR and VAULT_TRANSIT_KEY")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown JWT_SIGNER %q", c.SigningKey.Signer)
+	}
+}
 
-	return &Config{
-		KeyPath: os.Getenv("KEYS_PATH"),
+func Default() Config {
+	return Config{
+		Port:      9001,
+		Discovery: backend.DefaultConfig(),
+		SigningKey: SigningKey{
+			Signer:          SignerLocal,
+			Algorithm:       auth.AlgRS256,
+			RefreshInterval: time.Minute,
+			TransitMount:    "transit",
+		},
+		Password:     password.DefaultPolicy(),
+		PasswordHash: password.DefaultHashConfig(),
+		Login:        throttle.DefaultConfig(),
+		LoginStore:   LoginStoreMemory,
 	}
 }
+
+// Load returns the configuration of the user service. It returns
+// pkgconfig.ErrPrinted when run with --print-config.
+func Load(args []string) (Config, error) {
+	cfg := Default()
+	err := pkgconfig.New("user", envFiles...).Load(&cfg, args)
+	return cfg, err
+}
@@ -1,10 +1,12 @@
 package auth
 
 import (
+	"context"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type Authenticator interface {
-	GenerateToken(jwt jwt.Claims) (string, error)
-	ValidateToken(token string) (*jwt.Token, error)
+	GenerateToken(ctx context.Context, jwt jwt.Claims) (string, error)
+	ValidateToken(ctx context.Context, token string) (*jwt.Token, error)
 }
@@ -1,57 +1,52 @@
 package auth
 
 import (
-	"errors"
+	"context"
 	"fmt"
 
-	"github.com/CP-Payne/taskflow/pkg/authkeys"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type JWTAuthenticator struct {
-	keys authkeys.AuthKeys
-	aud  string
-	iss  string
+	signer Signer
+	aud    string
+	iss    string
 }
 
-func NewJWTAuthenticator(keys authkeys.AuthKeys) *JWTAuthenticator {
+func NewJWTAuthenticator(signer Signer) *JWTAuthenticator {
 	return &JWTAuthenticator{
-		keys: keys,
+		signer: signer,
 	}
 }
 
-func (a *JWTAuthenticator) GenerateToken(claims jwt.Claims) (string, error) {
-	prvKey := a.keys.PrivateKey()
-	if prvKey == nil {
-		return "", errors.New("failed to load private key")
-	}
-	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(prvKey)
-	if err != nil {
-		return "", fmt.Errorf("GenerateToken: parse key: %w", err)
-	}
-
-	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(privateKey)
+func (a *JWTAuthenticator) GenerateToken(ctx context.Context, claims jwt.Claims) (string, error) {
+	var token *jwt.Token
+	signingString, signature, err := a.signer.Sign(ctx, func(kid string, method jwt.SigningMethod) (string, error) {
+		token = jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+		return token.SigningString()
+	})
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("GenerateToken: sign: %w", err)
 	}
-	return token, err
+	return signingString + "." + token.EncodeSegment(signature), nil
 }
 
-func (a *JWTAuthenticator) ValidateToken(token string) (*jwt.Token, error) {
-	pubKey := a.keys.PublicKey()
-	if pubKey == nil {
-		return nil, errors.New("failed to load public key")
-	}
-	publicKey, err := jwt.ParseRSAPublicKeyFromPEM(pubKey)
-	if err != nil {
-		return nil, fmt.Errorf("ValidateToken: parse key: %w", err)
-	}
-
+// ValidateToken verifies the token with the key named by its kid header.
+// Tokens without one, issued before key IDs were set, are verified with the
+// current key.
+func (a *JWTAuthenticator) ValidateToken(ctx context.Context, token string) (*jwt.Token, error) {
+	method := a.signer.Method()
 	return jwt.Parse(token, func(jwtToken *jwt.Token) (interface{}, error) {
-		if _, ok := jwtToken.Method.(*jwt.SigningMethodRSA); !ok {
+		if jwtToken.Method != method {
 			return nil, fmt.Errorf("unexpected method: %s", jwtToken.Header["alg"])
 		}
 
+		kid, _ := jwtToken.Header["kid"].(string)
+		publicKey, err := a.signer.PublicKey(ctx, kid)
+		if err != nil {
+			return nil, fmt.Errorf("ValidateToken: public key: %w", err)
+		}
 		return publicKey, nil
 	})
 
@@ -0,0 +1,427 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/CP-Payne/taskflow/user/internal/auth"
+	"github.com/golang-jwt/jwt/v5"
+	vault "github.com/hashicorp/vault/api"
+)
+
+func generateKey(t testing.TB, alg string) crypto.Signer {
+	t.Helper()
+	var key crypto.Signer
+	var err error
+	switch alg {
+	case auth.AlgRS256:
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case auth.AlgES256:
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case auth.AlgEdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func privatePEM(t testing.TB, key crypto.Signer) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func claims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": "c0ffee",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+var algorithms = []string{auth.AlgRS256, auth.AlgES256, auth.AlgEdDSA}
+
+// testRoundTrip checks that tokens of signer validate, and tokens signed with
+// other keys or methods do not.
+func testRoundTrip(t *testing.T, signer auth.Signer) {
+	t.Helper()
+	ctx := context.Background()
+	a := auth.NewJWTAuthenticator(signer)
+
+	token, err := a.GenerateToken(ctx, claims())
+	if err != nil {
+		t.Fatalf("GenerateToken() failed: %v", err)
+	}
+	parsed, err := a.ValidateToken(ctx, token)
+	if err != nil {
+		t.Fatalf("ValidateToken() failed: %v", err)
+	}
+	if sub, _ := parsed.Claims.GetSubject(); sub != "c0ffee" {
+		t.Errorf("expected subject c0ffee, got %q", sub)
+	}
+
+	method := signer.Method()
+	forged, _ := jwt.NewWithClaims(method, claims()).SignedString(generateKey(t, method.Alg()))
+	if _, err := a.ValidateToken(ctx, forged); err == nil {
+		t.Error("expected a token signed with another key to be rejected")
+	}
+	hmac, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims()).SignedString([]byte("secret"))
+	if _, err := a.ValidateToken(ctx, hmac); err == nil {
+		t.Error("expected a token signed with another method to be rejected")
+	}
+}
+
+func TestJWTAuthenticator_LocalSigner(t *testing.T) {
+	for _, alg := range algorithms {
+		t.Run(alg, func(t *testing.T) {
+			signer, err := auth.NewLocalSignerFromPEM(privatePEM(t, generateKey(t, alg)), alg)
+			if err != nil {
+				t.Fatalf("NewLocalSignerFromPEM() failed: %v", err)
+			}
+			if signer.Method().Alg() != alg {
+				t.Errorf("expected method %s, got %s", alg, signer.Method().Alg())
+			}
+			testRoundTrip(t, signer)
+		})
+	}
+
+	t.Run("PKCS1", func(t *testing.T) {
+		key := generateKey(t, auth.AlgRS256).(*rsa.PrivateKey)
+		keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+		if _, err := auth.NewLocalSignerFromPEM(keyPEM, auth.AlgRS256); err != nil {
+			t.Errorf("NewLocalSignerFromPEM() failed: %v", err)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := auth.NewLocalSignerFromPEM([]byte("not a key"), auth.AlgRS256); err == nil {
+			t.Error("expected an invalid key to be rejected")
+		}
+		keyPEM := privatePEM(t, generateKey(t, auth.AlgEdDSA))
+		if _, err := auth.NewLocalSignerFromPEM(keyPEM, auth.AlgRS256); !errors.Is(err, auth.ErrAlgorithmMismatch) {
+			t.Errorf("expected ErrAlgorithmMismatch, got %v", err)
+		}
+	})
+}
+
+func TestLocalSigner_Reload(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	signer, err := auth.NewLocalSignerFromPEM(privatePEM(t, generateKey(t, auth.AlgES256)), auth.AlgES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer.WithRetention(time.Hour).WithClock(func() time.Time { return now })
+	a := auth.NewJWTAuthenticator(signer)
+	before, _ := a.GenerateToken(ctx, claims())
+	oldKID := signer.KeyID()
+
+	if err := signer.Reload(privatePEM(t, generateKey(t, auth.AlgES256))); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	if signer.KeyID() == oldKID {
+		t.Fatal("expected a new key ID after the rotation")
+	}
+	after, _ := a.GenerateToken(ctx, claims())
+	parsed, err := a.ValidateToken(ctx, after)
+	if err != nil {
+		t.Fatalf("expected tokens of the new key to validate: %v", err)
+	}
+	if kid := parsed.Header["kid"]; kid != signer.KeyID() {
+		t.Errorf("expected kid %q, got %v", signer.KeyID(), kid)
+	}
+	if _, err := a.ValidateToken(ctx, before); err != nil {
+		t.Errorf("expected tokens of the replaced key to validate during the retention: %v", err)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := a.ValidateToken(ctx, before); !errors.Is(err, auth.ErrUnknownKey) {
+		t.Errorf("expected tokens of the replaced key to be rejected after the retention, got %v", err)
+	}
+
+	if err := signer.Reload(privatePEM(t, generateKey(t, auth.AlgRS256))); !errors.Is(err, auth.ErrAlgorithmMismatch) {
+		t.Errorf("expected a key of another algorithm to be rejected, got %v", err)
+	}
+}
+
+func TestLocalSigner_ReloadWhileSigning(t *testing.T) {
+	ctx := context.Background()
+	signer, err := auth.NewLocalSignerFromPEM(privatePEM(t, generateKey(t, auth.AlgES256)), auth.AlgES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer.WithRetention(time.Hour)
+	a := auth.NewJWTAuthenticator(signer)
+
+	keys := make([][]byte, 20)
+	for i := range keys {
+		keys[i] = privatePEM(t, generateKey(t, auth.AlgES256))
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, key := range keys {
+			if err := signer.Reload(key); err != nil {
+				t.Errorf("Reload() failed: %v", err)
+			}
+		}
+	}()
+
+	// Every token carries the kid of the key that signed it
+	for signing := true; signing; {
+		select {
+		case <-done:
+			signing = false
+		default:
+		}
+		token, err := a.GenerateToken(ctx, claims())
+		if err != nil {
+			t.Fatalf("GenerateToken() failed: %v", err)
+		}
+		if _, err := a.ValidateToken(ctx, token); err != nil {
+			t.Fatalf("expected a token signed during a reload to validate: %v", err)
+		}
+	}
+}
+
+func TestLocalSigner_Retire(t *testing.T) {
+	ctx := context.Background()
+	previousPEM := privatePEM(t, generateKey(t, auth.AlgEdDSA))
+	previous, err := auth.NewLocalSignerFromPEM(previousPEM, auth.AlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _ := auth.NewJWTAuthenticator(previous).GenerateToken(ctx, claims())
+
+	// A restart after the rotation starts with the new key only
+	signer, err := auth.NewLocalSignerFromPEM(privatePEM(t, generateKey(t, auth.AlgEdDSA)), auth.AlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer.WithRetention(time.Hour)
+	a := auth.NewJWTAuthenticator(signer)
+	if _, err := a.ValidateToken(ctx, token); !errors.Is(err, auth.ErrUnknownKey) {
+		t.Fatalf("expected a token of an unknown key to be rejected, got %v", err)
+	}
+
+	if err := signer.Retire(previousPEM, time.Now().Add(-2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.ValidateToken(ctx, token); err == nil {
+		t.Error("expected a key retired before the retention not to verify")
+	}
+	if err := signer.Retire(previousPEM, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.ValidateToken(ctx, token); err != nil {
+		t.Errorf("expected a retired key to verify its tokens: %v", err)
+	}
+}
+
+// fakeTransit serves the transit endpoints used by TransitSigner, signing
+// with key the way Vault does for keyType.
+func fakeTransit(t *testing.T, key crypto.Signer, keyType string) *vault.Client {
+	t.Helper()
+	var publicKey string
+	if pub, ok := key.Public().(ed25519.PublicKey); ok {
+		publicKey = base64.StdEncoding.EncodeToString(pub)
+	} else {
+		der, err := x509.MarshalPKIXPublicKey(key.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		publicKey = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/transit/keys/jwt", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{
+			"type":           keyType,
+			"latest_version": 2,
+			"keys": map[string]any{
+				"1": map[string]any{"public_key": "stale"},
+				"2": map[string]any{"public_key": publicKey},
+			},
+		}})
+	})
+	mux.HandleFunc("PUT /v1/transit/sign/jwt/", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input               string `json:"input"`
+			KeyVersion          int    `json:"key_version"`
+			SignatureAlgorithm  string `json:"signature_algorithm"`
+			MarshalingAlgorithm string `json:"marshaling_algorithm"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.KeyVersion != 2 {
+			http.Error(w, `{"errors":["bad request"]}`, http.StatusBadRequest)
+			return
+		}
+		input, _ := base64.StdEncoding.DecodeString(req.Input)
+		digest := sha256.Sum256(input)
+
+		var signature string
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			sig, _ := rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+			signature = base64.StdEncoding.EncodeToString(sig)
+		case *ecdsa.PrivateKey:
+			if req.MarshalingAlgorithm != "jws" {
+				http.Error(w, `{"errors":["expected jws marshaling"]}`, http.StatusBadRequest)
+				return
+			}
+			r, s, _ := ecdsa.Sign(rand.Reader, k, digest[:])
+			sig := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+			signature = base64.RawURLEncoding.EncodeToString(sig)
+		case ed25519.PrivateKey:
+			signature = base64.StdEncoding.EncodeToString(ed25519.Sign(k, input))
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{
+			"signature": "vault:v2:" + signature,
+		}})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cfg := vault.DefaultConfig()
+	cfg.Address = srv.URL
+	client, err := vault.NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken("test")
+	return client
+}
+
+func TestJWTAuthenticator_TransitSigner(t *testing.T) {
+	keyTypes := map[string]string{auth.AlgRS256: "rsa-2048", auth.AlgES256: "ecdsa-p256", auth.AlgEdDSA: "ed25519"}
+	for _, alg := range algorithms {
+		t.Run(alg, func(t *testing.T) {
+			client := fakeTransit(t, generateKey(t, alg), keyTypes[alg])
+			signer, err := auth.NewTransitSigner(context.Background(), client, "", "jwt", alg)
+			if err != nil {
+				t.Fatalf("NewTransitSigner() failed: %v", err)
+			}
+			testRoundTrip(t, signer)
+			if kid := signer.KeyID(); kid != "jwt:v2" {
+				t.Errorf("expected key ID jwt:v2, got %q", kid)
+			}
+			if _, err := signer.PublicKey(context.Background(), "jwt:v7"); !errors.Is(err, auth.ErrUnknownKey) {
+				t.Errorf("expected ErrUnknownKey for a version Vault does not have, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewTransitSigner_RejectsUnsupportedKeys(t *testing.T) {
+	client := fakeTransit(t, generateKey(t, auth.AlgRS256), "aes256-gcm96")
+	if _, err := auth.NewTransitSigner(context.Background(), client, "", "jwt", auth.AlgRS256); !errors.Is(err, auth.ErrUnsupportedKey) {
+		t.Errorf("expected ErrUnsupportedKey, got %v", err)
+	}
+
+	client = fakeTransit(t, generateKey(t, auth.AlgRS256), "rsa-2048")
+	if _, err := auth.NewTransitSigner(context.Background(), client, "", "jwt", auth.AlgES256); !errors.Is(err, auth.ErrAlgorithmMismatch) {
+		t.Errorf("expected ErrAlgorithmMismatch, got %v", err)
+	}
+}
+
+func reportTokensPerSecond(b *testing.B) {
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "tokens/s")
+}
+
+// BenchmarkGenerateToken compares parsing the PEM key on every call, as
+// tokens were signed before keys were cached, with the cached signers.
+func BenchmarkGenerateToken(b *testing.B) {
+	ctx := context.Background()
+
+	b.Run("RS256/parse-per-call", func(b *testing.B) {
+		keyPEM := privatePEM(b, generateKey(b, auth.AlgRS256))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			key, err := jwt.ParseRSAPrivateKeyFromPEM(keyPEM)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims()).SignedString(key); err != nil {
+				b.Fatal(err)
+			}
+		}
+		reportTokensPerSecond(b)
+	})
+
+	for _, alg := range algorithms {
+		b.Run(alg+"/cached", func(b *testing.B) {
+			signer, err := auth.NewLocalSignerFromPEM(privatePEM(b, generateKey(b, alg)), alg)
+			if err != nil {
+				b.Fatal(err)
+			}
+			a := auth.NewJWTAuthenticator(signer)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := a.GenerateToken(ctx, claims()); err != nil {
+					b.Fatal(err)
+				}
+			}
+			reportTokensPerSecond(b)
+		})
+	}
+}
+
+// BenchmarkValidateToken compares parsing the PEM public key on every call
+// with the cached signers.
+func BenchmarkValidateToken(b *testing.B) {
+	ctx := context.Background()
+
+	b.Run("RS256/parse-per-call", func(b *testing.B) {
+		key := generateKey(b, auth.AlgRS256)
+		der, _ := x509.MarshalPKIXPublicKey(key.Public())
+		publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodRS256, claims()).SignedString(key)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			publicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicPEM)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) { return publicKey, nil }); err != nil {
+				b.Fatal(err)
+			}
+		}
+		reportTokensPerSecond(b)
+	})
+
+	for _, alg := range algorithms {
+		b.Run(alg+"/cached", func(b *testing.B) {
+			signer, err := auth.NewLocalSignerFromPEM(privatePEM(b, generateKey(b, alg)), alg)
+			if err != nil {
+				b.Fatal(err)
+			}
+			a := auth.NewJWTAuthenticator(signer)
+			token, _ := a.GenerateToken(ctx, claims())
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := a.ValidateToken(ctx, token); err != nil {
+					b.Fatal(err)
+				}
+			}
+			reportTokensPerSecond(b)
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signing algorithms selectable with JWT_ALGORITHM.
+const (
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrAlgorithmMismatch = errors.New("key does not match the signing algorithm")
+	// ErrUnknownKey is returned for a key ID the signer has no key for, e.g.
+	// one that was retired too long ago.
+	ErrUnknownKey = errors.New("unknown signing key")
+)
+
+// Signer signs tokens. The private key may never reach the service, as with
+// Vault Transit.
+type Signer interface {
+	// Method is the JWT signing method of the signatures, e.g. RS256.
+	Method() jwt.SigningMethod
+	// KeyID names the current key. Tokens take theirs from Sign, which may
+	// use a newer key after a reload.
+	KeyID() string
+	// Sign calls input with the ID and method of the key it signs with, to
+	// build the JWT signing input "<header>.<claims>", and returns the input
+	// and its signature.
+	Sign(ctx context.Context, input SigningInput) (string, []byte, error)
+	// PublicKey returns the key verifying the signatures of the key kid, or
+	// of the current key if kid is empty. It returns ErrUnknownKey for other
+	// keys.
+	PublicKey(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// SigningInput builds the JWT signing input for the key kid and its method.
+type SigningInput func(kid string, method jwt.SigningMethod) (string, error)
+
+// localKey is a parsed private key and its signing method.
+type localKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+}
+
+// retiredKey verifies the tokens signed before a rotation.
+type retiredKey struct {
+	id        string
+	public    crypto.PublicKey
+	retiredAt time.Time
+}
+
+// LocalSigner signs with a private key held in memory. The key is parsed
+// once, and replaced as a whole by Reload. Replaced keys keep verifying for
+// the retention, so the tokens they signed stay valid until they expire.
+type LocalSigner struct {
+	key       atomic.Pointer[localKey]
+	mu        sync.RWMutex
+	retired   []retiredKey
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewLocalSigner signs with an RSA, P-256 ECDSA or Ed25519 key, using RS256,
+// ES256 or EdDSA respectively.
+func NewLocalSigner(key crypto.Signer) (*LocalSigner, error) {
+	current, err := newLocalKey(key)
+	if err != nil {
+		return nil, err
+	}
+	s := &LocalSigner{now: time.Now}
+	s.key.Store(current)
+	return s, nil
+}
+
+// WithRetention keeps replaced keys verifying for d, which must be at least
+// the lifetime of the tokens. Without it they stop verifying at once.
+func (s *LocalSigner) WithRetention(d time.Duration) *LocalSigner {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retention = d
+	return s
+}
+
+// WithClock replaces time.Now, for tests.
+func (s *LocalSigner) WithClock(now func() time.Time) *LocalSigner {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+	return s
+}
+
+// NewLocalSignerFromPEM parses a private key, as stored by authkeys, that
+// must match alg.
+func NewLocalSignerFromPEM(keyPEM []byte, alg string) (*LocalSigner, error) {
+	key, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewLocalSigner(key)
+	if err != nil {
+		return nil, err
+	}
+	if s.Method().Alg() != alg {
+		return nil, fmt.Errorf("%w: %s key for %s", ErrAlgorithmMismatch, s.Method().Alg(), alg)
+	}
+	return s, nil
+}
+
+// Reload replaces the key, e.g. after authkeys read a new version. The new
+// key must use the same algorithm. The replaced key is retired.
+func (s *LocalSigner) Reload(keyPEM []byte) error {
+	next, err := s.parseKey(keyPEM)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current := s.key.Load()
+	if next.id == current.id {
+		return nil
+	}
+	s.retire(current.id, current.private.Public(), s.now())
+	s.key.Store(next)
+	return nil
+}
+
+// Retire adds a key that was replaced at retiredAt, e.g. a previous version
+// read at startup, to verify the tokens it signed until the retention ends.
+func (s *LocalSigner) Retire(keyPEM []byte, retiredAt time.Time) error {
+	key, err := s.parseKey(keyPEM)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key.id != s.key.Load().id {
+		s.retire(key.id, key.private.Public(), retiredAt)
+	}
+	return nil
+}
+
+func (s *LocalSigner) Method() jwt.SigningMethod {
+	return s.key.Load().method
+}
+
+func (s *LocalSigner) KeyID() string {
+	return s.key.Load().id
+}
+
+// Sign uses a single snapshot of the key, so a concurrent Reload cannot
+// pair the kid of one key with the signature of another.
+func (s *LocalSigner) Sign(_ context.Context, input SigningInput) (string, []byte, error) {
+	key := s.key.Load()
+	signingInput, err := input(key.id, key.method)
+	if err != nil {
+		return "", nil, err
+	}
+	signature, err := key.method.Sign(signingInput, key.private)
+	if err != nil {
+		return "", nil, err
+	}
+	return signingInput, signature, nil
+}
+
+func (s *LocalSigner) PublicKey(_ context.Context, kid string) (crypto.PublicKey, error) {
+	current := s.key.Load()
+	if kid == "" || kid == current.id {
+		return current.private.Public(), nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, k := range s.retired {
+		if k.id == kid && s.retained(k) {
+			return k.public, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownKey, kid)
+}
+
+// parseKey parses a key that must use the current algorithm.
+func (s *LocalSigner) parseKey(keyPEM []byte) (*localKey, error) {
+	private, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	key, err := newLocalKey(private)
+	if err != nil {
+		return nil, err
+	}
+	if current := s.Method(); key.method != current {
+		return nil, fmt.Errorf("%w: %s key for %s", ErrAlgorithmMismatch, key.method.Alg(), current.Alg())
+	}
+	return key, nil
+}
+
+// retire adds a retired key and drops those past the retention. s.mu must
+// be held.
+func (s *LocalSigner) retire(id string, public crypto.PublicKey, retiredAt time.Time) {
+	retired := []retiredKey{{id: id, public: public, retiredAt: retiredAt}}
+	for _, k := range s.retired {
+		if k.id != id && s.retained(k) {
+			retired = append(retired, k)
+		}
+	}
+	s.retired = retired
+}
+
+func (s *LocalSigner) retained(k retiredKey) bool {
+	return s.now().Before(k.retiredAt.Add(s.retention))
+}
+
+func newLocalKey(private crypto.Signer) (*localKey, error) {
+	method, err := keyMethod(private.Public())
+	if err != nil {
+		return nil, err
+	}
+	id, err := keyID(private.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &localKey{id: id, method: method, private: private}, nil
+}
+
+// keyID is a thumbprint of the public key, the same for every instance
+// holding the key.
+func keyID(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", fmt.Errorf("key id: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:12]), nil
+}
+
+// parsePrivateKey parses a PKCS #1, PKCS #8 or SEC 1 private key.
+func parsePrivateKey(keyPEM []byte) (crypto.Signer, error) {
+	if keyPEM == nil {
+		return nil, errors.New("failed to load private key")
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("parse key: no PEM data")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("parse key: unsupported key type %T", key)
+	}
+	return signer, nil
+}
+
+// keyMethod returns the signing method of a public key.
+func keyMethod(key crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("unsupported ECDSA curve %s, expected P-256", k.Curve.Params().Name)
+		}
+		return jwt.SigningMethodES256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+}
@@ -0,0 +1,265 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	vault "github.com/hashicorp/vault/api"
+)
+
+// DefaultTransitMount is where Vault's Transit engine is enabled.
+const DefaultTransitMount = "transit"
+
+var ErrUnsupportedKey = errors.New("unsupported transit key type")
+
+// transitRefreshInterval limits how often tokens naming an unknown key
+// version make TransitSigner read the key again.
+const transitRefreshInterval = time.Minute
+
+// TransitSigner signs with a key of Vault's Transit engine, so the private key
+// never leaves Vault. Signatures use the latest key version at creation, and
+// tokens of every version Vault still holds verify, so rotating the key does
+// not invalidate them.
+type TransitSigner struct {
+	client  *vault.Client
+	mount   string
+	name    string
+	version int
+	method  jwt.SigningMethod
+
+	mu         sync.RWMutex
+	publicKeys map[int]crypto.PublicKey
+	refreshed  time.Time
+}
+
+// NewTransitSigner reads the public keys of the Transit key name, which must
+// match alg: an RSA key for RS256, ecdsa-p256 for ES256 or ed25519 for EdDSA,
+// e.g. created with "vault write transit/keys/<name> type=rsa-2048".
+func NewTransitSigner(ctx context.Context, client *vault.Client, mount, name, alg string) (*TransitSigner, error) {
+	if mount == "" {
+		mount = DefaultTransitMount
+	}
+	s := &TransitSigner{client: client, mount: mount, name: name}
+	keyType, latest, publicKeys, err := s.readKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, ok := publicKeys[latest]
+	if !ok {
+		return nil, fmt.Errorf("transit key %s has no public key for version %d", name, latest)
+	}
+	method, err := keyMethod(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if method.Alg() != alg {
+		return nil, fmt.Errorf("%w: transit key %s of type %s for %s", ErrAlgorithmMismatch, name, keyType, alg)
+	}
+
+	s.version = latest
+	s.method = method
+	s.publicKeys = publicKeys
+	s.refreshed = time.Now()
+	return s, nil
+}
+
+// readKeys returns the key type, the latest version and the public keys of
+// the versions that parse.
+func (s *TransitSigner) readKeys(ctx context.Context) (string, int, map[int]crypto.PublicKey, error) {
+	secret, err := s.client.Logical().ReadWithContext(ctx, fmt.Sprintf("%s/keys/%s", s.mount, s.name))
+	if err != nil {
+		return "", 0, nil, fmt.Errorf("failed to read transit key %s: %w", s.name, err)
+	}
+	if secret == nil || secret.Data == nil {
+		return "", 0, nil, fmt.Errorf("transit key %s not found in %s", s.name, s.mount)
+	}
+
+	keyType, _ := secret.Data["type"].(string)
+	latest, err := jsonInt(secret.Data["latest_version"])
+	if err != nil {
+		return "", 0, nil, fmt.Errorf("invalid latest_version of transit key %s: %w", s.name, err)
+	}
+	keys, _ := secret.Data["keys"].(map[string]interface{})
+	publicKeys := make(map[int]crypto.PublicKey, len(keys))
+	for v, data := range keys {
+		version, err := strconv.Atoi(v)
+		if err != nil {
+			continue
+		}
+		fields, _ := data.(map[string]interface{})
+		encoded, _ := fields["public_key"].(string)
+		publicKey, err := parseTransitPublicKey(keyType, encoded)
+		if errors.Is(err, ErrUnsupportedKey) {
+			return "", 0, nil, err
+		} else if err != nil {
+			// Only the latest version is needed, older ones just verify
+			continue
+		}
+		publicKeys[version] = publicKey
+	}
+	return keyType, latest, publicKeys, nil
+}
+
+func (s *TransitSigner) Method() jwt.SigningMethod {
+	return s.method
+}
+
+func (s *TransitSigner) KeyID() string {
+	return transitKeyID(s.name, s.version)
+}
+
+func (s *TransitSigner) Sign(ctx context.Context, input SigningInput) (string, []byte, error) {
+	signingInput, err := input(s.KeyID(), s.method)
+	if err != nil {
+		return "", nil, err
+	}
+
+	path := fmt.Sprintf("%s/sign/%s", s.mount, s.name)
+	data := map[string]interface{}{
+		"input":       base64.StdEncoding.EncodeToString([]byte(signingInput)),
+		"key_version": s.version,
+	}
+	switch s.method {
+	case jwt.SigningMethodRS256:
+		path += "/sha2-256"
+		data["signature_algorithm"] = "pkcs1v15"
+	case jwt.SigningMethodES256:
+		// jws returns the raw r || s signature JWTs use, base64url encoded.
+		path += "/sha2-256"
+		data["marshaling_algorithm"] = "jws"
+	}
+
+	secret, err := s.client.Logical().WriteWithContext(ctx, path, data)
+	if err != nil {
+		return "", nil, fmt.Errorf("transit sign: %w", err)
+	}
+	if secret == nil {
+		return "", nil, errors.New("transit sign: no signature returned")
+	}
+	encoded, _ := secret.Data["signature"].(string)
+	signature, err := decodeTransitSignature(encoded)
+	if err != nil {
+		return "", nil, err
+	}
+	return signingInput, signature, nil
+}
+
+func (s *TransitSigner) PublicKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	version := s.version
+	if kid != "" {
+		v, ok := strings.CutPrefix(kid, s.name+":v")
+		n, err := strconv.Atoi(v)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("%w %q", ErrUnknownKey, kid)
+		}
+		version = n
+	}
+
+	s.mu.RLock()
+	publicKey, ok := s.publicKeys[version]
+	s.mu.RUnlock()
+	if ok {
+		return publicKey, nil
+	}
+
+	// Another instance may sign with a version created since the keys were
+	// read
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if publicKey, ok := s.publicKeys[version]; ok {
+		return publicKey, nil
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownKey, kid)
+}
+
+// refresh reads the public keys again, at most once per
+// transitRefreshInterval.
+func (s *TransitSigner) refresh(ctx context.Context) error {
+	s.mu.Lock()
+	if time.Since(s.refreshed) < transitRefreshInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	s.refreshed = time.Now()
+	s.mu.Unlock()
+
+	_, _, publicKeys, err := s.readKeys(ctx)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.publicKeys = publicKeys
+	return nil
+}
+
+func transitKeyID(name string, version int) string {
+	return fmt.Sprintf("%s:v%d", name, version)
+}
+
+// parseTransitPublicKey parses a PEM public key, or the base64 key Transit
+// returns for ed25519.
+func parseTransitPublicKey(keyType, encoded string) (crypto.PublicKey, error) {
+	switch {
+	case keyType == "ed25519":
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("parse transit public key: invalid ed25519 key")
+		}
+		return ed25519.PublicKey(raw), nil
+	case strings.HasPrefix(keyType, "rsa-"), keyType == "ecdsa-p256":
+		block, _ := pem.Decode([]byte(encoded))
+		if block == nil {
+			return nil, errors.New("parse transit public key: no PEM data")
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse transit public key: %w", err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedKey, keyType)
+	}
+}
+
+// decodeTransitSignature decodes a "vault:v<version>:<base64>" signature.
+func decodeTransitSignature(signature string) ([]byte, error) {
+	parts := strings.SplitN(signature, ":", 3)
+	if len(parts) != 3 || parts[0] != "vault" {
+		return nil, fmt.Errorf("transit sign: malformed signature %q", signature)
+	}
+	// Standard base64, or base64url for the jws marshaling.
+	if sig, err := base64.StdEncoding.DecodeString(parts[2]); err == nil {
+		return sig, nil
+	}
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[2], "="))
+}
+
+func jsonInt(v interface{}) (int, error) {
+	switch n := v.(type) {
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err
+	case float64:
+		return int(n), nil
+	case int:
+		return n, nil
+	default:
+		return 0, fmt.Errorf("not a number: %v", v)
+	}
+}
@@ -0,0 +1,115 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	api "github.com/CP-Payne/taskflow/pkg/gen/user/v1"
+	"github.com/CP-Payne/taskflow/pkg/grpcauth"
+	"github.com/CP-Payne/taskflow/user/internal/model"
+	"github.com/CP-Payne/taskflow/user/internal/service"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (h *UserHandler) CreateAccessToken(ctx context.Context, req *api.CreateAccessTokenRequest) (*api.CreateAccessTokenResponse, error) {
+	caller, ok := grpcauth.FromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "authentication required")
+	}
+
+	var expiresAt *time.Time
+	if req.GetExpiresAt() != nil {
+		t := req.ExpiresAt.AsTime()
+		expiresAt = &t
+	}
+	token, secret, err := h.tokenService.Create(ctx, caller.UserID, req.GetName(), req.GetScopes(), expiresAt)
+	if err != nil {
+		return nil, h.accessTokenStatus(err, caller)
+	}
+	return &api.CreateAccessTokenResponse{AccessToken: accessTokenToProto(token), Token: secret}, nil
+}
+
+func (h *UserHandler) ListAccessTokens(ctx context.Context, req *api.ListAccessTokensRequest) (*api.ListAccessTokensResponse, error) {
+	caller, ok := grpcauth.FromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "authentication required")
+	}
+
+	tokens, err := h.tokenService.List(ctx, caller.UserID)
+	if err != nil {
+		return nil, h.accessTokenStatus(err, caller)
+	}
+	res := &api.ListAccessTokensResponse{}
+	for _, t := range tokens {
+		res.AccessTokens = append(res.AccessTokens, accessTokenToProto(t))
+	}
+	return res, nil
+}
+
+func (h *UserHandler) RevokeAccessToken(ctx context.Context, req *api.RevokeAccessTokenRequest) (*api.RevokeAccessTokenResponse, error) {
+	caller, ok := grpcauth.FromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "authentication required")
+	}
+	if req.GetId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "id is required")
+	}
+
+	if err := h.tokenService.Revoke(ctx, caller.UserID, req.Id); err != nil {
+		return nil, h.accessTokenStatus(err, caller)
+	}
+	return &api.RevokeAccessTokenResponse{}, nil
+}
+
+// IntrospectToken reports refused tokens as inactive rather than failing, so
+// callers tell them apart from an unavailable user service.
+func (h *UserHandler) IntrospectToken(ctx context.Context, req *api.IntrospectTokenRequest) (*api.IntrospectTokenResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token is required")
+	}
+
+	principal, err := h.tokenService.Verify(ctx, req.Token)
+	if errors.Is(err, grpcauth.ErrInvalidToken) {
+		return &api.IntrospectTokenResponse{Active: false}, nil
+	} else if err != nil {
+		h.logger.Errorw("Internal error during token introspection", zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "internal server error")
+	}
+	return &api.IntrospectTokenResponse{
+		Active:  true,
+		UserId:  principal.UserID.String(),
+		TokenId: principal.TokenID,
+		Scopes:  principal.Scopes,
+	}, nil
+}
+
+func (h *UserHandler) accessTokenStatus(err error, caller *grpcauth.Principal) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidAccessToken):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrTooManyTokens):
+		return status.Errorf(codes.ResourceExhausted, "too many access tokens, revoke unused ones first")
+	case errors.Is(err, service.ErrNotFound):
+		return status.Errorf(codes.NotFound, "access token not found")
+	default:
+		h.logger.Errorw("Internal error managing access tokens", "userID", caller.UserID, zap.Error(err))
+		return status.Errorf(codes.Internal, "internal server error")
+	}
+}
+
+func accessTokenToProto(t *model.AccessToken) *api.AccessToken {
+	res := &api.AccessToken{
+		Id:        t.ID,
+		Name:      t.Name,
+		Scopes:    t.Scopes,
+		CreatedAt: timestamppb.New(t.CreatedAt),
+	}
+	if t.ExpiresAt != nil {
+		res.ExpiresAt = timestamppb.New(*t.ExpiresAt)
+	}
+	return res
+}
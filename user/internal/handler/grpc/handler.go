@@ -3,26 +3,35 @@ package grpc
 import (
 	"context"
 	"errors"
+	"net"
 
 	api "github.com/CP-Payne/taskflow/pkg/gen/user/v1"
+	"github.com/CP-Payne/taskflow/pkg/grpcauth"
 	"github.com/CP-Payne/taskflow/user/internal/model"
+	"github.com/CP-Payne/taskflow/user/internal/password"
 	"github.com/CP-Payne/taskflow/user/internal/service"
+	"github.com/CP-Payne/taskflow/user/internal/throttle"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 type UserHandler struct {
 	api.UnimplementedUserServer
-	userService *service.UserService
-	logger      *zap.SugaredLogger
+	userService  *service.UserService
+	tokenService *service.AccessTokenService
+	logger       *zap.SugaredLogger
 }
 
-func NewUserHandler(userService *service.UserService, logger *zap.SugaredLogger) *UserHandler {
+func NewUserHandler(userService *service.UserService, tokenService *service.AccessTokenService, logger *zap.SugaredLogger) *UserHandler {
 	return &UserHandler{
-		userService: userService,
-		logger:      logger,
+		userService:  userService,
+		tokenService: tokenService,
+		logger:       logger,
 	}
 }
 
@@ -39,10 +48,16 @@ func (h *UserHandler) RegisterUser(ctx context.Context, req *api.RegisterUserReq
 		Username: req.Username,
 		Email:    req.Email,
 	}
-	user.Password.Set(req.Password)
-	err := h.userService.RegisterUser(ctx, user)
+	err := h.userService.RegisterUser(ctx, user, req.Password)
 	if err != nil {
 		switch {
+		case errors.Is(err, password.ErrWeakPassword):
+			h.logger.Infow("User registration rejected: weak password",
+				"email", req.Email,
+				"username", req.Username,
+				zap.Error(err),
+			)
+			return nil, weakPasswordStatus(err)
 		case errors.Is(err, service.ErrUserExists):
 			h.logger.Warnw("User registration conflict: user already exists",
 				"email", req.Email,
@@ -61,7 +76,7 @@ func (h *UserHandler) RegisterUser(ctx context.Context, req *api.RegisterUserReq
 		}
 	}
 
-	token, err := h.userService.AuthenticateUser(ctx, user)
+	login, err := h.userService.AuthenticateUser(ctx, user.Email, req.Password, clientIP(ctx))
 	if err != nil {
 		h.logger.Errorw("Internal error during post-registration authentication",
 			"userID", user.ID.String(),
@@ -69,6 +84,8 @@ func (h *UserHandler) RegisterUser(ctx context.Context, req *api.RegisterUserReq
 			zap.Error(err),
 		)
 		switch {
+		case errors.Is(err, throttle.ErrThrottled):
+			return nil, throttledStatus(err)
 		case errors.Is(err, service.ErrNotFound) || errors.Is(err, service.ErrInvalidPassword):
 			return nil, status.Errorf(codes.Unauthenticated, "failed to authenticate newly registered user: %v", err)
 		default:
@@ -76,6 +93,7 @@ func (h *UserHandler) RegisterUser(ctx context.Context, req *api.RegisterUserReq
 		}
 	}
 
+	token := login.Token
 	if token == "" {
 		h.logger.Errorw("Post-registration authentication returned empty token without error",
 			"userID", user.ID.String(),
@@ -99,13 +117,11 @@ func (h *UserHandler) AuthenticateUser(ctx context.Context, req *api.Authenticat
 		return nil, status.Errorf(codes.InvalidArgument, "nil request or invalid arguments")
 	}
 
-	user := &model.User{
-		Email: req.Email,
-	}
-	user.Password.Set(req.Password)
-	token, err := h.userService.AuthenticateUser(ctx, user)
+	login, err := h.userService.AuthenticateUser(ctx, req.Email, req.Password, clientIP(ctx))
 	if err != nil {
 		switch {
+		case errors.Is(err, throttle.ErrThrottled):
+			return nil, throttledStatus(err)
 		case errors.Is(err, service.ErrNotFound) || errors.Is(err, service.ErrInvalidPassword):
 			h.logger.Warnw("User authentication failed: invalid credentials",
 				"email", req.Email,
@@ -120,7 +136,13 @@ func (h *UserHandler) AuthenticateUser(ctx context.Context, req *api.Authenticat
 			return nil, status.Errorf(codes.Internal, "internal server error")
 		}
 	}
-	if token == "" {
+	if login.ChallengeToken != "" {
+		h.logger.Infow("Password accepted, second factor required",
+			"email", req.Email,
+		)
+		return &api.AuthenticateUserResponse{ChallengeToken: login.ChallengeToken}, nil
+	}
+	if login.Token == "" {
 		h.logger.Errorw("Authentication service returned empty token without error",
 			"email", req.Email,
 		)
@@ -130,7 +152,81 @@ func (h *UserHandler) AuthenticateUser(ctx context.Context, req *api.Authenticat
 	h.logger.Infow("User authenticated successfully",
 		"email", req.Email,
 	)
-	return &api.AuthenticateUserResponse{Jwt: token}, nil
+	return &api.AuthenticateUserResponse{Jwt: login.Token}, nil
+}
+
+func (h *UserHandler) VerifySecondFactor(ctx context.Context, req *api.VerifySecondFactorRequest) (*api.VerifySecondFactorResponse, error) {
+	if req.GetChallengeToken() == "" || req.GetCode() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "challenge token and code are required")
+	}
+
+	token, err := h.userService.VerifySecondFactor(ctx, req.ChallengeToken, req.Code, clientIP(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, throttle.ErrThrottled):
+			return nil, throttledStatus(err)
+		case errors.Is(err, service.ErrInvalidToken):
+			h.logger.Warnw("Second factor rejected: invalid challenge", zap.Error(err))
+			return nil, status.Errorf(codes.Unauthenticated, "invalid or expired challenge")
+		case errors.Is(err, service.ErrInvalidCode):
+			h.logger.Warnw("Second factor rejected: invalid code", zap.Error(err))
+			return nil, status.Errorf(codes.Unauthenticated, "invalid code")
+		default:
+			h.logger.Errorw("Internal error during second factor verification", zap.Error(err))
+			return nil, status.Errorf(codes.Internal, "internal server error")
+		}
+	}
+
+	return &api.VerifySecondFactorResponse{Jwt: token}, nil
+}
+
+func (h *UserHandler) EnrollTOTP(ctx context.Context, req *api.EnrollTOTPRequest) (*api.EnrollTOTPResponse, error) {
+	caller, ok := grpcauth.FromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "authentication required")
+	}
+	userID := caller.UserID
+
+	enrollment, err := h.userService.EnrollTOTP(ctx, userID)
+	if err != nil {
+		return nil, h.totpStatus(err, userID)
+	}
+	return &api.EnrollTOTPResponse{Secret: enrollment.Secret, OtpauthUri: enrollment.URI}, nil
+}
+
+func (h *UserHandler) ConfirmTOTP(ctx context.Context, req *api.ConfirmTOTPRequest) (*api.ConfirmTOTPResponse, error) {
+	caller, ok := grpcauth.FromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "authentication required")
+	}
+	userID := caller.UserID
+	if req.GetCode() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "code is required")
+	}
+
+	recoveryCodes, err := h.userService.ConfirmTOTP(ctx, userID, req.Code)
+	if err != nil {
+		return nil, h.totpStatus(err, userID)
+	}
+	return &api.ConfirmTOTPResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+func (h *UserHandler) totpStatus(err error, userID uuid.UUID) error {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		return status.Errorf(codes.NotFound, "user not found")
+	case errors.Is(err, service.ErrAlreadyEnrolled):
+		return status.Errorf(codes.FailedPrecondition, "second factor already enrolled")
+	case errors.Is(err, service.ErrEnrollmentMissing):
+		return status.Errorf(codes.FailedPrecondition, "call EnrollTOTP first")
+	case errors.Is(err, service.ErrEnrollmentChanged):
+		return status.Errorf(codes.Aborted, "enrollment changed concurrently, try again")
+	case errors.Is(err, service.ErrInvalidCode):
+		return status.Errorf(codes.InvalidArgument, "invalid code")
+	default:
+		h.logger.Errorw("Internal error during TOTP enrollment", "userID", userID, zap.Error(err))
+		return status.Errorf(codes.Internal, "internal server error")
+	}
 }
 
 func (h *UserHandler) GetByID(ctx context.Context, req *api.GetByIDRequest) (*api.GetByIDResponse, error) {
@@ -171,3 +267,58 @@ func (h *UserHandler) GetByID(ctx context.Context, req *api.GetByIDRequest) (*ap
 		Username: user.Username,
 	}, nil
 }
+
+// clientIP returns the address of the caller, or "" when unknown.
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		return host
+	}
+	return p.Addr.String()
+}
+
+// throttledStatus tells throttled clients when to retry, in a RetryInfo
+// detail.
+func throttledStatus(err error) error {
+	var throttled *throttle.ThrottledError
+	if !errors.As(err, &throttled) {
+		return status.Error(codes.ResourceExhausted, "too many failed login attempts")
+	}
+	msg := "too many failed login attempts, retry later"
+	if throttled.Locked {
+		msg = "login temporarily locked after too many failed attempts"
+	}
+	st, detailErr := status.New(codes.ResourceExhausted, msg).WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(throttled.RetryAfter),
+	})
+	if detailErr != nil {
+		return status.Error(codes.ResourceExhausted, msg)
+	}
+	return st.Err()
+}
+
+// weakPasswordStatus lists the broken password rules in a BadRequest detail.
+func weakPasswordStatus(err error) error {
+	const msg = "password does not meet the password policy"
+	var policyErr *password.PolicyError
+	if !errors.As(err, &policyErr) {
+		return status.Error(codes.InvalidArgument, msg)
+	}
+
+	badRequest := &errdetails.BadRequest{}
+	for _, v := range policyErr.Violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       "password",
+			Description: v.Description,
+			Reason:      v.Reason,
+		})
+	}
+	st, detailErr := status.New(codes.InvalidArgument, msg).WithDetails(badRequest)
+	if detailErr != nil {
+		return status.Error(codes.InvalidArgument, policyErr.Error())
+	}
+	return st.Err()
+}
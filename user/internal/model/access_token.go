@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccessToken is a personal access token, used by automation in place of a
+// session JWT.
+type AccessToken struct {
+	ID     string
+	UserID uuid.UUID
+	Name   string
+	Scopes []string
+	// Hash is the SHA-256 of the token's secret; the secret is not stored.
+	Hash      string
+	CreatedAt time.Time
+	// ExpiresAt is nil for tokens that do not expire.
+	ExpiresAt *time.Time
+}
+
+// Expired reports whether the token is expired at the given time.
+func (t *AccessToken) Expired(at time.Time) bool {
+	return t.ExpiresAt != nil && !at.Before(*t.ExpiresAt)
+}
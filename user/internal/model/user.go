@@ -1,8 +1,9 @@
 package model
 
 import (
+	"slices"
+
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // User defines user data
@@ -11,25 +12,66 @@ type User struct {
 	Username string    `json:"username"`
 	Email    string    `json:"email"`
 	Password password  `json:"password"`
+	// TOTP is the user's second factor, nil until they start enrolling.
+	TOTP *TOTP `json:"-"`
 }
 
-type password struct {
-	Text *string
-	hash []byte
+// TOTP holds a user's TOTP enrollment.
+type TOTP struct {
+	Secret string
+	// Confirmed is set once the user proved their app generates codes. Only
+	// confirmed enrollments are asked for at login.
+	Confirmed bool
+	// LastStep is the time step of the last accepted code, so a code is
+	// accepted once.
+	LastStep int64
+	// RecoveryCodes holds the hashes of the unused recovery codes.
+	RecoveryCodes []string
 }
 
-func (p *password) Set(text string) error {
-	hash, err := bcrypt.GenerateFromPassword([]byte(text), bcrypt.DefaultCost)
-	if err != nil {
-		return err
+// Clone returns a copy of the enrollment that shares no memory with t.
+func (t *TOTP) Clone() *TOTP {
+	if t == nil {
+		return nil
 	}
+	clone := *t
+	clone.RecoveryCodes = slices.Clone(t.RecoveryCodes)
+	return &clone
+}
 
-	p.Text = &text
-	p.hash = hash
+// Equal reports whether both enrollments are nil or hold the same values.
+func (t *TOTP) Equal(other *TOTP) bool {
+	if t == nil || other == nil {
+		return t == other
+	}
+	return t.Secret == other.Secret &&
+		t.Confirmed == other.Confirmed &&
+		t.LastStep == other.LastStep &&
+		slices.Equal(t.RecoveryCodes, other.RecoveryCodes)
+}
+
+// Clone returns a copy of the user that shares no memory with u.
+func (u *User) Clone() *User {
+	clone := *u
+	clone.TOTP = u.TOTP.Clone()
+	return &clone
+}
+
+// SecondFactorEnabled reports whether logins need a second factor.
+func (u *User) SecondFactorEnabled() bool {
+	return u.TOTP != nil && u.TOTP.Confirmed
+}
 
-	return nil
+// password holds the encoded hash of the user's password, made by one of
+// the password.Hasher implementations.
+type password struct {
+	hash string
+}
+
+func (p *password) SetHash(hash string) {
+	p.hash = hash
 }
 
-func (p *password) Compare(text string) error {
-	return bcrypt.CompareHashAndPassword(p.hash, []byte(text))
+func (p *password) Hash() string {
+	return p.hash
 }
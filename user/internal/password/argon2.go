@@ -0,0 +1,117 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2Prefix = "$" + AlgArgon2id + "$"
+
+type Argon2Params struct {
+	// Memory is in KiB.
+	Memory      uint32 `yaml:"memory" env:"PASSWORD_ARGON2_MEMORY"`
+	Iterations  uint32 `yaml:"iterations" env:"PASSWORD_ARGON2_ITERATIONS"`
+	Parallelism uint8  `yaml:"parallelism" env:"PASSWORD_ARGON2_PARALLELISM"`
+	SaltLength  uint32 `yaml:"salt_length" env:"PASSWORD_ARGON2_SALT_LENGTH"`
+	KeyLength   uint32 `yaml:"key_length" env:"PASSWORD_ARGON2_KEY_LENGTH"`
+}
+
+// DefaultArgon2Params are the second recommended option of RFC 9106.
+func DefaultArgon2Params() Argon2Params {
+	return Argon2Params{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 4,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+func (p Argon2Params) Validate() error {
+	if p.Iterations < 1 || p.Parallelism < 1 || p.Memory < 8*uint32(p.Parallelism) {
+		return errors.New("argon2id needs at least 1 iteration and thread, and 8 KiB of memory per thread")
+	}
+	if p.SaltLength < 8 || p.KeyLength < 16 {
+		return errors.New("argon2id needs a salt of at least 8 bytes and a key of at least 16 bytes")
+	}
+	return nil
+}
+
+// Argon2Hasher hashes with argon2id, encoded as
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>.
+type Argon2Hasher struct {
+	params Argon2Params
+}
+
+func NewArgon2Hasher(params Argon2Params) *Argon2Hasher {
+	return &Argon2Hasher{params: params}
+}
+
+func (h *Argon2Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	p := h.params
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2Prefix, argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *Argon2Hasher) Verify(password, encoded string) error {
+	p, salt, key, err := decodeArgon2(encoded)
+	if err != nil {
+		return err
+	}
+	other := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+func (h *Argon2Hasher) Identifies(encoded string) bool {
+	return strings.HasPrefix(encoded, argon2Prefix)
+}
+
+func (h *Argon2Hasher) NeedsRehash(encoded string) bool {
+	p, _, _, err := decodeArgon2(encoded)
+	return err != nil || p != h.params
+}
+
+func decodeArgon2(encoded string) (p Argon2Params, salt, key []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != AlgArgon2id {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	if version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("%w: argon2 version %d", ErrUnknownHash, version)
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+	if p.Validate() != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	return p, salt, key, nil
+}
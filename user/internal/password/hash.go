@@ -0,0 +1,101 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrMismatch = errors.New("password does not match")
+	// ErrUnknownHash is returned for hashes no configured hasher can verify.
+	ErrUnknownHash = errors.New("unknown password hash algorithm")
+	ErrInvalidHash = errors.New("malformed password hash")
+)
+
+// Hasher hashes passwords with one algorithm. Hashes are encoded with their
+// algorithm and parameters, in PHC string format where the algorithm has one.
+type Hasher interface {
+	Hash(password string) (string, error)
+	// Verify returns ErrMismatch when password does not match encoded.
+	Verify(password, encoded string) error
+	// Identifies reports whether encoded was produced by this algorithm.
+	Identifies(encoded string) bool
+	// NeedsRehash reports whether encoded was produced with other
+	// parameters than the hasher's.
+	NeedsRehash(encoded string) bool
+}
+
+// Hashers hashes new passwords with the current hasher, and verifies those
+// hashed by it or by older ones.
+type Hashers struct {
+	current Hasher
+	all     []Hasher
+}
+
+func NewHashers(current Hasher, older ...Hasher) *Hashers {
+	return &Hashers{current: current, all: append([]Hasher{current}, older...)}
+}
+
+func (h *Hashers) Hash(password string) (string, error) {
+	return h.current.Hash(password)
+}
+
+// Verify checks password against encoded. When it matches, rehash reports
+// whether encoded should be replaced by a hash from the current hasher.
+func (h *Hashers) Verify(password, encoded string) (rehash bool, err error) {
+	for _, hasher := range h.all {
+		if !hasher.Identifies(encoded) {
+			continue
+		}
+		if err := hasher.Verify(password, encoded); err != nil {
+			return false, err
+		}
+		return hasher != h.current || hasher.NeedsRehash(encoded), nil
+	}
+	return false, ErrUnknownHash
+}
+
+// Hash algorithms selectable with PASSWORD_HASHER.
+const (
+	AlgArgon2id = "argon2id"
+	AlgBcrypt   = "bcrypt"
+)
+
+type HashConfig struct {
+	Algorithm string `yaml:"algorithm" env:"PASSWORD_HASHER" usage:"argon2id or bcrypt"`
+	// Argon2 is used for new argon2id hashes.
+	Argon2 Argon2Params `yaml:"argon2"`
+	// BcryptCost is used for new bcrypt hashes.
+	BcryptCost int `yaml:"bcrypt_cost" env:"PASSWORD_BCRYPT_COST"`
+}
+
+func DefaultHashConfig() HashConfig {
+	return HashConfig{
+		Algorithm:  AlgArgon2id,
+		Argon2:     DefaultArgon2Params(),
+		BcryptCost: DefaultBcryptCost,
+	}
+}
+
+func (c HashConfig) Validate() error {
+	switch c.Algorithm {
+	case AlgArgon2id, AlgBcrypt:
+	default:
+		return fmt.Errorf("unknown PASSWORD_HASHER %q, expected argon2id or bcrypt", c.Algorithm)
+	}
+	if c.BcryptCost < MinBcryptCost || c.BcryptCost > MaxBcryptCost {
+		return fmt.Errorf("PASSWORD_BCRYPT_COST must be between %d and %d", MinBcryptCost, MaxBcryptCost)
+	}
+	return c.Argon2.Validate()
+}
+
+// NewHashersFromConfig hashes with the configured algorithm and verifies
+// hashes of both.
+func NewHashersFromConfig(cfg HashConfig) *Hashers {
+	argon2id := NewArgon2Hasher(cfg.Argon2)
+	bcrypt := NewBcryptHasher(cfg.BcryptCost)
+	if cfg.Algorithm == AlgBcrypt {
+		return NewHashers(bcrypt, argon2id)
+	}
+	return NewHashers(argon2id, bcrypt)
+}
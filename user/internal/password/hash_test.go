@@ -0,0 +1,105 @@
+package password_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/CP-Payne/taskflow/user/internal/password"
+)
+
+// fastArgon2 keeps the tests quick; the defaults take tens of milliseconds.
+var fastArgon2 = password.Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2Hasher(t *testing.T) {
+	h := password.NewArgon2Hasher(fastArgon2)
+	encoded, err := h.Hash("Tangerine-Lamp-42")
+	if err != nil {
+		t.Fatalf("Hash() failed: %v", err)
+	}
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$") || !h.Identifies(encoded) {
+		t.Errorf("expected a PHC string with the parameters, got %q", encoded)
+	}
+	if other, _ := h.Hash("Tangerine-Lamp-42"); other == encoded {
+		t.Error("expected a random salt per hash")
+	}
+
+	if err := h.Verify("Tangerine-Lamp-42", encoded); err != nil {
+		t.Errorf("Verify() failed: %v", err)
+	}
+	if err := h.Verify("tangerine-lamp-42", encoded); !errors.Is(err, password.ErrMismatch) {
+		t.Errorf("expected ErrMismatch, got %v", err)
+	}
+	for _, malformed := range []string{"$argon2id$v=19$m=64,t=1,p=1$c2FsdA", "$argon2id$v=19$m=x$c2FsdHNhbHQ$a2V5", "$argon2id$v=19$m=64,t=1,p=1$!!$a2V5"} {
+		if err := h.Verify("Tangerine-Lamp-42", malformed); !errors.Is(err, password.ErrInvalidHash) {
+			t.Errorf("Verify(%q): expected ErrInvalidHash, got %v", malformed, err)
+		}
+	}
+
+	if h.NeedsRehash(encoded) {
+		t.Error("expected a hash with the current parameters to be kept")
+	}
+	stronger := fastArgon2
+	stronger.Iterations = 2
+	if !password.NewArgon2Hasher(stronger).NeedsRehash(encoded) {
+		t.Error("expected a hash with other parameters to need a rehash")
+	}
+}
+
+func TestHashers_Verify(t *testing.T) {
+	bcrypt := password.NewBcryptHasher(password.MinBcryptCost)
+	argon2id := password.NewArgon2Hasher(fastArgon2)
+	hashers := password.NewHashers(argon2id, bcrypt)
+
+	legacy, err := bcrypt.Hash("Tangerine-Lamp-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := hashers.Hash("Tangerine-Lamp-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stronger := fastArgon2
+	stronger.Memory = 128
+	outdated, err := password.NewArgon2Hasher(stronger).Hash("Tangerine-Lamp-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		password   string
+		encoded    string
+		wantRehash bool
+		expectErr  error
+	}{
+		{name: "Keep a current hash", password: "Tangerine-Lamp-42", encoded: current},
+		{name: "Rehash a bcrypt hash", password: "Tangerine-Lamp-42", encoded: legacy, wantRehash: true},
+		{name: "Rehash other argon2id parameters", password: "Tangerine-Lamp-42", encoded: outdated, wantRehash: true},
+		{name: "Reject a wrong password", password: "wrong", encoded: current, expectErr: password.ErrMismatch},
+		{name: "Reject a wrong bcrypt password", password: "wrong", encoded: legacy, expectErr: password.ErrMismatch},
+		{name: "Reject an unknown algorithm", password: "Tangerine-Lamp-42", encoded: "$scrypt$ln=16,r=8,p=1$c2FsdA$a2V5", expectErr: password.ErrUnknownHash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rehash, err := hashers.Verify(tt.password, tt.encoded)
+			if !errors.Is(err, tt.expectErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+			if rehash != tt.wantRehash {
+				t.Errorf("expected rehash %v, got %v", tt.wantRehash, rehash)
+			}
+		})
+	}
+}
+
+func TestBcryptHasher_NeedsRehash(t *testing.T) {
+	h := password.NewBcryptHasher(password.MinBcryptCost)
+	encoded, err := h.Hash("Tangerine-Lamp-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.NeedsRehash(encoded) || !password.NewBcryptHasher(password.MinBcryptCost+1).NeedsRehash(encoded) {
+		t.Error("expected a rehash only when the cost changed")
+	}
+}
@@ -0,0 +1,163 @@
+// Package password checks new passwords against the password policy and a
+// list of breached passwords.
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxBcryptLength is the longest password bcrypt hashes, in bytes.
+const MaxBcryptLength = 72
+
+// defaultMaxLength bounds the work of hashing a password.
+const defaultMaxLength = 128
+
+var ErrWeakPassword = errors.New("password does not meet the password policy")
+
+// Reasons a password is rejected, reported in Violation.Reason.
+const (
+	ReasonTooShort         = "PASSWORD_TOO_SHORT"
+	ReasonTooLong          = "PASSWORD_TOO_LONG"
+	ReasonCharacterClasses = "PASSWORD_CHARACTER_CLASSES"
+	ReasonContainsUsername = "PASSWORD_CONTAINS_USERNAME"
+	ReasonContainsEmail    = "PASSWORD_CONTAINS_EMAIL"
+	ReasonBreached         = "PASSWORD_BREACHED"
+)
+
+// Violation is one rule a password breaks.
+type Violation struct {
+	Reason      string
+	Description string
+}
+
+// PolicyError lists every rule a password breaks.
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	descriptions := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		descriptions[i] = v.Description
+	}
+	return fmt.Sprintf("%v: %s", ErrWeakPassword, strings.Join(descriptions, "; "))
+}
+
+func (e *PolicyError) Unwrap() error {
+	return ErrWeakPassword
+}
+
+type Policy struct {
+	// MinLength is counted in characters, MaxLength in bytes as the hash
+	// limits them.
+	MinLength int `yaml:"min_length" env:"PASSWORD_MIN_LENGTH"`
+	MaxLength int `yaml:"max_length" env:"PASSWORD_MAX_LENGTH"`
+	// MinClasses is how many of lowercase letters, uppercase letters, digits
+	// and other characters the password must mix.
+	MinClasses int `yaml:"min_classes" env:"PASSWORD_MIN_CLASSES"`
+	// ForbidIdentity rejects passwords containing the username or email.
+	ForbidIdentity bool `yaml:"forbid_identity" env:"PASSWORD_FORBID_IDENTITY"`
+	// BreachedList is a file of SHA-1 hashes of breached passwords, one per
+	// line, rejected when set.
+	BreachedList string `yaml:"breached_list" env:"PASSWORD_BREACHED_LIST"`
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		MinLength:      12,
+		MaxLength:      defaultMaxLength,
+		MinClasses:     2,
+		ForbidIdentity: true,
+	}
+}
+
+func (p Policy) Validate() error {
+	if p.MinLength < 1 || p.MaxLength < p.MinLength {
+		return errors.New("the password policy needs 1 <= PASSWORD_MIN_LENGTH <= PASSWORD_MAX_LENGTH")
+	}
+	if p.MinClasses < 0 || p.MinClasses > 4 {
+		return errors.New("PASSWORD_MIN_CLASSES must be between 0 and 4")
+	}
+	return nil
+}
+
+// Validator checks passwords against a policy.
+type Validator struct {
+	policy   Policy
+	breached *BreachedList
+}
+
+// NewValidator returns a validator for policy. breached may be nil to skip
+// the breached passwords check.
+func NewValidator(policy Policy, breached *BreachedList) *Validator {
+	return &Validator{policy: policy, breached: breached}
+}
+
+// Validate returns a *PolicyError listing every rule the password of the
+// given user breaks, or nil.
+func (v *Validator) Validate(password, username, email string) error {
+	var violations []Violation
+	add := func(reason, format string, args ...any) {
+		violations = append(violations, Violation{Reason: reason, Description: fmt.Sprintf(format, args...)})
+	}
+
+	if n := utf8.RuneCountInString(password); n < v.policy.MinLength {
+		add(ReasonTooShort, "must be at least %d characters long", v.policy.MinLength)
+	}
+	if len(password) > v.policy.MaxLength {
+		add(ReasonTooLong, "must be at most %d bytes long", v.policy.MaxLength)
+	}
+	if classes := characterClasses(password); classes < v.policy.MinClasses {
+		add(ReasonCharacterClasses, "must mix at least %d of lowercase letters, uppercase letters, digits and symbols", v.policy.MinClasses)
+	}
+
+	if v.policy.ForbidIdentity {
+		lower := strings.ToLower(password)
+		if contains(lower, username) {
+			add(ReasonContainsUsername, "must not contain the username")
+		}
+		local, _, _ := strings.Cut(email, "@")
+		if contains(lower, email) || contains(lower, local) {
+			add(ReasonContainsEmail, "must not contain the email address")
+		}
+	}
+
+	if v.breached != nil && v.breached.Contains(password) {
+		add(ReasonBreached, "appears in a list of breached passwords")
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// minIdentityLength keeps very short usernames from rejecting most
+// passwords.
+const minIdentityLength = 3
+
+func contains(lowerPassword, s string) bool {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return len(s) >= minIdentityLength && strings.Contains(lowerPassword, s)
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, other int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+	return lower + upper + digit + other
+}
@@ -0,0 +1,117 @@
+package password_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/CP-Payne/taskflow/user/internal/password"
+)
+
+func TestValidator_Validate(t *testing.T) {
+	// SHA-1 of "correct horse battery staple"
+	breached, err := password.ReadBreachedList(strings.NewReader(
+		"# breached passwords\n\nABF7AAD6438836DBE526AA231ABDE2D0EEF74D42:12\n"))
+	if err != nil {
+		t.Fatalf("ReadBreachedList() failed: %v", err)
+	}
+	v := password.NewValidator(password.DefaultPolicy(), breached)
+
+	tests := []struct {
+		name     string
+		password string
+		reasons  []string
+	}{
+		{name: "Accept a strong password", password: "Tangerine-Lamp-42"},
+		{name: "Accept a passphrase mixing two classes", password: "a long passphrase with spaces"},
+		{name: "Reject a short password", password: "Sh0rt!", reasons: []string{password.ReasonTooShort}},
+		{
+			name:     "Reject a password over the maximum length",
+			password: strings.Repeat("aB", 70),
+			reasons:  []string{password.ReasonTooLong},
+		},
+		{name: "Reject a single character class", password: "alllowercaseletters", reasons: []string{password.ReasonCharacterClasses}},
+		{name: "Reject the username", password: "JaneDoe-Rocks-2024", reasons: []string{password.ReasonContainsUsername}},
+		{name: "Reject the email's local part", password: "my-Jane.Doe-2024", reasons: []string{password.ReasonContainsEmail}},
+		{
+			name:     "Reject a breached password",
+			password: "correct horse battery staple",
+			reasons:  []string{password.ReasonBreached},
+		},
+		{
+			name:     "Report every violation",
+			password: "janedoe",
+			reasons:  []string{password.ReasonTooShort, password.ReasonCharacterClasses, password.ReasonContainsUsername},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(tt.password, "janedoe", "jane.doe@example.com")
+			if tt.reasons == nil {
+				if err != nil {
+					t.Fatalf("expected the password to be accepted, got %v", err)
+				}
+				return
+			}
+
+			var policyErr *password.PolicyError
+			if !errors.As(err, &policyErr) || !errors.Is(err, password.ErrWeakPassword) {
+				t.Fatalf("expected a PolicyError, got %v", err)
+			}
+			var reasons []string
+			for _, violation := range policyErr.Violations {
+				reasons = append(reasons, violation.Reason)
+			}
+			if !reflect.DeepEqual(reasons, tt.reasons) {
+				t.Errorf("expected violations %v, got %v", tt.reasons, reasons)
+			}
+		})
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*password.Policy)
+		valid  bool
+	}{
+		{"default", func(*password.Policy) {}, true},
+		{"no character classes", func(p *password.Policy) { p.MinClasses = 0 }, true},
+		{"max below min", func(p *password.Policy) { p.MaxLength = p.MinLength - 1 }, false},
+		{"no minimum length", func(p *password.Policy) { p.MinLength = 0 }, false},
+		{"too many classes", func(p *password.Policy) { p.MinClasses = 5 }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := password.DefaultPolicy()
+			tt.modify(&policy)
+			if err := policy.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate() = %v, expected valid: %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestLoadBreachedList(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "breached.txt")
+	if err := os.WriteFile(path, []byte("abf7aad6438836dbe526aa231abde2d0eef74d42\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	list, err := password.LoadBreachedList(path)
+	if err != nil {
+		t.Fatalf("LoadBreachedList() failed: %v", err)
+	}
+	if list.Len() != 1 || !list.Contains("correct horse battery staple") || list.Contains("Tangerine-Lamp-42") {
+		t.Errorf("expected lowercase hashes to match, got %d hashes", list.Len())
+	}
+
+	for _, content := range []string{"not-a-hash\n", "ABF7AAD6438836DBE526AA231ABDE2D0EEF74D4200\n"} {
+		if _, err := password.ReadBreachedList(strings.NewReader(content)); err == nil {
+			t.Errorf("expected %q to be rejected", content)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package publisher
+
+import (
+	"context"
+
+	"github.com/CP-Payne/taskflow/pkg/events"
+)
+
+type Publisher interface {
+	PublishUserUpdated(ctx context.Context, event *events.UserUpdatedEvent) error
+	PublishUserLockedOut(ctx context.Context, event *events.UserLockedOutEvent) error
+}
@@ -0,0 +1,45 @@
+package publisher
+
+import (
+	"context"
+
+	"github.com/CP-Payne/taskflow/pkg/events"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const producerName = "user-service"
+
+type RedisPublisher struct {
+	rdb    *redis.Client
+	logger *zap.SugaredLogger
+}
+
+func NewRedisPublisher(rdb *redis.Client, logger *zap.SugaredLogger) *RedisPublisher {
+	return &RedisPublisher{rdb: rdb, logger: logger}
+}
+
+func (p *RedisPublisher) PublishUserUpdated(ctx context.Context, event *events.UserUpdatedEvent) error {
+	return p.publish(ctx, events.ChannelUserUpdated, event)
+}
+
+func (p *RedisPublisher) PublishUserLockedOut(ctx context.Context, event *events.UserLockedOutEvent) error {
+	return p.publish(ctx, events.ChannelUserSecurity, event)
+}
+
+func (p *RedisPublisher) publish(ctx context.Context, channel string, event events.Event) error {
+	payload, err := events.DefaultRegistry.Encode(event, events.WithProducer(producerName))
+	if err != nil {
+		p.logger.Errorw("Failed to encode event", "type", event.EventType(), "error", err)
+		return err
+	}
+
+	err = p.rdb.Publish(ctx, channel, payload).Err()
+	if err != nil {
+		p.logger.Errorw("Failed to publish event", "type", event.EventType(), "error", err, "channel", channel)
+		return err
+	}
+
+	p.logger.Infow("Published event", "type", event.EventType(), "channel", channel, "event", event)
+	return nil
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/CP-Payne/taskflow/user/internal/model"
+	"github.com/CP-Payne/taskflow/user/internal/repository"
+	"github.com/google/uuid"
+)
+
+type AccessTokenRepository struct {
+	mu     sync.RWMutex
+	tokens map[string]model.AccessToken
+}
+
+func NewAccessTokenRepository() *AccessTokenRepository {
+	return &AccessTokenRepository{
+		tokens: make(map[string]model.AccessToken),
+	}
+}
+
+func (r *AccessTokenRepository) Create(ctx context.Context, token *model.AccessToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[token.ID] = *token
+	return nil
+}
+
+func (r *AccessTokenRepository) GetByID(ctx context.Context, id string) (*model.AccessToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if t, ok := r.tokens[id]; ok {
+		return &t, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *AccessTokenRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*model.AccessToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var tokens []*model.AccessToken
+	for _, t := range r.tokens {
+		if t.UserID == userID {
+			tokens = append(tokens, &t)
+		}
+	}
+	slices.SortFunc(tokens, func(a, b *model.AccessToken) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return tokens, nil
+}
+
+func (r *AccessTokenRepository) Delete(ctx context.Context, userID uuid.UUID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t, ok := r.tokens[id]; !ok || t.UserID != userID {
+		return repository.ErrNotFound
+	}
+	delete(r.tokens, id)
+	return nil
+}
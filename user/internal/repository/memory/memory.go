@@ -2,13 +2,17 @@ package memory
 
 import (
 	"context"
+	"sync"
 
 	"github.com/CP-Payne/taskflow/user/internal/model"
 	"github.com/CP-Payne/taskflow/user/internal/repository"
 	"github.com/google/uuid"
 )
 
+// MemoryRepository keeps users in memory. It stores and returns copies, so
+// callers never share a *model.User with each other.
 type MemoryRepository struct {
+	mu   sync.RWMutex
 	user map[uuid.UUID]*model.User
 }
 
@@ -19,6 +23,9 @@ func NewInMemory() *MemoryRepository {
 }
 
 func (r *MemoryRepository) Create(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	for _, existingUser := range r.user {
 		if user.Email == existingUser.Email {
 			return repository.ErrDuplicateEmail
@@ -29,22 +36,59 @@ func (r *MemoryRepository) Create(ctx context.Context, user *model.User) error {
 		}
 	}
 
-	r.user[user.ID] = user
+	r.user[user.ID] = user.Clone()
 	return nil
 }
 
 func (r *MemoryRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	for _, v := range r.user {
 		if v.Email == email {
-			return v, nil
+			return v.Clone(), nil
 		}
 	}
 	return nil, repository.ErrNotFound
 }
 
 func (r *MemoryRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	if v, ok := r.user[id]; ok {
-		return v, nil
+		return v.Clone(), nil
 	}
 	return nil, repository.ErrNotFound
 }
+
+func (r *MemoryRepository) UpdatePasswordHash(ctx context.Context, id uuid.UUID, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v, ok := r.user[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	v.Password.SetHash(hash)
+	return nil
+}
+
+func (r *MemoryRepository) UpdateTOTP(ctx context.Context, id uuid.UUID, previous, totp *model.TOTP) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v, ok := r.user[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if !v.TOTP.Equal(previous) {
+		return repository.ErrConflict
+	}
+	v.TOTP = totp.Clone()
+	return nil
+}
+
+func (r *MemoryRepository) Ping(ctx context.Context) error {
+	return nil
+}
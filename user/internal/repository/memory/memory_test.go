@@ -2,6 +2,9 @@ package memory_test
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/CP-Payne/taskflow/user/internal/model"
@@ -217,3 +220,55 @@ func TestMemoryRepository_GetByEmail(t *testing.T) {
 		})
 	}
 }
+
+func TestMemoryRepository_UpdateTOTP(t *testing.T) {
+	repo := memory.NewInMemory()
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Username: "testuser"}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	enrolled := &model.TOTP{Secret: "SECRET", Confirmed: true, LastStep: 10, RecoveryCodes: []string{"a", "b"}}
+	if err := repo.UpdateTOTP(ctx, user.ID, nil, enrolled); err != nil {
+		t.Fatalf("UpdateTOTP() failed: %v", err)
+	}
+
+	// Concurrent logins that read the same enrollment race to mark a code
+	// used; only one of them may win.
+	const logins = 20
+	var wg sync.WaitGroup
+	var won atomic.Int32
+	for i := 0; i < logins; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			read, err := repo.GetByID(ctx, user.ID)
+			if err != nil {
+				t.Errorf("GetByID() failed: %v", err)
+				return
+			}
+			used := read.TOTP.Clone()
+			used.RecoveryCodes = used.RecoveryCodes[1:]
+			err = repo.UpdateTOTP(ctx, user.ID, enrolled, used)
+			switch {
+			case err == nil:
+				won.Add(1)
+			case !errors.Is(err, repository.ErrConflict):
+				t.Errorf("expected ErrConflict, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := won.Load(); got != 1 {
+		t.Errorf("expected exactly one update to win, got %d", got)
+	}
+
+	// Changing a returned user does not change the stored one
+	read, _ := repo.GetByID(ctx, user.ID)
+	read.TOTP.RecoveryCodes[0] = "changed"
+	stored, _ := repo.GetByID(ctx, user.ID)
+	if stored.TOTP.RecoveryCodes[0] != "b" {
+		t.Errorf("stored enrollment was changed through a returned user: %+v", stored.TOTP)
+	}
+}
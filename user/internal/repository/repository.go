@@ -12,10 +12,30 @@ var (
 	ErrDuplicateUsername = errors.New("username already exist")
 	ErrDuplicateEmail    = errors.New("email already exist")
 	ErrNotFound          = errors.New("resource not found")
+	// ErrConflict is returned when a record changed since it was read.
+	ErrConflict = errors.New("record changed concurrently")
 )
 
 type UserRepository interface {
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
 	Create(context.Context, *model.User) error
+	// UpdatePasswordHash replaces the stored hash of the user's password.
+	UpdatePasswordHash(ctx context.Context, id uuid.UUID, hash string) error
+	// UpdateTOTP replaces the user's TOTP enrollment if it still equals
+	// previous, and returns ErrConflict otherwise. This keeps concurrent
+	// logins from accepting the same code twice.
+	UpdateTOTP(ctx context.Context, id uuid.UUID, previous, totp *model.TOTP) error
+	// Ping reports whether the storage is reachable.
+	Ping(ctx context.Context) error
+}
+
+// AccessTokenRepository stores personal access tokens.
+type AccessTokenRepository interface {
+	Create(ctx context.Context, token *model.AccessToken) error
+	GetByID(ctx context.Context, id string) (*model.AccessToken, error)
+	// ListByUser returns the user's tokens, oldest first.
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*model.AccessToken, error)
+	// Delete removes the user's token, or returns ErrNotFound.
+	Delete(ctx context.Context, userID uuid.UUID, id string) error
 }
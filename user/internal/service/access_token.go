@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/CP-Payne/taskflow/pkg/grpcauth"
+	"github.com/CP-Payne/taskflow/user/internal/model"
+	"github.com/CP-Payne/taskflow/user/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// AccessTokenPrefix starts every personal access token, so they are told
+// apart from JWTs and found by secret scanners.
+const AccessTokenPrefix = "tfp_"
+
+const (
+	maxTokenNameLength = 100
+	// maxTokensPerUser bounds the tokens a user keeps at once.
+	maxTokensPerUser = 50
+)
+
+var (
+	ErrInvalidAccessToken = errors.New("invalid access token request")
+	ErrTooManyTokens      = errors.New("too many access tokens")
+)
+
+// AccessTokenService issues personal access tokens, and verifies them
+// alongside session JWTs.
+type AccessTokenService struct {
+	repo   repository.AccessTokenRepository
+	users  *UserService
+	logger *zap.SugaredLogger
+	now    func() time.Time
+}
+
+func NewAccessTokenService(repo repository.AccessTokenRepository, users *UserService, logger *zap.SugaredLogger) *AccessTokenService {
+	return &AccessTokenService{
+		repo:   repo,
+		users:  users,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Create issues a token to the user and returns it with its secret, which
+// is not stored and cannot be shown again. expiresAt may be nil.
+func (s *AccessTokenService) Create(ctx context.Context, userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (*model.AccessToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxTokenNameLength {
+		return nil, "", fmt.Errorf("%w: name must have 1 to %d characters", ErrInvalidAccessToken, maxTokenNameLength)
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	now := s.now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, "", fmt.Errorf("%w: expiry must be in the future", ErrInvalidAccessToken)
+	}
+
+	existing, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		s.logger.Errorw("Failed to list access tokens", "userID", userID, "error", err)
+		return nil, "", ErrInternal
+	}
+	if len(existing) >= maxTokensPerUser {
+		return nil, "", ErrTooManyTokens
+	}
+
+	id, secret, err := newAccessTokenSecret()
+	if err != nil {
+		s.logger.Errorw("Failed to generate access token", "userID", userID, "error", err)
+		return nil, "", ErrInternal
+	}
+	token := &model.AccessToken{
+		ID:        id,
+		UserID:    userID,
+		Name:      name,
+		Scopes:    scopes,
+		Hash:      hashAccessTokenSecret(secret),
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.repo.Create(ctx, token); err != nil {
+		s.logger.Errorw("Failed to save access token", "userID", userID, "error", err)
+		return nil, "", ErrInternal
+	}
+
+	s.logger.Infow("Security event: access token created",
+		"event", "access_token.created",
+		"userID", userID,
+		"tokenID", id,
+		"scopes", scopes,
+		"expiresAt", expiresAt,
+	)
+	return token, AccessTokenPrefix + id + "_" + secret, nil
+}
+
+func (s *AccessTokenService) List(ctx context.Context, userID uuid.UUID) ([]*model.AccessToken, error) {
+	tokens, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		s.logger.Errorw("Failed to list access tokens", "userID", userID, "error", err)
+		return nil, ErrInternal
+	}
+	return tokens, nil
+}
+
+func (s *AccessTokenService) Revoke(ctx context.Context, userID uuid.UUID, id string) error {
+	if err := s.repo.Delete(ctx, userID, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNotFound
+		}
+		s.logger.Errorw("Failed to revoke access token", "userID", userID, "tokenID", id, "error", err)
+		return ErrInternal
+	}
+	s.logger.Infow("Security event: access token revoked", "event", "access_token.revoked", "userID", userID, "tokenID", id)
+	return nil
+}
+
+// Verify authenticates a personal access token or a session JWT.
+func (s *AccessTokenService) Verify(ctx context.Context, token string) (*grpcauth.Principal, error) {
+	if !strings.HasPrefix(token, AccessTokenPrefix) {
+		userID, err := s.users.ParseToken(ctx, token)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", grpcauth.ErrInvalidToken, err)
+		}
+		return &grpcauth.Principal{UserID: userID}, nil
+	}
+
+	id, secret, ok := strings.Cut(strings.TrimPrefix(token, AccessTokenPrefix), "_")
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed access token", grpcauth.ErrInvalidToken)
+	}
+	stored, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: unknown or revoked access token", grpcauth.ErrInvalidToken)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read access token: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(stored.Hash), []byte(hashAccessTokenSecret(secret))) != 1 {
+		return nil, fmt.Errorf("%w: access token secret mismatch", grpcauth.ErrInvalidToken)
+	}
+	if stored.Expired(s.now()) {
+		return nil, fmt.Errorf("%w: access token expired", grpcauth.ErrInvalidToken)
+	}
+	return &grpcauth.Principal{UserID: stored.UserID, TokenID: stored.ID, Scopes: stored.Scopes}, nil
+}
+
+// normalizeScopes sorts and deduplicates scopes, which must be known.
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidAccessToken)
+	}
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !slices.Contains(grpcauth.Scopes, scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q, expected one of %s", ErrInvalidAccessToken, scope, strings.Join(grpcauth.Scopes, ", "))
+		}
+		normalized = append(normalized, scope)
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}
+
+// newAccessTokenSecret returns a random token ID, used to look the token up,
+// and secret. The secret carries 256 bits, so a fast hash protects it.
+func newAccessTokenSecret() (id, secret string, err error) {
+	raw := make([]byte, 8+32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(raw[:8]), base64.RawURLEncoding.EncodeToString(raw[8:]), nil
+}
+
+func hashAccessTokenSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
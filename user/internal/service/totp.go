@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/CP-Payne/taskflow/user/internal/model"
+	"github.com/CP-Payne/taskflow/user/internal/repository"
+	"github.com/CP-Payne/taskflow/user/internal/totp"
+	"github.com/google/uuid"
+)
+
+// totpIssuer names the service in authenticator apps.
+const totpIssuer = "Taskflow"
+
+var (
+	ErrInvalidCode       = errors.New("invalid second factor code")
+	ErrAlreadyEnrolled   = errors.New("second factor already enrolled")
+	ErrEnrollmentMissing = errors.New("no pending second factor enrollment")
+	// ErrEnrollmentChanged is returned when another request changed the
+	// enrollment at the same time. Enrolling again resolves it.
+	ErrEnrollmentChanged = errors.New("second factor enrollment changed concurrently")
+)
+
+// Enrollment is a new TOTP secret, awaiting confirmation with a first code.
+type Enrollment struct {
+	Secret string
+	URI    string
+}
+
+// EnrollTOTP starts enrolling the user in TOTP. Until ConfirmTOTP succeeds
+// logins keep working with the password alone, and enrolling again replaces
+// the secret.
+func (s *UserService) EnrollTOTP(ctx context.Context, userID uuid.UUID) (*Enrollment, error) {
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.SecondFactorEnabled() {
+		return nil, ErrAlreadyEnrolled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		s.logger.Errorw("Failed to generate TOTP secret", "userID", userID, "error", err)
+		return nil, ErrInternal
+	}
+	err = s.repo.UpdateTOTP(ctx, userID, user.TOTP, &model.TOTP{Secret: secret})
+	if errors.Is(err, repository.ErrConflict) {
+		return nil, ErrEnrollmentChanged
+	} else if err != nil {
+		s.logger.Errorw("Failed to save TOTP enrollment", "userID", userID, "error", err)
+		return nil, ErrInternal
+	}
+
+	s.logger.Infow("Started TOTP enrollment", "userID", userID)
+	return &Enrollment{Secret: secret, URI: totp.URI(totpIssuer, user.Email, secret)}, nil
+}
+
+// ConfirmTOTP completes the enrollment once code matches the new secret, and
+// returns the recovery codes. Only their hashes are kept, so they cannot be
+// shown again.
+func (s *UserService) ConfirmTOTP(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case user.SecondFactorEnabled():
+		return nil, ErrAlreadyEnrolled
+	case user.TOTP == nil:
+		return nil, ErrEnrollmentMissing
+	}
+
+	step, ok, err := totp.Validate(user.TOTP.Secret, code, time.Now(), 0)
+	if err != nil {
+		s.logger.Errorw("Failed to validate TOTP code", "userID", userID, "error", err)
+		return nil, ErrInternal
+	}
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	codes, hashes, err := totp.GenerateRecoveryCodes(totp.RecoveryCodeCount)
+	if err != nil {
+		s.logger.Errorw("Failed to generate recovery codes", "userID", userID, "error", err)
+		return nil, ErrInternal
+	}
+	enrolled := &model.TOTP{
+		Secret:        user.TOTP.Secret,
+		Confirmed:     true,
+		LastStep:      step,
+		RecoveryCodes: hashes,
+	}
+	err = s.repo.UpdateTOTP(ctx, userID, user.TOTP, enrolled)
+	if errors.Is(err, repository.ErrConflict) {
+		// Confirmed by a concurrent call with the same code, or the secret
+		// was replaced since the code was checked
+		return nil, ErrInvalidCode
+	} else if err != nil {
+		s.logger.Errorw("Failed to save TOTP enrollment", "userID", userID, "error", err)
+		return nil, ErrInternal
+	}
+
+	s.logger.Infow("Security event: second factor enrolled", "event", "totp.enrolled", "userID", userID)
+	s.userUpdated(ctx, userID)
+	return codes, nil
+}
+
+// VerifySecondFactor exchanges a challenge token from AuthenticateUser and a
+// TOTP or recovery code for the access token. Wrong codes count as failed
+// logins.
+func (s *UserService) VerifySecondFactor(ctx context.Context, challengeToken, code, clientIP string) (string, error) {
+	userID, err := s.parseToken(ctx, challengeToken, challengeAudience)
+	if err != nil {
+		return "", err
+	}
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", ErrInvalidToken
+		}
+		return "", ErrInternal
+	}
+	if !user.SecondFactorEnabled() {
+		return "", ErrInvalidToken
+	}
+
+	if err := s.allowLogin(ctx, user.Email, clientIP); err != nil {
+		return "", err
+	}
+
+	updated, err := s.checkCode(user, code)
+	if err != nil {
+		s.releaseLogin(ctx, user.Email, clientIP)
+		return "", err
+	}
+	if updated == nil {
+		s.loginFailed(ctx, user.Email, clientIP, user)
+		return "", ErrInvalidCode
+	}
+	// Saving the used code is what keeps it from being replayed, so it only
+	// succeeds if no concurrent login used a code since user was read
+	err = s.repo.UpdateTOTP(ctx, user.ID, user.TOTP, updated)
+	if errors.Is(err, repository.ErrConflict) {
+		s.logger.Warnw("Second factor code raced a concurrent login", "userID", user.ID)
+		s.loginFailed(ctx, user.Email, clientIP, user)
+		return "", ErrInvalidCode
+	} else if err != nil {
+		s.logger.Errorw("Failed to save used second factor code", "userID", user.ID, "error", err)
+		s.releaseLogin(ctx, user.Email, clientIP)
+		return "", ErrInternal
+	}
+
+	s.loginSucceeded(ctx, user.Email, clientIP)
+	return s.issueToken(ctx, user, tokenAudience, TokenTTL)
+}
+
+// checkCode returns the enrollment with code marked as used, or nil when
+// code is neither the current TOTP code nor an unused recovery code.
+func (s *UserService) checkCode(user *model.User, code string) (*model.TOTP, error) {
+	current := user.TOTP
+	updated := *current.Clone()
+
+	step, ok, err := totp.Validate(current.Secret, code, time.Now(), current.LastStep)
+	if err != nil {
+		s.logger.Errorw("Failed to validate TOTP code", "userID", user.ID, "error", err)
+		return nil, ErrInternal
+	}
+	if ok {
+		updated.LastStep = step
+		return &updated, nil
+	}
+
+	if i := totp.MatchRecoveryCode(current.RecoveryCodes, code); i >= 0 {
+		updated.RecoveryCodes = slices.Delete(updated.RecoveryCodes, i, i+1)
+		s.logger.Warnw("Security event: recovery code used",
+			"event", "totp.recovery_code_used",
+			"userID", user.ID,
+			"remaining", len(updated.RecoveryCodes),
+		)
+		return &updated, nil
+	}
+	return nil, nil
+}
@@ -3,11 +3,17 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
 	"time"
 
+	"github.com/CP-Payne/taskflow/pkg/events"
 	"github.com/CP-Payne/taskflow/user/internal/auth"
 	"github.com/CP-Payne/taskflow/user/internal/model"
+	"github.com/CP-Payne/taskflow/user/internal/password"
+	"github.com/CP-Payne/taskflow/user/internal/publisher"
 	"github.com/CP-Payne/taskflow/user/internal/repository"
+	"github.com/CP-Payne/taskflow/user/internal/throttle"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -18,24 +24,70 @@ var (
 	ErrNotFound        = errors.New("resource not found")
 	ErrInternal        = errors.New("internal server error")
 	ErrInvalidPassword = errors.New("invalid password hash")
+	ErrInvalidToken    = errors.New("invalid token")
 )
 
+// Token audiences, which keep challenge tokens from being used as access
+// tokens and the other way around.
+const (
+	tokenIssuer       = "taskflow-user-service"
+	tokenAudience     = "taskflow-api"
+	challengeAudience = "taskflow-second-factor"
+	// challengeTTL is how long the user has to enter their second factor.
+	challengeTTL = 5 * time.Minute
+)
+
+// TokenTTL is the lifetime of access tokens, and so how long a replaced
+// signing key must keep verifying.
+const TokenTTL = 3 * 24 * time.Hour
+
+// Login is the outcome of a correct password: the access token, or for users
+// with a second factor the challenge to exchange for it.
+type Login struct {
+	Token          string
+	ChallengeToken string
+}
+
 type UserService struct {
 	repo          repository.UserRepository
 	logger        *zap.SugaredLogger
 	authenticator auth.Authenticator
+	limiter       *throttle.Limiter
+	publisher     publisher.Publisher
+	passwords     *password.Validator
+	hashers       *password.Hashers
 }
 
-func New(repo repository.UserRepository, authenticator auth.Authenticator, logger *zap.SugaredLogger) *UserService {
+// New creates the user service. A nil limiter disables login throttling, a
+// nil publisher lockout and update events, and a nil validator the password policy.
+func New(repo repository.UserRepository, authenticator auth.Authenticator, logger *zap.SugaredLogger, limiter *throttle.Limiter, publisher publisher.Publisher, passwords *password.Validator, hashers *password.Hashers) *UserService {
 	return &UserService{
 		repo:          repo,
 		authenticator: authenticator,
 		logger:        logger,
+		limiter:       limiter,
+		publisher:     publisher,
+		passwords:     passwords,
+		hashers:       hashers,
 	}
 }
 
-func (s *UserService) RegisterUser(ctx context.Context, user *model.User) error {
-	err := s.repo.Create(ctx, user)
+// RegisterUser sets the user's password and saves the user. It returns a
+// *password.PolicyError listing every rule a weak password breaks.
+func (s *UserService) RegisterUser(ctx context.Context, user *model.User, plaintext string) error {
+	if s.passwords != nil {
+		if err := s.passwords.Validate(plaintext, user.Username, user.Email); err != nil {
+			return err
+		}
+	}
+	hash, err := s.hashers.Hash(plaintext)
+	if err != nil {
+		s.logger.Errorw("Failed to hash password", "email", user.Email, "error", err)
+		return ErrInternal
+	}
+	user.Password.SetHash(hash)
+
+	err = s.repo.Create(ctx, user)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrDuplicateEmail) || errors.Is(err, repository.ErrDuplicateUsername):
@@ -48,36 +100,130 @@ func (s *UserService) RegisterUser(ctx context.Context, user *model.User) error
 	return nil
 }
 
-func (s *UserService) AuthenticateUser(ctx context.Context, user *model.User) (string, error) {
-	userDB, err := s.repo.GetByEmail(ctx, user.Email)
+// AuthenticateUser checks the user's email and password. Users enrolled in
+// TOTP get a challenge token for VerifySecondFactor instead of the access
+// token. clientIP may be empty when unknown. Once failed attempts are
+// throttled it returns a *throttle.ThrottledError without checking the
+// password.
+func (s *UserService) AuthenticateUser(ctx context.Context, email, plaintext, clientIP string) (*Login, error) {
+	if err := s.allowLogin(ctx, email, clientIP); err != nil {
+		return nil, err
+	}
+
+	userDB, err := s.repo.GetByEmail(ctx, email)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrNotFound):
-			return "", ErrNotFound
+			// Guessing unknown emails counts too, against the IP mostly
+			s.loginFailed(ctx, email, clientIP, nil)
+			return nil, ErrNotFound
 		default:
-			return "", ErrInternal
+			s.releaseLogin(ctx, email, clientIP)
+			return nil, ErrInternal
+		}
+	}
+
+	rehash, err := s.hashers.Verify(plaintext, userDB.Password.Hash())
+	if errors.Is(err, password.ErrMismatch) {
+		s.loginFailed(ctx, email, clientIP, userDB)
+		return nil, ErrInvalidPassword
+	} else if err != nil {
+		s.logger.Errorw("Failed to verify password hash", "userID", userDB.ID, "error", err)
+		s.releaseLogin(ctx, email, clientIP)
+		return nil, ErrInternal
+	}
+	if rehash {
+		s.rehashPassword(ctx, userDB, plaintext)
+	}
+
+	if userDB.SecondFactorEnabled() {
+		// Failures are kept until the second factor is verified, so the
+		// password alone does not reset the budget for guessing codes
+		s.releaseLogin(ctx, email, clientIP)
+		challenge, err := s.issueToken(ctx, userDB, challengeAudience, challengeTTL)
+		if err != nil {
+			return nil, err
 		}
+		return &Login{ChallengeToken: challenge}, nil
 	}
 
-	if err := userDB.Password.Compare(*user.Password.Text); err != nil {
-		return "", ErrInvalidPassword
+	s.loginSucceeded(ctx, email, clientIP)
+	token, err := s.issueToken(ctx, userDB, tokenAudience, TokenTTL)
+	if err != nil {
+		return nil, err
 	}
+	return &Login{Token: token}, nil
+}
 
+func (s *UserService) issueToken(ctx context.Context, user *model.User, audience string, ttl time.Duration) (string, error) {
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"sub": user.ID,
-		"exp": time.Now().Add(time.Hour * 24 * 3).Unix(),
-		"iat": time.Now().Unix(),
-		"nbf": time.Now().Unix(),
-		"iss": "taskflow-user-service",
-		"aud": "taskflow-api",
+		"exp": now.Add(ttl).Unix(),
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"iss": tokenIssuer,
+		"aud": audience,
 	}
-	token, err := s.authenticator.GenerateToken(claims)
+	token, err := s.authenticator.GenerateToken(ctx, claims)
 	if err != nil {
+		s.logger.Errorw("Failed to generate token", "userID", user.ID, "audience", audience, "error", err)
 		return "", ErrInternal
 	}
 	return token, nil
 }
 
+// ParseToken returns the user an access token was issued to.
+func (s *UserService) ParseToken(ctx context.Context, token string) (uuid.UUID, error) {
+	return s.parseToken(ctx, token, tokenAudience)
+}
+
+func (s *UserService) parseToken(ctx context.Context, token, audience string) (uuid.UUID, error) {
+	parsed, err := s.authenticator.ValidateToken(ctx, token)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	audiences, err := parsed.Claims.GetAudience()
+	if err != nil || !slices.Contains(audiences, audience) {
+		return uuid.Nil, fmt.Errorf("%w: not issued for %s", ErrInvalidToken, audience)
+	}
+	subject, err := parsed.Claims.GetSubject()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	userID, err := uuid.Parse(subject)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: subject: %v", ErrInvalidToken, err)
+	}
+	return userID, nil
+}
+
+// rehashPassword replaces a hash made with an older algorithm or parameters.
+// The login succeeds regardless, and the next one tries again on failure.
+func (s *UserService) rehashPassword(ctx context.Context, user *model.User, plaintext string) {
+	hash, err := s.hashers.Hash(plaintext)
+	if err != nil {
+		s.logger.Errorw("Failed to rehash password", "userID", user.ID, "error", err)
+		return
+	}
+	if err := s.repo.UpdatePasswordHash(ctx, user.ID, hash); err != nil {
+		s.logger.Errorw("Failed to save rehashed password", "userID", user.ID, "error", err)
+		return
+	}
+	s.logger.Infow("Rehashed password with the current parameters", "userID", user.ID)
+	s.userUpdated(ctx, user.ID)
+}
+
+// userUpdated tells consumers caching the user's details to drop them. The
+// change is saved regardless, and their caches expire on their own, so
+// failures are only logged.
+func (s *UserService) userUpdated(ctx context.Context, userID uuid.UUID) {
+	if s.publisher == nil {
+		return
+	}
+	_ = s.publisher.PublishUserUpdated(ctx, &events.UserUpdatedEvent{UserID: userID.String()})
+}
+
 func (s *UserService) GetByID(ctx context.Context, userID uuid.UUID) (*model.User, error) {
 	user, err := s.repo.GetByID(ctx, userID)
 	if err != nil {
@@ -89,3 +235,74 @@ func (s *UserService) GetByID(ctx context.Context, userID uuid.UUID) (*model.Use
 
 	return user, nil
 }
+
+// allowLogin reserves the attempt, which loginFailed, loginSucceeded or
+// releaseLogin must follow. It fails open when the failure counters cannot
+// be read, so an unavailable store does not lock everyone out.
+func (s *UserService) allowLogin(ctx context.Context, email, clientIP string) error {
+	if s.limiter == nil {
+		return nil
+	}
+	err := s.limiter.Allow(ctx, email, clientIP)
+	if errors.Is(err, throttle.ErrThrottled) {
+		s.logger.Warnw("Login attempt throttled", "email", email, "ip", clientIP, "error", err)
+		return err
+	} else if err != nil {
+		s.logger.Errorw("Failed to check login throttling, allowing the attempt", "email", email, "error", err)
+	}
+	return nil
+}
+
+// loginFailed counts a failed login. userDB is the account, nil when the
+// email is unknown.
+func (s *UserService) loginFailed(ctx context.Context, email, clientIP string, userDB *model.User) {
+	if s.limiter == nil {
+		return
+	}
+	lockouts, err := s.limiter.Failure(ctx, email, clientIP)
+	if err != nil {
+		s.logger.Errorw("Failed to count failed login", "email", email, "error", err)
+	}
+
+	for _, lockout := range lockouts {
+		s.logger.Warnw("Security event: login locked out",
+			"event", "login.lockout",
+			"scope", lockout.Scope,
+			"email", email,
+			"ip", clientIP,
+			"failures", lockout.Failures,
+			"lockedUntil", lockout.Until,
+		)
+		if lockout.Scope != throttle.ScopeAccount || userDB == nil || s.publisher == nil {
+			continue
+		}
+		event := &events.UserLockedOutEvent{
+			UserID:      userDB.ID.String(),
+			LockedUntil: lockout.Until,
+			IP:          clientIP,
+			Failures:    lockout.Failures,
+		}
+		// The lockout holds without the notification, so only log failures
+		_ = s.publisher.PublishUserLockedOut(ctx, event)
+	}
+}
+
+func (s *UserService) loginSucceeded(ctx context.Context, email, clientIP string) {
+	if s.limiter == nil {
+		return
+	}
+	if err := s.limiter.Success(ctx, email, clientIP); err != nil {
+		s.logger.Errorw("Failed to reset failed logins", "email", email, "error", err)
+	}
+}
+
+// releaseLogin drops the attempt allowLogin reserved when it neither failed
+// nor succeeded. A reservation that is not released expires on its own.
+func (s *UserService) releaseLogin(ctx context.Context, email, clientIP string) {
+	if s.limiter == nil {
+		return
+	}
+	if err := s.limiter.Release(ctx, email, clientIP); err != nil {
+		s.logger.Errorw("Failed to release login attempt", "email", email, "error", err)
+	}
+}
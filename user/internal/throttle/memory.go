@@ -0,0 +1,124 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many writes pass between removals of expired records.
+const sweepEvery = 1024
+
+type memoryRecord struct {
+	Record
+	expires time.Time
+}
+
+// MemoryStore keeps records in the process, for a single instance.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]*memoryRecord
+	writes  int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]*memoryRecord)}
+}
+
+func (s *MemoryStore) Reserve(_ context.Context, key string, now time.Time, p Policy) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rec Record
+	if r := s.get(key); r != nil {
+		rec = r.Record
+	}
+	if !now.Before(rec.LastAttempt.Add(p.Hold)) {
+		rec.Pending = 0
+	}
+	if p.throttled(rec, now) != nil {
+		return rec, false, nil
+	}
+
+	r := s.getOrCreate(key)
+	r.Pending = rec.Pending + 1
+	r.LastAttempt = now
+	r.expires = later(r.expires, time.Now().Add(p.Hold))
+	return rec, true, nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r := s.get(key); r != nil && r.Pending > 0 {
+		r.Pending--
+	}
+	return nil
+}
+
+func (s *MemoryStore) AddFailure(_ context.Context, key string, at time.Time, ttl time.Duration) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.getOrCreate(key)
+	r.Failures++
+	r.LastFailure = at
+	if r.Pending > 0 {
+		r.Pending--
+	}
+	r.expires = later(r.expires, time.Now().Add(ttl))
+	return r.Record, nil
+}
+
+func (s *MemoryStore) Lock(_ context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.getOrCreate(key)
+	r.Failures = 0
+	r.LockedUntil = until
+	r.expires = later(r.expires, until)
+	return nil
+}
+
+func (s *MemoryStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+func (s *MemoryStore) get(key string) *memoryRecord {
+	r, ok := s.records[key]
+	if !ok {
+		return nil
+	}
+	if !time.Now().Before(r.expires) {
+		delete(s.records, key)
+		return nil
+	}
+	return r
+}
+
+func (s *MemoryStore) getOrCreate(key string) *memoryRecord {
+	s.writes++
+	if s.writes%sweepEvery == 0 {
+		now := time.Now()
+		for k, r := range s.records {
+			if !now.Before(r.expires) {
+				delete(s.records, k)
+			}
+		}
+	}
+
+	r := s.get(key)
+	if r == nil {
+		r = &memoryRecord{}
+		s.records[key] = r
+	}
+	return r
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
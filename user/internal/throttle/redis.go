@@ -0,0 +1,178 @@
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "login:throttle:"
+
+// Hash fields of a record.
+const (
+	fieldFailures    = "failures"
+	fieldLastFailure = "last_failure"
+	fieldLockedUntil = "locked_until"
+	fieldPending     = "pending"
+	fieldLastAttempt = "last_attempt"
+)
+
+// recordFields is the order in which the scripts return a record.
+var recordFields = []string{fieldFailures, fieldLastFailure, fieldLockedUntil, fieldPending, fieldLastAttempt}
+
+// RedisStore keeps records in Redis hashes, shared by all instances. Each
+// hash expires with the record it holds.
+type RedisStore struct {
+	rdb redis.UniversalClient
+}
+
+func NewRedisStore(rdb redis.UniversalClient) *RedisStore {
+	return &RedisStore{rdb: rdb}
+}
+
+// reserveScript mirrors Policy.throttled. It returns the record as it was
+// checked, in recordFields order, followed by 1 if the attempt was reserved.
+var reserveScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local max = tonumber(ARGV[2])
+local hold = tonumber(ARGV[5])
+local f = redis.call('HMGET', KEYS[1], 'failures', 'last_failure', 'locked_until', 'pending', 'last_attempt')
+local failures = tonumber(f[1]) or 0
+local lastFailure = tonumber(f[2]) or 0
+local lockedUntil = tonumber(f[3]) or 0
+local pending = tonumber(f[4]) or 0
+local lastAttempt = tonumber(f[5]) or 0
+if now >= lastAttempt + hold then
+	pending = 0
+end
+local function result(reserved)
+	return {failures, lastFailure, lockedUntil, pending, lastAttempt, reserved}
+end
+
+if now < lockedUntil then
+	return result(0)
+end
+local nextAt = 0
+if failures > 0 then
+	local delay = tonumber(ARGV[3])
+	local maxDelay = tonumber(ARGV[4])
+	for i = 2, failures do
+		if delay >= maxDelay then
+			break
+		end
+		delay = delay * 2
+	end
+	nextAt = lastFailure + math.min(delay, maxDelay)
+end
+if failures + pending >= max and pending > 0 then
+	nextAt = math.max(nextAt, lastAttempt + hold)
+end
+if now < nextAt then
+	return result(0)
+end
+
+redis.call('HSET', KEYS[1], 'pending', pending + 1, 'last_attempt', ARGV[1])
+-- A longer expiry is kept for the failures
+if redis.call('PTTL', KEYS[1]) < hold then
+	redis.call('PEXPIRE', KEYS[1], hold)
+end
+return result(1)
+`)
+
+// releaseScript drops a pending attempt without creating the record.
+var releaseScript = redis.NewScript(`
+local pending = tonumber(redis.call('HGET', KEYS[1], 'pending'))
+if pending and pending > 0 then
+	redis.call('HINCRBY', KEYS[1], 'pending', -1)
+end
+return 0
+`)
+
+// failureScript counts a failure in place of a pending attempt and returns
+// the record in recordFields order.
+var failureScript = redis.NewScript(`
+redis.call('HINCRBY', KEYS[1], 'failures', 1)
+redis.call('HSET', KEYS[1], 'last_failure', ARGV[1])
+local pending = tonumber(redis.call('HGET', KEYS[1], 'pending'))
+if pending and pending > 0 then
+	redis.call('HINCRBY', KEYS[1], 'pending', -1)
+end
+-- Failures are only counted outside of a lockout, which the expiry cannot
+-- cut short then
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return redis.call('HMGET', KEYS[1], 'failures', 'last_failure', 'locked_until', 'pending', 'last_attempt')
+`)
+
+func (s *RedisStore) Reserve(ctx context.Context, key string, now time.Time, p Policy) (Record, bool, error) {
+	res, err := reserveScript.Run(ctx, s.rdb, []string{redisKeyPrefix + key},
+		now.UnixMilli(), p.Max, p.BaseDelay.Milliseconds(), p.MaxDelay.Milliseconds(), p.Hold.Milliseconds(),
+	).Slice()
+	if err != nil {
+		return Record{}, false, err
+	}
+	if len(res) != len(recordFields)+1 {
+		return Record{}, false, fmt.Errorf("unexpected reserve result %v", res)
+	}
+	reserved, _ := res[len(recordFields)].(int64)
+	return parseRecord(res[:len(recordFields)]), reserved == 1, nil
+}
+
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	return releaseScript.Run(ctx, s.rdb, []string{redisKeyPrefix + key}).Err()
+}
+
+func (s *RedisStore) AddFailure(ctx context.Context, key string, at time.Time, ttl time.Duration) (Record, error) {
+	res, err := failureScript.Run(ctx, s.rdb, []string{redisKeyPrefix + key},
+		at.UnixMilli(), ttl.Milliseconds(),
+	).Slice()
+	if err != nil {
+		return Record{}, err
+	}
+	return parseRecord(res), nil
+}
+
+func (s *RedisStore) Lock(ctx context.Context, key string, until time.Time) error {
+	key = redisKeyPrefix + key
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, key, fieldFailures)
+		pipe.HSet(ctx, key, fieldLockedUntil, until.UnixMilli())
+		pipe.ExpireAt(ctx, key, until)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) Reset(ctx context.Context, key string) error {
+	return s.rdb.Del(ctx, redisKeyPrefix+key).Err()
+}
+
+// parseRecord reads the values of recordFields, as integers from reserveScript
+// or strings from HMGET.
+func parseRecord(values []any) Record {
+	ints := make([]int64, len(recordFields))
+	for i, v := range values {
+		switch v := v.(type) {
+		case int64:
+			ints[i] = v
+		case string:
+			ints[i], _ = strconv.ParseInt(v, 10, 64)
+		}
+	}
+	return Record{
+		Failures:    int(ints[0]),
+		LastFailure: fromMilli(ints[1]),
+		LockedUntil: fromMilli(ints[2]),
+		Pending:     int(ints[3]),
+		LastAttempt: fromMilli(ints[4]),
+	}
+}
+
+func fromMilli(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
@@ -0,0 +1,285 @@
+// Package throttle slows down password guessing. Every failed login delays
+// the next attempt for the account and the client IP exponentially, and too
+// many failures lock them out for a while.
+package throttle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrThrottled = errors.New("too many failed login attempts")
+
+// ThrottledError tells when the next attempt is allowed.
+type ThrottledError struct {
+	RetryAfter time.Duration
+	// Locked is true during a lockout, rather than a backoff delay.
+	Locked bool
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%v, retry after %s", ErrThrottled, e.RetryAfter.Round(time.Second))
+}
+
+func (e *ThrottledError) Unwrap() error {
+	return ErrThrottled
+}
+
+// attemptHold is how long a reserved attempt counts when it is neither
+// recorded nor released, e.g. because the instance handling it stopped.
+const attemptHold = 30 * time.Second
+
+// Record is the failure history of an account or IP.
+type Record struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+	// Pending counts the attempts reserved since LastAttempt that are not
+	// recorded or released yet.
+	Pending     int
+	LastAttempt time.Time
+}
+
+// Policy decides whether a record allows another attempt.
+type Policy struct {
+	Max       int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Hold is how long reserved attempts count after the last one.
+	Hold time.Duration
+}
+
+// Store keeps the records, shared by the instances of the service.
+type Store interface {
+	// Reserve checks the record of key against p and, if it allows another
+	// attempt, counts one as pending, in one atomic step. It returns the
+	// record as it was checked and whether the attempt was reserved.
+	Reserve(ctx context.Context, key string, now time.Time, p Policy) (Record, bool, error)
+	// Release drops a pending attempt.
+	Release(ctx context.Context, key string) error
+	// AddFailure counts a failure at the given time, in place of a pending
+	// attempt if there is one, and keeps the record for ttl. It returns the
+	// updated record.
+	AddFailure(ctx context.Context, key string, at time.Time, ttl time.Duration) (Record, error)
+	// Lock locks key until the given time and clears its failures.
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+// Scope is what a limit applies to.
+type Scope string
+
+const (
+	ScopeAccount Scope = "account"
+	ScopeIP      Scope = "ip"
+)
+
+type Config struct {
+	// MaxAccountFailures and MaxIPFailures lock the account or IP out once
+	// reached. An IP may fail more often, as it can be shared.
+	MaxAccountFailures int `yaml:"max_account_failures" env:"LOGIN_MAX_ACCOUNT_FAILURES"`
+	MaxIPFailures      int `yaml:"max_ip_failures" env:"LOGIN_MAX_IP_FAILURES"`
+	// BaseDelay after the first failure doubles with every further one, up to
+	// MaxDelay.
+	BaseDelay time.Duration `yaml:"base_delay" env:"LOGIN_BASE_DELAY"`
+	MaxDelay  time.Duration `yaml:"max_delay" env:"LOGIN_MAX_DELAY"`
+	Lockout   time.Duration `yaml:"lockout" env:"LOGIN_LOCKOUT"`
+	// Window is how long failures are remembered.
+	Window time.Duration `yaml:"window" env:"LOGIN_FAILURE_WINDOW"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		MaxAccountFailures: 5,
+		MaxIPFailures:      20,
+		BaseDelay:          time.Second,
+		MaxDelay:           30 * time.Second,
+		Lockout:            15 * time.Minute,
+		Window:             15 * time.Minute,
+	}
+}
+
+func (c Config) Validate() error {
+	if c.MaxAccountFailures < 1 || c.MaxIPFailures < 1 {
+		return errors.New("LOGIN_MAX_ACCOUNT_FAILURES and LOGIN_MAX_IP_FAILURES must be at least 1")
+	}
+	if c.BaseDelay < 0 || c.MaxDelay < c.BaseDelay || c.Lockout <= 0 || c.Window <= 0 {
+		return errors.New("login throttling needs 0 <= LOGIN_BASE_DELAY <= LOGIN_MAX_DELAY and a positive LOGIN_LOCKOUT and LOGIN_FAILURE_WINDOW")
+	}
+	return nil
+}
+
+// Lockout describes a lockout started by a failure.
+type Lockout struct {
+	Scope    Scope
+	Until    time.Time
+	Failures int
+}
+
+type Limiter struct {
+	store Store
+	cfg   Config
+	now   func() time.Time
+}
+
+func NewLimiter(store Store, cfg Config) *Limiter {
+	return &Limiter{store: store, cfg: cfg, now: time.Now}
+}
+
+// WithClock replaces time.Now, for tests.
+func (l *Limiter) WithClock(now func() time.Time) *Limiter {
+	l.now = now
+	return l
+}
+
+// Allow returns a *ThrottledError when the account or ip must wait before
+// trying again. ip may be empty when unknown. Otherwise it reserves the
+// attempt, so concurrent attempts cannot pass before it is counted; the
+// caller must follow up with Failure, Success or Release.
+func (l *Limiter) Allow(ctx context.Context, account, ip string) error {
+	now := l.now()
+	var wait *ThrottledError
+	var reserved []target
+	for _, t := range l.targets(account, ip) {
+		p := l.policy(t)
+		rec, ok, err := l.store.Reserve(ctx, t.key, now, p)
+		if err != nil {
+			l.release(ctx, reserved)
+			return fmt.Errorf("failed to reserve login attempt: %w", err)
+		}
+		if ok {
+			reserved = append(reserved, t)
+			continue
+		}
+		e := p.throttled(rec, now)
+		if e == nil {
+			// The store saw a later record than the one it returned
+			e = &ThrottledError{RetryAfter: p.BaseDelay}
+		}
+		if wait == nil || e.RetryAfter > wait.RetryAfter {
+			wait = e
+		}
+	}
+	if wait != nil {
+		l.release(ctx, reserved)
+		return wait
+	}
+	return nil
+}
+
+// Release drops the attempt reserved by Allow when it neither failed nor
+// succeeded, e.g. because a second factor is still needed.
+func (l *Limiter) Release(ctx context.Context, account, ip string) error {
+	return l.release(ctx, l.targets(account, ip))
+}
+
+func (l *Limiter) release(ctx context.Context, targets []target) error {
+	var errs []error
+	for _, t := range targets {
+		if err := l.store.Release(ctx, t.key); err != nil {
+			errs = append(errs, fmt.Errorf("failed to release login attempt for %s: %w", t.scope, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Failure counts a failed login of account from ip, in place of the attempt
+// Allow reserved. It returns the lockouts this failure started, if any.
+func (l *Limiter) Failure(ctx context.Context, account, ip string) ([]Lockout, error) {
+	now := l.now()
+	var lockouts []Lockout
+	for _, t := range l.targets(account, ip) {
+		rec, err := l.store.AddFailure(ctx, t.key, now, l.cfg.Window)
+		if err != nil {
+			return lockouts, fmt.Errorf("failed to count login failure: %w", err)
+		}
+		if rec.Failures < t.max {
+			continue
+		}
+		until := now.Add(l.cfg.Lockout)
+		if err := l.store.Lock(ctx, t.key, until); err != nil {
+			return lockouts, fmt.Errorf("failed to lock out %s: %w", t.scope, err)
+		}
+		lockouts = append(lockouts, Lockout{Scope: t.scope, Until: until, Failures: rec.Failures})
+	}
+	return lockouts, nil
+}
+
+// Success clears the failures of account and releases the attempt reserved
+// for ip. The failures of the IP remain, so one valid account does not reset
+// an attacker's budget.
+func (l *Limiter) Success(ctx context.Context, account, ip string) error {
+	if err := l.store.Reset(ctx, accountKey(account)); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	return l.store.Release(ctx, ipKey(ip))
+}
+
+func (l *Limiter) policy(t target) Policy {
+	return Policy{Max: t.max, BaseDelay: l.cfg.BaseDelay, MaxDelay: l.cfg.MaxDelay, Hold: attemptHold}
+}
+
+// throttled delays the next attempt after failures only, so logins sharing
+// an IP, e.g. behind NAT, do not slow each other down. Pending attempts count
+// toward Max together with the failures, so concurrent guesses cannot exceed
+// it.
+func (p Policy) throttled(rec Record, now time.Time) *ThrottledError {
+	if now.Before(rec.LockedUntil) {
+		return &ThrottledError{RetryAfter: rec.LockedUntil.Sub(now), Locked: true}
+	}
+	pending := rec.Pending
+	if !now.Before(rec.LastAttempt.Add(p.Hold)) {
+		pending = 0
+	}
+
+	var next time.Time
+	if rec.Failures > 0 {
+		next = rec.LastFailure.Add(p.delay(rec.Failures))
+	}
+	if rec.Failures+pending >= p.Max && pending > 0 {
+		// Wait for the pending attempts, which may start a lockout
+		next = later(next, rec.LastAttempt.Add(p.Hold))
+	}
+	if now.Before(next) {
+		return &ThrottledError{RetryAfter: next.Sub(now)}
+	}
+	return nil
+}
+
+// delay is BaseDelay doubled for every failure after the first, up to
+// MaxDelay.
+func (p Policy) delay(failures int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < failures && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxDelay)
+}
+
+type target struct {
+	scope Scope
+	key   string
+	max   int
+}
+
+func (l *Limiter) targets(account, ip string) []target {
+	targets := []target{{scope: ScopeAccount, key: accountKey(account), max: l.cfg.MaxAccountFailures}}
+	if ip != "" {
+		targets = append(targets, target{scope: ScopeIP, key: ipKey(ip), max: l.cfg.MaxIPFailures})
+	}
+	return targets
+}
+
+func accountKey(account string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(account))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
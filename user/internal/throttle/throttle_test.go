@@ -0,0 +1,241 @@
+package throttle_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CP-Payne/taskflow/user/internal/throttle"
+)
+
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time          { return c.now }
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newLimiter(cfg throttle.Config) (*throttle.Limiter, *clock) {
+	c := &clock{now: time.Now()}
+	return throttle.NewLimiter(throttle.NewMemoryStore(), cfg).WithClock(c.Now), c
+}
+
+func retryAfter(t *testing.T, err error) *throttle.ThrottledError {
+	t.Helper()
+	var throttled *throttle.ThrottledError
+	if !errors.As(err, &throttled) || !errors.Is(err, throttle.ErrThrottled) {
+		t.Fatalf("expected a ThrottledError, got %v", err)
+	}
+	return throttled
+}
+
+func TestLimiterBackoff(t *testing.T) {
+	ctx := context.Background()
+	cfg := throttle.DefaultConfig()
+	cfg.MaxAccountFailures = 10
+	l, c := newLimiter(cfg)
+
+	if err := l.Allow(ctx, "ann@example.com", "10.0.0.1"); err != nil {
+		t.Fatalf("expected the first attempt to be allowed, got %v", err)
+	}
+
+	tests := []struct {
+		failures int
+		delay    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{5, 16 * time.Second},
+		{6, 30 * time.Second},
+		{8, 30 * time.Second},
+	}
+	failures := 0
+	for _, tt := range tests {
+		for ; failures < tt.failures; failures++ {
+			if _, err := l.Failure(ctx, "ann@example.com", "10.0.0.1"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// The email is matched case-insensitively
+		throttled := retryAfter(t, l.Allow(ctx, "Ann@Example.com", ""))
+		if throttled.RetryAfter != tt.delay || throttled.Locked {
+			t.Errorf("after %d failures: expected a delay of %s, got %+v", tt.failures, tt.delay, throttled)
+		}
+		c.Advance(tt.delay)
+		if err := l.Allow(ctx, "ann@example.com", ""); err != nil {
+			t.Errorf("after %d failures: expected an attempt after the delay to be allowed, got %v", tt.failures, err)
+		}
+	}
+
+	if err := l.Success(ctx, "ann@example.com", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Allow(ctx, "ann@example.com", ""); err != nil {
+		t.Errorf("expected a success to clear the account's failures, got %v", err)
+	}
+}
+
+func TestLimiterLockout(t *testing.T) {
+	ctx := context.Background()
+	cfg := throttle.DefaultConfig()
+	cfg.MaxAccountFailures = 3
+	l, c := newLimiter(cfg)
+
+	var lockouts []throttle.Lockout
+	for range 3 {
+		var err error
+		if lockouts, err = l.Failure(ctx, "ann@example.com", "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(lockouts) != 1 || lockouts[0].Scope != throttle.ScopeAccount || lockouts[0].Failures != 3 ||
+		!lockouts[0].Until.Equal(c.Now().Add(cfg.Lockout)) {
+		t.Fatalf("expected the third failure to lock the account out, got %+v", lockouts)
+	}
+
+	throttled := retryAfter(t, l.Allow(ctx, "ann@example.com", "10.0.0.2"))
+	if !throttled.Locked || throttled.RetryAfter != cfg.Lockout {
+		t.Errorf("expected a lockout of %s, got %+v", cfg.Lockout, throttled)
+	}
+	if throttled := retryAfter(t, l.Allow(ctx, "bob@example.com", "10.0.0.1")); throttled.Locked {
+		t.Errorf("expected the IP to be delayed but stay below its limit, got %+v", throttled)
+	}
+
+	c.Advance(cfg.Lockout)
+	if err := l.Allow(ctx, "ann@example.com", "10.0.0.1"); err != nil {
+		t.Errorf("expected the lockout to end, got %v", err)
+	}
+	if lockouts, _ := l.Failure(ctx, "ann@example.com", ""); len(lockouts) != 0 {
+		t.Errorf("expected the lockout to reset the failures, got %+v", lockouts)
+	}
+}
+
+func TestLimiterIPLockout(t *testing.T) {
+	ctx := context.Background()
+	cfg := throttle.DefaultConfig()
+	cfg.MaxIPFailures = 4
+	l, _ := newLimiter(cfg)
+
+	var lockouts []throttle.Lockout
+	for _, account := range []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com"} {
+		var err error
+		if lockouts, err = l.Failure(ctx, account, "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(lockouts) != 1 || lockouts[0].Scope != throttle.ScopeIP {
+		t.Fatalf("expected failures on several accounts to lock the IP out, got %+v", lockouts)
+	}
+	if throttled := retryAfter(t, l.Allow(ctx, "e@example.com", "10.0.0.1")); !throttled.Locked {
+		t.Errorf("expected the IP to be locked out, got %+v", throttled)
+	}
+	if err := l.Allow(ctx, "e@example.com", "10.0.0.2"); err != nil {
+		t.Errorf("expected other IPs to be allowed, got %v", err)
+	}
+}
+
+func TestLimiterConcurrentAttempts(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  func(*throttle.Config)
+		max  int
+	}{
+		// Pending attempts count toward the lockout like failures
+		{"backoff", func(*throttle.Config) {}, 5},
+		{"lockout without delay", func(cfg *throttle.Config) { cfg.BaseDelay, cfg.MaxDelay = 0, 0 }, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := throttle.DefaultConfig()
+			cfg.MaxAccountFailures = 5
+			tt.cfg(&cfg)
+			l := throttle.NewLimiter(throttle.NewMemoryStore(), cfg)
+
+			// Every guess passes Allow before any of them is counted
+			start := make(chan struct{})
+			var passed atomic.Int32
+			var allowed, wg sync.WaitGroup
+			allowed.Add(50)
+			for range 50 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					ok := l.Allow(ctx, "ann@example.com", "10.0.0.1") == nil
+					allowed.Done()
+					allowed.Wait()
+					if ok {
+						passed.Add(1)
+						if _, err := l.Failure(ctx, "ann@example.com", "10.0.0.1"); err != nil {
+							t.Error(err)
+						}
+					}
+				}()
+			}
+			close(start)
+			wg.Wait()
+
+			if n := int(passed.Load()); n < 1 || n > tt.max {
+				t.Errorf("expected 1 to %d concurrent guesses to get through, got %d", tt.max, n)
+			}
+		})
+	}
+}
+
+func TestLimiterRelease(t *testing.T) {
+	ctx := context.Background()
+	cfg := throttle.DefaultConfig()
+	cfg.MaxAccountFailures = 2
+	cfg.MaxIPFailures = 3
+	l, _ := newLimiter(cfg)
+
+	// Pending attempts without failures are not delayed, e.g. logins behind
+	// a shared NAT address, until they reach the limit
+	for range 2 {
+		if err := l.Allow(ctx, "ann@example.com", "10.0.0.1"); err != nil {
+			t.Fatalf("expected a pending attempt not to delay the next one, got %v", err)
+		}
+	}
+	if throttled := retryAfter(t, l.Allow(ctx, "ann@example.com", "10.0.0.1")); throttled.Locked {
+		t.Errorf("expected pending attempts at the limit to delay the next one, got %+v", throttled)
+	}
+	if err := l.Release(ctx, "ann@example.com", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Allow(ctx, "ann@example.com", "10.0.0.1"); err != nil {
+		t.Errorf("expected a released attempt to allow the next one, got %v", err)
+	}
+	if err := l.Success(ctx, "ann@example.com", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Allow(ctx, "bob@example.com", "10.0.0.1"); err != nil {
+		t.Errorf("expected a success to release the IP's attempt, got %v", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*throttle.Config)
+		valid  bool
+	}{
+		{"default", func(*throttle.Config) {}, true},
+		{"no delay", func(c *throttle.Config) { c.BaseDelay = 0 }, true},
+		{"no failures allowed", func(c *throttle.Config) { c.MaxAccountFailures = 0 }, false},
+		{"max below base delay", func(c *throttle.Config) { c.MaxDelay = c.BaseDelay / 2 }, false},
+		{"no lockout", func(c *throttle.Config) { c.Lockout = 0 }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := throttle.DefaultConfig()
+			tt.modify(&cfg)
+			if err := cfg.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate() = %v, expected valid: %v", err, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CP-Payne/taskflow/user/internal/totp"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors.
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// The last 6 digits of the 8 digit codes in RFC 6238, appendix B
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		code, err := totp.Code(rfcSecret, totp.Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code() failed: %v", err)
+		}
+		if code != tt.code {
+			t.Errorf("at %d: expected %s, got %s", tt.unix, tt.code, code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	step := totp.Step(now)
+	codeAt := func(s int64) string {
+		code, err := totp.Code(secret, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		after    int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "Accept the current code", code: codeAt(step), wantStep: step, wantOK: true},
+		{name: "Accept the previous code", code: codeAt(step - 1), wantStep: step - 1, wantOK: true},
+		{name: "Accept the next code", code: codeAt(step + 1), wantStep: step + 1, wantOK: true},
+		{name: "Reject an older code", code: codeAt(step - 2)},
+		{name: "Reject a used code", code: codeAt(step), after: step},
+		{name: "Reject a code older than the used one", code: codeAt(step - 1), after: step},
+		{name: "Reject a malformed code", code: "12345"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := totp.Validate(secret, tt.code, now, tt.after)
+			if err != nil {
+				t.Fatalf("Validate() failed: %v", err)
+			}
+			if ok != tt.wantOK || got != tt.wantStep {
+				t.Errorf("expected step %d, %v, got %d, %v", tt.wantStep, tt.wantOK, got, ok)
+			}
+		})
+	}
+
+	if _, _, err := totp.Validate("not base32!", "123456", now, 0); err == nil {
+		t.Error("expected an invalid secret to fail")
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri, err := url.Parse(totp.URI("Taskflow", "jane@example.com", "JBSWY3DPEHPK3PXP"))
+	if err != nil {
+		t.Fatalf("invalid URI: %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/Taskflow:jane@example.com" {
+		t.Errorf("unexpected URI %s", uri)
+	}
+	query := uri.Query()
+	if query.Get("secret") != "JBSWY3DPEHPK3PXP" || query.Get("issuer") != "Taskflow" || query.Get("digits") != "6" || query.Get("period") != "30" {
+		t.Errorf("unexpected parameters %v", query)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := totp.GenerateRecoveryCodes(totp.RecoveryCodeCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != totp.RecoveryCodeCount || len(hashes) != len(codes) {
+		t.Fatalf("expected %d codes and hashes, got %d and %d", totp.RecoveryCodeCount, len(codes), len(hashes))
+	}
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' || seen[code] {
+			t.Errorf("unexpected or repeated code %q", code)
+		}
+		seen[code] = true
+	}
+
+	entered := strings.ToUpper(strings.ReplaceAll(codes[3], "-", " "))
+	if i := totp.MatchRecoveryCode(hashes, entered); i != 3 {
+		t.Errorf("expected the code to match ignoring case and separators, got index %d", i)
+	}
+	if i := totp.MatchRecoveryCode(hashes, "aaaaa-aaaaa"); i != -1 {
+		t.Errorf("expected an unknown code not to match, got index %d", i)
+	}
+}